@@ -6,6 +6,7 @@ package api
 
 import (
     "errors"
+    "log/slog"
     "strconv"
     "time"
 )
@@ -18,6 +19,9 @@ var (
     ErrTimeNull = errors.New("times list empty")
     ErrNoOffer = errors.New("table is not offered on given date")
     ErrNoPayInfo = errors.New("no payment info on account")
+    ErrNoList = errors.New("service can not list reservations")
+    ErrNoCancel = errors.New("service can not cancel reservations")
+    ErrBookUnknown = errors.New("book failed without an answer, it may have gone through")
 )
 
 
@@ -45,6 +49,9 @@ type LoginParam struct {
     Mobile          string 
     Email           string
     Password        string
+    // Where the service records the timing of its
+    // requests, nothing is recorded if it is nil
+    Trace           *Trace
 }
 
 /*
@@ -101,6 +108,9 @@ type SearchResult struct {
 Name: TableType 
 Type: API Input Struct
 Purpose: Allow an opaque interface for choosing table/seating type
+Note: A TableType is a pattern matched against the seating
+type a service names, see 'Matches'. The constants below are
+plain patterns for common seating
 */
 type TableType string
 
@@ -114,19 +124,183 @@ const (
     Booth                = "booth"
 )
 
+// WindowPolicy type is an enum, only use with next const def types
+type WindowPolicy int
+
+const (
+    // the zero value prefers the slot closest to the target
+    ClosestWindowPolicy WindowPolicy = iota
+    EarliestWindowPolicy
+    LatestWindowPolicy
+)
+
+/*
+Name: TimeWindow
+Type: API Input Struct
+Purpose: A range of acceptable reservation times, and 
+which slot in the range to prefer
+Note: Target is only used by the closest policy, and 
+defaults to the middle of the window. A window whose
+Start and End are equal accepts only that time
+*/
+type TimeWindow struct {
+    Start            time.Time
+    End              time.Time
+    Target           time.Time
+    Policy           WindowPolicy
+}
+
+/*
+Name: PointWindow
+Type: API Func
+Purpose: Make a window which accepts exactly one time
+*/
+func PointWindow(t time.Time) (TimeWindow) {
+    return TimeWindow{Start: t, End: t, Target: t}
+}
+
+/*
+Name: Contains
+Type: API Func
+Purpose: Report whether a slot time is in the window,
+to the minute
+*/
+func (w TimeWindow) Contains(t time.Time) (bool) {
+    t = t.Truncate(time.Minute)
+    return !t.Before(w.Start.Truncate(time.Minute)) && !t.After(w.End.Truncate(time.Minute))
+}
+
+/*
+Name: Preferred
+Type: API Func
+Purpose: Return the time the window's policy likes best
+*/
+func (w TimeWindow) Preferred() (time.Time) {
+    switch w.Policy {
+    case EarliestWindowPolicy:
+        return w.Start
+    case LatestWindowPolicy:
+        return w.End
+    }
+    if w.Target.IsZero() {
+        return w.Start.Add(w.End.Sub(w.Start) / 2)
+    }
+    return w.Target
+}
+
+/*
+Name: Prefers
+Type: API Func
+Purpose: Report whether the window's policy ranks t1
+before t2. Ties go to the earlier time
+*/
+func (w TimeWindow) Prefers(t1 time.Time, t2 time.Time) (bool) {
+    switch w.Policy {
+    case EarliestWindowPolicy:
+        return t1.Before(t2)
+    case LatestWindowPolicy:
+        return t1.After(t2)
+    }
+    target := w.Preferred()
+    d1 := t1.Sub(target)
+    if d1 < 0 {
+        d1 = -d1
+    }
+    d2 := t2.Sub(target)
+    if d2 < 0 {
+        d2 = -d2
+    }
+    if d1 == d2 {
+        return t1.Before(t2)
+    }
+    return d1 < d2
+}
+
 /*
 Name: ReserveParam
 Type: API Func Input Struct
 Purpose: Input information to the 'Reserve' api function 
+Note: If TimeWindows is set it takes the place of
+ReservationTimes as the priority list. Entries may fall
+on different days, each is searched on its own date
 */
 type ReserveParam struct {
     VenueID          int64
     ReservationTimes []time.Time
+    TimeWindows      []TimeWindow
     PartySize        int
+    // Sizes to fall back on, in priority order, if
+    // nothing is open for PartySize
+    AltPartySizes    []int
     TableTypes       []TableType
+    // Seating never booked, whatever TableTypes says
+    ExcludeTableTypes []TableType
+    // Order to try open slots in, see 'SlotSelector'
+    Selector         SlotSelector
+    // Fees accepted, slots above the limit are skipped
+    FeeLimit         FeeLimit
+    // Go through every step but the final book call,
+    // returning the slot that would have been booked
+    DryRun           bool
+    // Slots to fetch booking details for at once, where
+    // services have such a step. Slots are still booked
+    // one at a time in rank order. One if unset
+    ParallelDetails  int
+    // Time after which the service's failed requests are
+    // no longer retried, see 'RetryTransport'. Only the
+    // retry policy limits retries if zero
+    Deadline         time.Time
+    // Where the service logs its steps, usually tagged
+    // with the operation making the request. Services
+    // fall back on their own logger if it is nil
+    Logger           *slog.Logger
+    // As in LoginParam
+    Trace            *Trace
     LoginResp        LoginResponse
 }
 
+/*
+Name: Windows
+Type: API Func
+Purpose: Return the priority list of a request as windows,
+turning each of ReservationTimes into a point window if no
+TimeWindows are set
+*/
+func (p ReserveParam) Windows() ([]TimeWindow) {
+    if len(p.TimeWindows) != 0 {
+        return p.TimeWindows
+    }
+    windows := make([]TimeWindow, len(p.ReservationTimes))
+    for i, t := range p.ReservationTimes {
+        windows[i] = PointWindow(t)
+    }
+    return windows
+}
+
+/*
+Name: PartySizes
+Type: API Func
+Purpose: Return the party sizes of a request in the
+order to try them, PartySize first and then each
+alternate not already listed
+*/
+func (p ReserveParam) PartySizes() ([]int) {
+    sizes := []int{p.PartySize}
+    for _, size := range p.AltPartySizes {
+        listed := false
+        for _, prev := range sizes {
+            if prev == size {
+                listed = true
+                break
+            }
+        }
+        if !listed {
+            sizes = append(sizes, size)
+        }
+    }
+    return sizes
+}
+
 /*
 Name: ReserveResponse
 Type: API Func Output Struct
@@ -134,6 +308,115 @@ Purpose: Output information from the 'Reserve' api function
 */
 type ReserveResponse struct {
     ReservationTime time.Time
+    ReservationID   string
+    VenueName       string
+    VenueAddress    string
+    // The table type from the request list that was booked,
+    // empty if the request listed none
+    TableType       TableType
+    // The party size booked, which is one of the 
+    // alternates if the preferred size had no table
+    PartySize       int
+    // Fees the service listed for the slot booked
+    Fees            FeePolicy
+    // Opaque token to hand to 'Cancel', empty if the
+    // service gave none
+    CancelToken     string
+    // Set if the request was a dry run, in which case
+    // nothing was booked and there is no ReservationID
+    // or CancelToken
+    DryRun          bool
+}
+
+/*
+Name: CancelParam
+Type: API Func Input Struct
+Purpose: Input information to the 'Cancel' api function 
+*/
+type CancelParam struct {
+    CancelToken     string
+    LoginResp       LoginResponse
+}
+
+/*
+Name: CancelResponse
+Type: API Func Output Struct
+Purpose: Output information from the 'Cancel' api function 
+*/
+type CancelResponse struct {
+    Refund          bool
+}
+
+/*
+Name: FindParam
+Type: API Func Input Struct
+Purpose: Input information to the 'Find' api function 
+Note: Only the date of Day is used by services which list
+a whole day. Services which only list slots near a time 
+look around the clock time of Day
+*/
+type FindParam struct {
+    VenueID          int64
+    Day              time.Time
+    PartySize        int
+    // As in ReserveParam
+    Logger           *slog.Logger
+    Trace            *Trace
+    LoginResp        LoginResponse
+}
+
+/*
+Name: Slot
+Type: API Output Struct
+Purpose: Output specific results from 'Find' api function 
+*/
+type Slot struct {
+    Time            time.Time
+    // Seating as the service names it, i.e. "Dining Room"
+    TableType       string
+}
+
+/*
+Name: FindResponse
+Type: API Func Output Struct
+Purpose: Output information from the 'Find' api function 
+*/
+type FindResponse struct {
+    VenueName       string
+    VenueAddress    string
+    Slots           []Slot
+}
+
+/*
+Name: ReservationsParam
+Type: API Func Input Struct
+Purpose: Input information to the 'Reservations' api function 
+*/
+type ReservationsParam struct {
+    LoginResp       LoginResponse
+}
+
+/*
+Name: Reservation
+Type: API Output Struct
+Purpose: Output specific results from 'Reservations' api function 
+*/
+type Reservation struct {
+    ReservationID   string
+    VenueID         int64
+    VenueName       string
+    VenueAddress    string
+    ReservationTime time.Time
+    PartySize       int
+}
+
+/*
+Name: ReservationsResponse
+Type: API Func Output Struct
+Purpose: Output information from the 'Reservations' api function 
+*/
+type ReservationsResponse struct {
+    Reservations []Reservation
 }
 
 /*
@@ -150,6 +433,40 @@ type API interface {
     AuthMinExpire() (time.Duration)
 }
 
+/*
+Name: ReservationLister
+Type: Interface 
+Purpose: Optional behavior for external services which
+can list the upcoming reservations on an account. Consumers
+should check for it with a type assertion on an API
+*/
+type ReservationLister interface {
+    Reservations(params ReservationsParam) (*ReservationsResponse, error)
+}
+
+/*
+Name: Canceller
+Type: Interface 
+Purpose: Optional behavior for external services which
+can cancel a reservation made by 'Reserve'. Consumers
+should check for it with a type assertion on an API
+*/
+type Canceller interface {
+    Cancel(params CancelParam) (*CancelResponse, error)
+}
+
+/*
+Name: Finder
+Type: Interface 
+Purpose: Optional behavior for external services which
+can list the open slots at a venue without booking any.
+Consumers should check for it with a type assertion on
+an API
+*/
+type Finder interface {
+    Find(params FindParam) (*FindResponse, error)
+}
+
 /*
 Name: SearchResponse.ToString 
 Type: Stringify Func
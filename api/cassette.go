@@ -0,0 +1,539 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+var (
+    ErrNoInteraction = errors.New("no recorded interaction matches the request")
+)
+
+/*
+Name: Cassette
+Type: API Struct
+Purpose: HTTP exchanges an operation made, recorded by a
+Recorder in the order they were made, with secrets redacted so the file
+can be attached to a bug report. A Replayer feeds them
+back to a service
+*/
+type Cassette struct {
+    RecordedAt      time.Time               `json:"recorded_at"`
+    Interactions    []Interaction           `json:"interactions"`
+}
+
+/*
+Name: Interaction
+Type: API Struct
+Purpose: One request and the response it got
+*/
+type Interaction struct {
+    Request         RecordedRequest         `json:"request"`
+    Response        RecordedResponse        `json:"response"`
+}
+
+/*
+Name: RecordedRequest
+Type: API Struct
+Purpose: A request as a cassette keeps it
+*/
+type RecordedRequest struct {
+    Method          string                  `json:"method"`
+    URL             string                  `json:"url"`
+    Header          http.Header             `json:"header,omitempty"`
+    Body            string                  `json:"body,omitempty"`
+}
+
+/*
+Name: RecordedResponse
+Type: API Struct
+Purpose: A response as a cassette keeps it
+*/
+type RecordedResponse struct {
+    Status          int                     `json:"status"`
+    Header          http.Header             `json:"header,omitempty"`
+    Body            string                  `json:"body,omitempty"`
+}
+
+/*
+Name: ReadCassette
+Type: API Func
+Purpose: Read a cassette written by WriteCassette
+*/
+func ReadCassette(r io.Reader) (*Cassette, error) {
+    var cassette Cassette
+    err := json.NewDecoder(r).Decode(&cassette)
+    if err != nil {
+        return nil, err
+    }
+    return &cassette, nil
+}
+
+/*
+Name: LoadCassette
+Type: API Func
+Purpose: Read a cassette from a file
+*/
+func LoadCassette(path string) (*Cassette, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+    return ReadCassette(file)
+}
+
+/*
+Name: WriteCassette
+Type: API Func
+Purpose: Write a cassette as indented JSON
+*/
+func WriteCassette(w io.Writer, cassette *Cassette) (error) {
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(cassette)
+}
+
+/*
+Name: SaveCassette
+Type: API Func
+Purpose: Write a cassette to a file, replacing it
+*/
+func SaveCassette(path string, cassette *Cassette) (error) {
+    file, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    err = WriteCassette(file, cassette)
+    if err != nil {
+        file.Close()
+        return err
+    }
+    return file.Close()
+}
+
+/*
+Name: Recorder
+Type: API Struct
+Purpose: Record the HTTP exchanges of chosen operations,
+each onto a cassette of its own, with secrets redacted.
+Requests are told apart by the operation id they are
+tagged with, see 'WithOperation', so operations running
+at once never share a cassette. Requests of operations
+not being recorded, or not tagged, are only passed on
+*/
+type Recorder struct {
+    mu              sync.Mutex
+    // keyed by operation id
+    cassettes       map[int64]*Cassette
+}
+
+/*
+Name: DefaultRecorder
+Type: API Var
+Purpose: The recorder under every client made by NewClient,
+so the requests of each service can be recorded
+*/
+var DefaultRecorder = &Recorder{}
+
+/*
+Name: Start
+Type: API Func
+Purpose: Begin recording an operation's requests onto an
+empty cassette, dropping whatever was recorded for it
+*/
+func (r *Recorder) Start(id int64) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if r.cassettes == nil {
+        r.cassettes = map[int64]*Cassette{}
+    }
+    r.cassettes[id] = &Cassette{RecordedAt: time.Now(), Interactions: []Interaction{}}
+}
+
+/*
+Name: Recording
+Type: API Func
+Purpose: Report whether an operation's requests are
+being recorded
+*/
+func (r *Recorder) Recording(id int64) (bool) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    _, ok := r.cassettes[id]
+    return ok
+}
+
+/*
+Name: Stop
+Type: API Func
+Purpose: Stop recording an operation and return the
+cassette recorded since Start, nil if it wasn't started
+*/
+func (r *Recorder) Stop(id int64) (*Cassette) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    cassette := r.cassettes[id]
+    delete(r.cassettes, id)
+    return cassette
+}
+
+/*
+Name: Transport
+Type: API Func
+Purpose: Wrap a transport so the exchanges sent through
+it are recorded by this recorder. Base is
+http.DefaultTransport if nil
+*/
+func (r *Recorder) Transport(base http.RoundTripper) (http.RoundTripper) {
+    if base == nil {
+        base = http.DefaultTransport
+    }
+    return &recordingTransport{recorder: r, base: base}
+}
+
+/*
+Name: record
+Type: Internal Func
+Purpose: Add an exchange to an operation's cassette, if
+it is still being recorded
+*/
+func (r *Recorder) record(id int64, interaction Interaction) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    // it may have been stopped while the request was out
+    if cassette, ok := r.cassettes[id]; ok {
+        cassette.Interactions = append(cassette.Interactions, interaction)
+    }
+}
+
+/*
+Name: recordingTransport
+Type: http.RoundTripper
+Purpose: Middleware handing the exchanges of recorded
+operations to a Recorder, see 'Recorder.Transport'
+*/
+type recordingTransport struct {
+    recorder        *Recorder
+    base            http.RoundTripper
+}
+
+/*
+Name: RoundTrip
+Type: http.RoundTripper method
+Purpose: Satisfy the http.RoundTripper interface
+*/
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    id, ok := RequestOperation(req)
+    if !ok || !t.recorder.Recording(id) {
+        return t.base.RoundTrip(req)
+    }
+    reqBody, err := copyRequestBody(req)
+    if err != nil {
+        return nil, err
+    }
+    resp, err := t.base.RoundTrip(req)
+    if err != nil {
+        // nothing came back to replay
+        return resp, err
+    }
+    respBody, err := io.ReadAll(resp.Body)
+    resp.Body.Close()
+    if err != nil {
+        return nil, err
+    }
+    resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+    t.recorder.record(id, Interaction{
+        Request: recordRequest(req, reqBody),
+        Response: RecordedResponse{
+            Status: resp.StatusCode,
+            Header: redactHeader(resp.Header),
+            Body: redactBody(respBody),
+        },
+    })
+    return resp, nil
+}
+
+// Key of the operation id a request is tagged with
+type operationKey struct{}
+
+/*
+Name: WithOperation
+Type: API Func
+Purpose: Return the request tagged with the id of the
+operation sending it, so a Recorder can tell it apart.
+Traces made by NewOperationTrace tag the requests they
+start
+*/
+func WithOperation(req *http.Request, id int64) (*http.Request) {
+    return req.WithContext(context.WithValue(req.Context(), operationKey{}, id))
+}
+
+/*
+Name: RequestOperation
+Type: API Func
+Purpose: Return the operation id a request is tagged
+with, if any
+*/
+func RequestOperation(req *http.Request) (int64, bool) {
+    id, ok := req.Context().Value(operationKey{}).(int64)
+    return id, ok
+}
+
+/*
+Name: Replayer
+Type: http.RoundTripper
+Purpose: Answer requests from a cassette instead of the
+network. A request gets the first unused interaction with
+the same method and URL, preferring one whose body matches
+too, so a service makes the same calls it did when it was
+recorded. Requests are compared after redaction, as they
+were recorded. A request nothing matches fails with
+ErrNoInteraction
+*/
+type Replayer struct {
+    mu              sync.Mutex
+    interactions    []Interaction
+    used            []bool
+}
+
+/*
+Name: NewReplayer
+Type: API Func
+Purpose: Make a replayer over a cassette's interactions
+*/
+func NewReplayer(cassette *Cassette) (*Replayer) {
+    return &Replayer{
+        interactions: cassette.Interactions,
+        used: make([]bool, len(cassette.Interactions)),
+    }
+}
+
+/*
+Name: Unused
+Type: API Func
+Purpose: Return the number of interactions not replayed
+yet, so tests can check a service made every call
+*/
+func (r *Replayer) Unused() (int) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    unused := 0
+    for _, used := range r.used {
+        if !used {
+            unused += 1
+        }
+    }
+    return unused
+}
+
+/*
+Name: RoundTrip
+Type: http.RoundTripper method
+Purpose: Satisfy the http.RoundTripper interface
+*/
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+    reqBody, err := copyRequestBody(req)
+    if err != nil {
+        return nil, err
+    }
+    if req.Body != nil {
+        req.Body.Close()
+    }
+    recorded := recordRequest(req, reqBody)
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    match := -1
+    for i, interaction := range r.interactions {
+        if r.used[i] || interaction.Request.Method != recorded.Method || interaction.Request.URL != recorded.URL {
+            continue
+        }
+        if interaction.Request.Body == recorded.Body {
+            match = i
+            break
+        }
+        if match == -1 {
+            match = i
+        }
+    }
+    if match == -1 {
+        return nil, ErrNoInteraction
+    }
+    r.used[match] = true
+    response := r.interactions[match].Response
+    header := response.Header.Clone()
+    if header == nil {
+        header = http.Header{}
+    }
+    return &http.Response{
+        Status: http.StatusText(response.Status),
+        StatusCode: response.Status,
+        Proto: "HTTP/1.1",
+        ProtoMajor: 1,
+        ProtoMinor: 1,
+        Header: header,
+        Body: io.NopCloser(strings.NewReader(response.Body)),
+        ContentLength: int64(len(response.Body)),
+        Request: req,
+    }, nil
+}
+
+/*
+Name: NewReplayClient
+Type: API Func
+Purpose: Make a client answering from a cassette, for a
+service's Client field. Recorded failures are retried as
+a client from NewClient would, without the waits
+*/
+func NewReplayClient(cassette *Cassette) (*http.Client, *Replayer) {
+    replayer := NewReplayer(cassette)
+    policy := RetryPolicy{MaxRetries: DefaultRetryPolicy.MaxRetries}
+    return &http.Client{Transport: &RetryTransport{Base: replayer, Policy: policy}}, replayer
+}
+
+/*
+Name: copyRequestBody
+Type: Internal Func
+Purpose: Read a request's body without using it up
+*/
+func copyRequestBody(req *http.Request) ([]byte, error) {
+    if req.Body == nil || req.Body == http.NoBody {
+        return nil, nil
+    }
+    if req.GetBody != nil {
+        body, err := req.GetBody()
+        if err != nil {
+            return nil, err
+        }
+        defer body.Close()
+        return io.ReadAll(body)
+    }
+    body, err := io.ReadAll(req.Body)
+    req.Body.Close()
+    if err != nil {
+        return nil, err
+    }
+    req.Body = io.NopCloser(bytes.NewReader(body))
+    return body, nil
+}
+
+/*
+Name: recordRequest
+Type: Internal Func
+Purpose: Make the redacted record of a request
+*/
+func recordRequest(req *http.Request, body []byte) (RecordedRequest) {
+    return RecordedRequest{
+        Method: req.Method,
+        URL: Redact(req.URL.String()),
+        Header: redactHeader(req.Header),
+        Body: redactBody(body),
+    }
+}
+
+/*
+Name: redactBody
+Type: Internal Func
+Purpose: Scrub a body as Redact does, except that JSON keeps
+its shape, so a service replaying it still finds every field
+where it expects it. Secret strings become Redacted, secret
+numbers 0, and everything under a secret key is scrubbed
+*/
+func redactBody(body []byte) (string) {
+    decoder := json.NewDecoder(bytes.NewReader(body))
+    decoder.UseNumber()
+    var value interface{}
+    if decoder.Decode(&value) != nil || decoder.More() {
+        return Redact(string(body))
+    }
+    redacted, err := json.Marshal(redactJSON(value, false))
+    if err != nil {
+        return Redact(string(body))
+    }
+    return string(redacted)
+}
+
+/*
+Name: redactJSON
+Type: Internal Func
+Purpose: Scrub a decoded JSON value, secret if it sits
+under a SecretKeys key
+*/
+func redactJSON(value interface{}, secret bool) (interface{}) {
+    switch v := value.(type) {
+    case map[string]interface{}:
+        for key, field := range v {
+            v[key] = redactJSON(field, secret || isSecretKey(key))
+        }
+        return v
+    case []interface{}:
+        for i, elem := range v {
+            v[i] = redactJSON(elem, secret)
+        }
+        return v
+    case string:
+        if secret {
+            return Redacted
+        }
+        return Redact(v)
+    case json.Number:
+        // a registered secret, like a payment method id
+        if secret || Redact(v.String()) != v.String() {
+            return json.Number("0")
+        }
+        return v
+    }
+    return value
+}
+
+/*
+Name: isSecretKey
+Type: Internal Func
+Purpose: Report whether a field or header name is one
+of SecretKeys
+*/
+func isSecretKey(key string) (bool) {
+    lower := strings.ToLower(key)
+    for _, secretKey := range SecretKeys {
+        if lower == secretKey {
+            return true
+        }
+    }
+    return false
+}
+
+/*
+Name: redactHeader
+Type: Internal Func
+Purpose: Copy a header with the values of SecretKeys
+headers and cookies replaced, and the rest scrubbed
+*/
+func redactHeader(header http.Header) (http.Header) {
+    redacted := http.Header{}
+    for key, values := range header {
+        lower := strings.ToLower(key)
+        secret := isSecretKey(key) || lower == "cookie" || lower == "set-cookie"
+        for _, value := range values {
+            if secret {
+                redacted.Add(key, Redacted)
+            } else {
+                redacted.Add(key, Redact(value))
+            }
+        }
+    }
+    return redacted
+}
@@ -0,0 +1,122 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "io"
+    "net/http"
+    "strings"
+    "sync"
+    "testing"
+)
+
+/*
+Name: echoTransport
+Type: Internal Test Struct
+Purpose: A RoundTripper answering every request with
+its path
+*/
+type echoTransport struct{}
+
+func (echoTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    if req.Body != nil {
+        io.Copy(io.Discard, req.Body)
+        req.Body.Close()
+    }
+    return &http.Response{
+        StatusCode: 200,
+        Header: http.Header{},
+        Body: io.NopCloser(strings.NewReader(`{"path":"` + req.URL.Path + `","token":"answer-secret"}`)),
+    }, nil
+}
+
+func TestRecorderKeepsOperationsApart(t *testing.T) {
+    recorder := &Recorder{}
+    client := &http.Client{Transport: recorder.Transport(echoTransport{})}
+    recorder.Start(1)
+    recorder.Start(2)
+
+    send := func(trace *Trace, path string) {
+        req, err := http.NewRequest("POST", "https://example.com" + path + "?api_key=k1", strings.NewReader(`{"password":"hunter22"}`))
+        if err != nil {
+            t.Error(err)
+            return
+        }
+        req, span := trace.Start("test", FindStep, req)
+        resp, err := client.Do(req)
+        span.End(resp, err)
+        if err != nil {
+            t.Error(err)
+            return
+        }
+        resp.Body.Close()
+    }
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        wg.Add(3)
+        go func() { defer wg.Done(); send(NewOperationTrace(1), "/one") }()
+        go func() { defer wg.Done(); send(NewOperationTrace(2), "/two") }()
+        // not being recorded, or not an operation's
+        go func() { defer wg.Done(); send(NewOperationTrace(3), "/three"); send(NewTrace(), "/none") }()
+    }
+    wg.Wait()
+
+    if recorder.Recording(3) {
+        t.Error("recording an operation that was never started")
+    }
+    for id, path := range map[int64]string{1: "/one", 2: "/two"} {
+        cassette := recorder.Stop(id)
+        if cassette == nil {
+            t.Fatalf("no cassette for operation %d", id)
+        }
+        if len(cassette.Interactions) != 10 {
+            t.Errorf("operation %d recorded %d requests, want 10", id, len(cassette.Interactions))
+        }
+        for _, interaction := range cassette.Interactions {
+            if !strings.Contains(interaction.Request.URL, path) {
+                t.Errorf("operation %d recorded %s", id, interaction.Request.URL)
+            }
+            for _, leak := range []string{"k1", "hunter22", "answer-secret"} {
+                if strings.Contains(interaction.Request.URL + interaction.Request.Body + interaction.Response.Body, leak) {
+                    t.Errorf("cassette leaked %q", leak)
+                }
+            }
+        }
+        if recorder.Stop(id) != nil {
+            t.Errorf("operation %d still recording after Stop", id)
+        }
+    }
+}
+
+func TestReplayerMatching(t *testing.T) {
+    cassette := &Cassette{Interactions: []Interaction{
+        {Request: RecordedRequest{Method: "GET", URL: "https://example.com/a"}, Response: RecordedResponse{Status: 500}},
+        {Request: RecordedRequest{Method: "GET", URL: "https://example.com/a"}, Response: RecordedResponse{Status: 200, Body: "ok"}},
+        {Request: RecordedRequest{Method: "POST", URL: "https://example.com/b", Body: `{"n":2}`}, Response: RecordedResponse{Status: 201, Body: "two"}},
+        {Request: RecordedRequest{Method: "POST", URL: "https://example.com/b", Body: `{"n":1}`}, Response: RecordedResponse{Status: 201, Body: "one"}},
+    }}
+    client, replayer := NewReplayClient(cassette)
+
+    // the 500 is retried, as it was when recorded
+    resp, err := client.Get("https://example.com/a")
+    if err != nil || resp.StatusCode != 200 {
+        t.Fatalf("GET = %v, %v, want the retried 200", resp, err)
+    }
+    // bodies pick between requests to the same url
+    resp, err = client.Post("https://example.com/b", "application/json", strings.NewReader(`{"n":1}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+    body, _ := io.ReadAll(resp.Body)
+    if string(body) != "one" {
+        t.Errorf("POST n=1 got %q, want one", body)
+    }
+    if replayer.Unused() != 1 {
+        t.Errorf("unused = %d, want 1", replayer.Unused())
+    }
+    if _, err := client.Get("https://example.com/a"); err == nil {
+        t.Error("a request with nothing left to replay succeeded")
+    }
+}
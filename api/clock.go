@@ -0,0 +1,278 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "encoding/binary"
+    "errors"
+    "io"
+    "log/slog"
+    "net"
+    "net/http"
+    "time"
+)
+
+var (
+    ErrNoDate = errors.New("response has no usable Date header")
+    ErrNTP = errors.New("invalid NTP response")
+)
+
+const (
+    // Sources of a ClockOffset
+    DateClockSource = "date"
+    NTPClockSource = "ntp"
+    // Offsets from both sources agreeing
+    CombinedClockSource = "date+ntp"
+    // Date header samples taken by default
+    DefaultClockSamples = 5
+    // Seconds between the NTP era and the unix epoch
+    ntpEpochOffset = 2208988800
+)
+
+/*
+Name: ClockOffset
+Type: API Struct
+Purpose: An estimate of how far a clock is ahead of the
+local one. The true offset lies within Uncertainty of
+Offset
+*/
+type ClockOffset struct {
+    // Other clock minus the local clock
+    Offset          time.Duration
+    Uncertainty     time.Duration
+    // One of the ClockSource consts
+    Source          string
+    Samples         int
+    MeasuredAt      time.Time
+}
+
+/*
+Name: bounds
+Type: Internal Func
+Purpose: Return the range the true offset lies in
+*/
+func (c ClockOffset) bounds() (time.Duration, time.Duration) {
+    return c.Offset - c.Uncertainty, c.Offset + c.Uncertainty
+}
+
+/*
+Name: offsetBetween
+Type: Internal Func
+Purpose: Make an offset covering the range lo to hi
+*/
+func offsetBetween(lo time.Duration, hi time.Duration) (ClockOffset) {
+    return ClockOffset{Offset: (lo + hi) / 2, Uncertainty: (hi - lo) / 2}
+}
+
+/*
+Name: Combine
+Type: API Func
+Purpose: Narrow an offset with another measured against
+the same clock. If the two ranges overlap the result is
+their overlap, otherwise one of them is wrong and the
+receiver is kept
+*/
+func (c ClockOffset) Combine(other ClockOffset) (ClockOffset) {
+    lo, hi := c.bounds()
+    otherLo, otherHi := other.bounds()
+    if otherLo > lo {
+        lo = otherLo
+    }
+    if otherHi < hi {
+        hi = otherHi
+    }
+    if lo > hi {
+        return c
+    }
+    combined := offsetBetween(lo, hi)
+    combined.Source = c.Source
+    if other.Source != c.Source {
+        combined.Source = CombinedClockSource
+    }
+    combined.Samples = c.Samples + other.Samples
+    combined.MeasuredAt = c.MeasuredAt
+    if other.MeasuredAt.After(combined.MeasuredAt) {
+        combined.MeasuredAt = other.MeasuredAt
+    }
+    return combined
+}
+
+/*
+Name: OffsetFromDate
+Type: API Func
+Purpose: Estimate a server's clock offset from the Date
+header of a response to a request sent at sent and
+answered at received, both on the local clock
+Note: Date only has whole seconds, so the server stamped
+it at some point in the second it names, at some local
+time between sent and received. A single sample is
+good to about half a second plus half the round trip
+*/
+func OffsetFromDate(date string, sent time.Time, received time.Time) (ClockOffset, error) {
+    serverTime, err := http.ParseTime(date)
+    if err != nil {
+        return ClockOffset{}, ErrNoDate
+    }
+    lo := serverTime.Sub(received)
+    hi := serverTime.Add(time.Second).Sub(sent)
+    offset := offsetBetween(lo, hi)
+    offset.Source = DateClockSource
+    offset.Samples = 1
+    offset.MeasuredAt = received
+    return offset, nil
+}
+
+/*
+Name: MeasureClock
+Type: API Func
+Purpose: Estimate the clock offset of the server behind url
+from the Date headers of samples HEAD requests, combining
+them. The requests are spread over a little more than a
+second, so the second boundaries they straddle narrow the
+estimate down
+*/
+func MeasureClock(client *http.Client, url string, samples int, logger *slog.Logger) (*ClockOffset, error) {
+    if samples <= 0 {
+        samples = DefaultClockSamples
+    }
+    spacing := time.Second / time.Duration(samples) + 7 * time.Millisecond
+    var measured *ClockOffset
+    var lastErr error
+    for i := 0; i < samples; i++ {
+        if i > 0 {
+            time.Sleep(spacing)
+        }
+        request, err := http.NewRequest("HEAD", url, nil)
+        if err != nil {
+            return nil, err
+        }
+        request.Header.Set("user-agent", "Resolved-Server")
+        sent := time.Now()
+        response, err := client.Do(request)
+        received := time.Now()
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        io.Copy(io.Discard, response.Body)
+        response.Body.Close()
+        sample, err := OffsetFromDate(response.Header.Get("Date"), sent, received)
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        if measured == nil {
+            measured = &sample
+        } else {
+            combined := measured.Combine(sample)
+            measured = &combined
+        }
+    }
+    if measured == nil {
+        return nil, lastErr
+    }
+    PickLogger(logger).Debug("measured server clock", "offset", measured.Offset, "uncertainty", measured.Uncertainty, "samples", measured.Samples)
+    return measured, nil
+}
+
+/*
+Name: QueryNTP
+Type: API Func
+Purpose: Estimate the local clock's offset from an NTP
+server, given as host or host:port, with one SNTP query.
+The uncertainty is half the round trip
+*/
+func QueryNTP(server string, timeout time.Duration) (*ClockOffset, error) {
+    if _, _, err := net.SplitHostPort(server); err != nil {
+        server = net.JoinHostPort(server, "123")
+    }
+    conn, err := net.DialTimeout("udp", server, timeout)
+    if err != nil {
+        return nil, err
+    }
+    defer conn.Close()
+    conn.SetDeadline(time.Now().Add(timeout))
+
+    // version 4, client mode
+    request := make([]byte, 48)
+    request[0] = 4 << 3 | 3
+    sent := time.Now()
+    putNTPTime(request[40:], sent)
+    if _, err := conn.Write(request); err != nil {
+        return nil, err
+    }
+    response := make([]byte, 48)
+    n, err := conn.Read(response)
+    received := time.Now()
+    if err != nil {
+        return nil, err
+    }
+    // a stratum of 0 is a kiss of death
+    if n < 48 || response[0] & 0x7 != 4 || response[1] == 0 {
+        return nil, ErrNTP
+    }
+    serverReceived := ntpTime(response[32:])
+    serverSent := ntpTime(response[40:])
+    offset := (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2
+    delay := received.Sub(sent) - serverSent.Sub(serverReceived)
+    if delay < 0 {
+        delay = 0
+    }
+    return &ClockOffset{
+        Offset: offset,
+        Uncertainty: delay / 2,
+        Source: NTPClockSource,
+        Samples: 1,
+        MeasuredAt: received,
+    }, nil
+}
+
+/*
+Name: ntpTime
+Type: Internal Func
+Purpose: Read an NTP timestamp, seconds and a fraction
+of a second since 1900
+*/
+func ntpTime(b []byte) (time.Time) {
+    secs := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+    frac := int64(binary.BigEndian.Uint32(b[4:8]))
+    return time.Unix(secs, frac * 1e9 >> 32)
+}
+
+/*
+Name: putNTPTime
+Type: Internal Func
+Purpose: Write a time as an NTP timestamp
+*/
+func putNTPTime(b []byte, t time.Time) {
+    binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix() + ntpEpochOffset))
+    binary.BigEndian.PutUint32(b[4:8], uint32((int64(t.Nanosecond()) << 32) / 1e9))
+}
+
+/*
+Name: ClockParam
+Type: API Func Input Struct
+Purpose: Input information to the 'ServerClock' api function
+*/
+type ClockParam struct {
+    // Date header samples to take, DefaultClockSamples
+    // if zero
+    Samples         int
+    // As in ReserveParam
+    Logger          *slog.Logger
+}
+
+/*
+Name: ClockReader
+Type: Interface
+Purpose: Optional behavior for external services which
+can estimate how far their servers' clock is ahead of the
+local one, so requests can be timed on the service's
+clock. Consumers should check for it with a type
+assertion on an API
+*/
+type ClockReader interface {
+    ServerClock(params ClockParam) (*ClockOffset, error)
+}
@@ -44,6 +44,45 @@ Reserve:
     must be obtained by a 'Login' api function call, though such a
     value only needs to be obtained before a series of Reserve calls.
 
+    Instead of exact times, the priority list may be given as 
+    TimeWindows, each a range of acceptable times with a policy 
+    saying which slot in the range to prefer: the closest to a
+    target, the earliest or the latest. ReserveParam.Windows()
+    returns the list as windows either way, so services only need
+    to handle windows.
+
+    Times and windows need not share a date. A request for any of
+    several days lists the times for its first choice of day before
+    those for the next, and services must search each window on its
+    own date rather than the date of the first.
+
+    A request may list AltPartySizes to fall back on. Services try
+    the whole request at PartySize before moving on to each alternate
+    in order, and report the size booked in ReserveResponse.PartySize.
+    ReserveParam.PartySizes() returns the sizes in the order to try.
+
+    A request with DryRun set goes through every step a service
+    takes except the one that books, and returns the slot it would
+    have booked with ReserveResponse.DryRun set and no ReservationID
+    or CancelToken. This lets a consumer rehearse a request, checking
+    its venue, table types and timing, without holding a table.
+
+    Services which take a step before booking, such as fetching a
+    book token, may run that step for up to ParallelDetails of the
+    best slots at once, so a slot lost to someone else doesn't cost
+    a round trip before the next is tried. Slots are still booked
+    one at a time in rank order, and never more than one. The next
+    slot is only booked once the service has turned the last book
+    down. A book that failed without an answer may have gone
+    through, so Reserve stops with an error wrapping ErrBookUnknown,
+    which consumers must not retry blindly.
+
+    ReserveParam and FindParam carry a Logger, usually tagged with
+    the operation making the request, which services log their steps
+    to. Services fall back on a logger of their own when it is nil,
+    and PickLogger returns the first set logger or one that discards.
+    Auth tokens, book tokens and payment details are never logged.
+
 **********************************************************************   
 
 Search:
@@ -57,6 +96,121 @@ Search:
     
 **********************************************************************   
 
+ReservationLister:
+
+    Some services can also list the upcoming reservations on an
+    account. Since not all can, this lives in a separate optional
+    interface, ReservationLister, with one method:
+
+        Reservations(params ReservationsParam) (*ReservationsResponse, error)
+
+    Consumers check for it with a type assertion on an API value.
+
+**********************************************************************   
+
+Finder:
+
+    Some services can list the open slots at a venue without booking
+    one. This lives in the optional Finder interface, with one method:
+
+        Find(params FindParam) (*FindResponse, error)
+
+    Services which list a whole day only use the date of the Day
+    field, while those which only list slots near a time look
+    around its clock time. Consumers check for Finder with a type
+    assertion on an API value.
+
+**********************************************************************   
+
+Warmer:
+
+    Services should send their requests through one HTTP client kept
+    for the life of the process, such as one made by NewClient, so
+    connections are kept alive between calls. Services which can get
+    ready for a request ahead of time implement the optional Warmer
+    interface, with one method:
+
+        Warm(params WarmParam) (*LoginResponse, error)
+
+    Warm logs in again for fresh auth and opens Conns connections to
+    the service, see WarmConnections, returning the new login. It is
+    called a few seconds before a scheduled request, so the requests
+    at the drop go out on open connections.
+
+**********************************************************************   
+
+ClockReader:
+
+    Requests timed for a drop should be timed on the service's clock,
+    which can be seconds off the local one. Services which can measure
+    the difference implement the optional ClockReader interface:
+
+        ServerClock(params ClockParam) (*ClockOffset, error)
+
+    A ClockOffset is the service's clock minus the local clock, give
+    or take its Uncertainty. MeasureClock builds one from the Date
+    headers of a few requests, OffsetFromDate from a single response,
+    and QueryNTP asks an NTP server instead. Combine narrows an offset
+    with another one when their ranges agree.
+
+**********************************************************************   
+
+Canceller:
+
+    Some services can also cancel a booking. This lives in the optional
+    Canceller interface, with one method:
+
+        Cancel(params CancelParam) (*CancelResponse, error)
+
+    The CancelToken in CancelParam is the one handed back on the
+    ReserveResponse of the booking. Consumers check for Canceller with
+    a type assertion on an API value.
+
+**********************************************************************   
+
+SlotSelector:
+
+    Services no longer hardcode the order open slots are tried in. 
+    They list every slot fitting a request as a Candidate, placed on
+    the request's window, table type and party size lists by 
+    ReserveParam.Candidate, order the candidates with the request's
+    selector, and try them in that order. A SlotSelector only has to 
+    say whether one candidate goes before another. TableFirstSelector
+    is the default and tries every time on a table type before the 
+    next one. TimeFirstSelector tries every table type at a time before
+    the next time. WeightedSelector scores each candidate on its 
+    distance from the window's preferred time, its window, table type
+    and party size ranks, and its deposit, trying the lowest first.
+
+**********************************************************************   
+
+TableType:
+
+    A TableType is a pattern matched against the seating type a 
+    service names a slot with, like "Chef's Counter". A plain pattern
+    must be whole words of the name, ignoring case, so "bar" fits 
+    "Main Bar" but not "Barrel Room". Prefixed by "exact:" it must be
+    the whole name, by "contains:" any part of it, and by "regex:" it
+    is a regular expression found in the name. The TableType constants
+    are plain patterns for common seating. A request's TableTypes are
+    its priority list and its ExcludeTableTypes are never booked, both
+    matched through MatchSeating.
+
+**********************************************************************   
+
+FeePolicy:
+
+    Slots can carry deposits, prepayment or late cancellation fees.
+    Services report what they know of them as a FeePolicy, on each
+    Candidate and on the ReserveResponse of the slot booked. A request's
+    FeeLimit can cap the largest fee accepted and refuse anything paid
+    up front. Slots breaking the limit are skipped, and if nothing else
+    could be booked the service returns a FeeError naming the slot and
+    its fees, which wraps ErrFeeLimit or ErrPrepay. Opentable does not
+    list fees, so its slots always pass.
+
+**********************************************************************   
+
 AuthMinExpire:
 
     The AuthMinExpire function provides the minimum time irresepective
@@ -66,5 +220,95 @@ AuthMinExpire:
 
 **********************************************************************   
 
+Redaction:
+
+    Auth tokens, API keys, passwords and payment method ids must never
+    reach a log, error message or exported file. Services register the
+    secret values they handle with AddSecret, at the latest when they
+    log in, and Redact replaces those values, along with the values of
+    any field, query param or header named in SecretKeys, by Redacted.
+    RedactError wraps an error so its message is scrubbed while
+    errors.Is still sees what it wraps, NewRedactHandler wraps a slog
+    handler so every record is scrubbed, DumpRequest and DumpResponse
+    give scrubbed HTTP dumps for debug logs, and RedactWriter scrubs
+    whatever is written through it.
+
+**********************************************************************   
+
+ProviderError:
+
+    When a service turns a request down, services return a
+    ProviderError naming the service, the step, the HTTP status, the
+    service's own error code if its body had one, and up to
+    MaxErrorBody bytes of the body, redacted. It wraps the sentinel
+    the failure maps to, usually ErrNetwork, ErrLoginWrong for a bad
+    login and ErrNoTable for a refused book, so errors.Is keeps
+    working. When nothing books, Reserve returns the book or listing
+    the service turned down rather than a bare ErrNoTable.
+    NewProviderError reads the body of a response, and
+    BodyProviderError takes one already read. Class names the kind
+    of failure its status is, which ErrorType reports for metrics.
+
+**********************************************************************   
+
+Metrics:
+
+    Services time every request they make to the reservation service
+    and record it with ObserveStep, naming the service and the step,
+    one of LoginStep, SearchStep, FindStep, DetailsStep, BookStep,
+    ReservationsStep or CancelStep. This counts requests by outcome,
+    failures by ErrorType and latency in the metrics.Default registry.
+
+**********************************************************************   
+
+Trace:
+
+    LoginParam, ReserveParam and FindParam take an optional Trace.
+    Services pass each request they make through Trace.Start and end
+    the span it returns once the response is in, so the trace records
+    how long DNS, connecting, TLS and the first byte took per step.
+    A nil Trace records nothing. Callers group the requests with
+    StartAttempt, and WriteOTLP exports spans as OpenTelemetry JSON.
+
+**********************************************************************   
+
+Retry:
+
+    Clients made by NewClient send their requests through a
+    RetryTransport. ClassifyStatus names a failed status as one of
+    RateLimitedClass, ServerClass, AuthClass or ClientClass, and a
+    request without a response is a NetworkClass failure. Rate
+    limited, server and network failures are retried under a
+    RetryPolicy, waiting out an exponential backoff with jitter or
+    the Retry-After the service sent, whichever is longer. No retry
+    starts past the policy's MaxElapsed, the deadline of the
+    request's context, or the time WithRetryDeadline put on it,
+    which services take from ReserveParam.Deadline. Requests marked
+    with NotIdempotent, such as books, are only retried when rate
+    limited. Each retry is recorded on the request's trace span and
+    counted in the metrics.Default registry.
+
+**********************************************************************   
+
+Cassettes:
+
+    A Recorder records the exchanges of chosen operations, each onto a
+    Cassette of its own that SaveCassette writes as JSON, with secrets
+    redacted as Redact and SecretKeys describe. Requests are told apart
+    by the operation id WithOperation tags them with, which the traces
+    NewOperationTrace makes do for every request they start, so
+    operations running at once never share a cassette. Clients made by
+    NewClient send every try, retries included, through the
+    DefaultRecorder, so every service using one can be recorded.
+    Requests made outside an operation's trace are not recorded.
+
+    A Replayer answers requests from a cassette, matching on method,
+    URL and body, and NewReplayClient makes a client around one to put
+    in a service's Client field, so a failure recorded for a bug report
+    can be reproduced, or kept as a regression test. Requests the
+    cassette has no answer for fail with ErrNoInteraction.
+
+**********************************************************************   
+
 */
 package api
@@ -0,0 +1,132 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "errors"
+    "strconv"
+    "time"
+)
+
+var (
+    ErrFeeLimit = errors.New("slot fee is above the limit")
+    ErrPrepay = errors.New("slot requires prepayment")
+)
+
+/*
+Name: FeePolicy
+Type: API Struct
+Purpose: What a service charges to book a slot, and
+what it charges if the booking is cancelled late or
+not shown up to
+Note: Amounts are in the venue's currency, 0 if there
+is no such fee or the service didn't say
+*/
+type FeePolicy struct {
+    // Charged when booking, and kept or refunded by
+    // the venue's rules
+    Deposit          float64
+    // Charged on a late cancel or no show
+    CancellationFee  float64
+    // The whole bill is paid when booking
+    Prepay           bool
+}
+
+/*
+Name: Largest
+Type: API Func
+Purpose: Return the largest amount the policy could
+charge for a single booking
+*/
+func (f FeePolicy) Largest() (float64) {
+    if f.Deposit > f.CancellationFee {
+        return f.Deposit
+    }
+    return f.CancellationFee
+}
+
+/*
+Name: Merge
+Type: API Func
+Purpose: Combine two readings of the same slot's policy,
+keeping the stricter of each term
+*/
+func (f FeePolicy) Merge(g FeePolicy) (FeePolicy) {
+    if g.Deposit > f.Deposit {
+        f.Deposit = g.Deposit
+    }
+    if g.CancellationFee > f.CancellationFee {
+        f.CancellationFee = g.CancellationFee
+    }
+    f.Prepay = f.Prepay || g.Prepay
+    return f
+}
+
+/*
+Name: FeeLimit
+Type: API Input Struct
+Purpose: The fees a reserve request accepts. The zero
+value accepts any fee
+*/
+type FeeLimit struct {
+    // Largest deposit or cancellation fee accepted,
+    // only checked if LimitFee is set
+    LimitFee         bool
+    MaxFee           float64
+    // Refuse slots which take any money up front
+    NoPrepay         bool
+}
+
+/*
+Name: FeeError
+Type: API Error
+Purpose: Explain which slot a fee limit turned down
+and why. Wraps ErrFeeLimit or ErrPrepay
+*/
+type FeeError struct {
+    Time             time.Time
+    Fees             FeePolicy
+    Err              error
+}
+
+/*
+Name: Error
+Type: error method
+Purpose: Satisfy the error interface
+*/
+func (e *FeeError) Error() (string) {
+    str := e.Err.Error() + " at " + e.Time.Format("2006-01-02 15:04") + " ("
+    str += "deposit " + strconv.FormatFloat(e.Fees.Deposit, 'f', 2, 64)
+    str += ", cancellation fee " + strconv.FormatFloat(e.Fees.CancellationFee, 'f', 2, 64)
+    if e.Fees.Prepay {
+        str += ", prepaid"
+    }
+    return str + ")"
+}
+
+/*
+Name: Unwrap
+Type: error method
+Purpose: Let errors.Is see the sentinel underneath
+*/
+func (e *FeeError) Unwrap() (error) {
+    return e.Err
+}
+
+/*
+Name: Check
+Type: API Func
+Purpose: Return a FeeError if a slot at the given time
+with the given fees breaks the limit, otherwise nil
+*/
+func (l FeeLimit) Check(t time.Time, fees FeePolicy) (error) {
+    if l.NoPrepay && (fees.Prepay || fees.Deposit > 0) {
+        return &FeeError{Time: t, Fees: fees, Err: ErrPrepay}
+    }
+    if l.LimitFee && fees.Largest() > l.MaxFee {
+        return &FeeError{Time: t, Fees: fees, Err: ErrFeeLimit}
+    }
+    return nil
+}
@@ -0,0 +1,33 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "io"
+    "log/slog"
+)
+
+/*
+Name: discardLogger
+Type: Internal Var
+Purpose: Logger standing in when none is given
+*/
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+/*
+Name: PickLogger
+Type: API Func
+Purpose: Return the first of the loggers that is set, or
+one that discards everything if none are. Services use it
+to prefer a request's logger over their own
+*/
+func PickLogger(loggers ...*slog.Logger) (*slog.Logger) {
+    for _, logger := range loggers {
+        if logger != nil {
+            return logger
+        }
+    }
+    return discardLogger
+}
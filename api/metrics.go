@@ -0,0 +1,88 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "errors"
+    "net"
+    "time"
+    "github.com/21Bruce/resolved-server/metrics"
+)
+
+const (
+    // Steps a service records with ObserveStep
+    LoginStep = "login"
+    SearchStep = "search"
+    FindStep = "find"
+    DetailsStep = "details"
+    BookStep = "book"
+    ReservationsStep = "reservations"
+    CancelStep = "cancel"
+)
+
+var (
+    stepCount = metrics.Default.Counter(
+        "resolved_api_requests_total",
+        "Requests made to a reservation service, by step and outcome",
+        "service", "step", "outcome")
+    stepErrors = metrics.Default.Counter(
+        "resolved_api_errors_total",
+        "Failed requests to a reservation service, by step and error type",
+        "service", "step", "type")
+    stepDuration = metrics.Default.Histogram(
+        "resolved_api_request_duration_seconds",
+        "Time taken by requests to a reservation service, by step",
+        metrics.DefaultBuckets,
+        "service", "step")
+    retryCount = metrics.Default.Counter(
+        "resolved_api_retries_total",
+        "Requests to a reservation service sent again, by host and error class",
+        "host", "class")
+)
+
+/*
+Name: ObserveStep
+Type: API Func
+Purpose: Record one request a service made for a step,
+started at start and failing with err if it is set, in
+the default metrics registry
+*/
+func ObserveStep(service string, step string, start time.Time, err error) {
+    stepDuration.Observe(time.Since(start).Seconds(), service, step)
+    if err != nil {
+        stepCount.Inc(service, step, "error")
+        stepErrors.Inc(service, step, ErrorType(err))
+        return
+    }
+    stepCount.Inc(service, step, "ok")
+}
+
+/*
+Name: ErrorType
+Type: API Func
+Purpose: Name the kind of an error for metrics and logs,
+one of login, no_table, fee, network, transport or other,
+or for a ProviderError other than a bad login, the
+ErrorClass of its status
+*/
+func ErrorType(err error) (string) {
+    var netErr net.Error
+    var providerErr *ProviderError
+    switch {
+    case errors.Is(err, ErrLoginWrong) || errors.Is(err, ErrNoPayInfo):
+        return "login"
+    case errors.As(err, &providerErr) && providerErr.Class() != "":
+        return string(providerErr.Class())
+    case errors.Is(err, ErrNoTable) || errors.Is(err, ErrNoOffer):
+        return "no_table"
+    case errors.Is(err, ErrFeeLimit) || errors.Is(err, ErrPrepay):
+        return "fee"
+    case errors.Is(err, ErrNetwork):
+        return "network"
+    case errors.As(err, &netErr):
+        return "transport"
+    }
+    return "other"
+}
@@ -6,8 +6,11 @@ import (
     "net/http"
     "io"
     "encoding/json"
+    "sort"
     "strconv"
     "errors"
+    "time"
+    "log/slog"
 )
 
 var (
@@ -19,6 +22,23 @@ type API struct {
     XCSRFToken  string
     SearchKey   string
     FindKey     string
+    // Logs calls made without a request logger, nothing
+    // is logged if unset
+    Logger      *slog.Logger
+    // Client requests are sent with, the package's shared
+    // keep-alive client if unset
+    Client      *http.Client
+}
+
+// Client shared by every opentable API without one of
+// its own, so connections stay open between calls
+var sharedClient = api.NewClient()
+
+func (a *API) httpClient() (*http.Client) {
+    if a.Client != nil {
+        return a.Client
+    }
+    return sharedClient
 }
 
 func GetDefaultAPI() (API) {
@@ -45,15 +65,25 @@ func (a *API) Login(params api.LoginParam) (*api.LoginResponse, error) {
     }, nil
 }
 
-func (a *API) getSlotMetadata(params api.ReserveParam, time api.Time) (*string, *string, error) {
+// Query the slots opentable offers around resTime, each
+// slot has a timeOffsetMinutes relative to resTime
+func (a *API) availability(venueID int64, partySize int, resTime time.Time, trace *api.Trace) ([]interface{}, error) {
+    start := time.Now()
+    hits, err := a.queryAvailability(venueID, partySize, resTime, trace)
+    api.ObserveStep("opentable", api.FindStep, start, err)
+    return hits, err
+}
+
+// availability without recording metrics
+func (a *API) queryAvailability(venueID int64, partySize int, resTime time.Time, trace *api.Trace) ([]interface{}, error) {
     findUrl := "https://www.opentable.com/dapi/fe/gql?optype=query&opname=RestaurantsAvailability"
-    dateStr := params.Year + "-" + params.Month + "-" + params.Day
-    timeStr := time.Hour + ":" + time.Minute
-    venueId := strconv.FormatInt(params.VenueID, 10)
-    partySize := strconv.Itoa(params.PartySize)
+    dateStr := resTime.Format("2006-01-02")
+    timeStr := resTime.Format("15:04")
+    venueId := strconv.FormatInt(venueID, 10)
+    partySizeStr := strconv.Itoa(partySize)
     variableStr := `"variables": {"onlyPop": false, "forwardDays": 0,` +
     `"requireTimes": false, "requireTypes": [], "restaurantIds": [` + venueId + `],` +
-    `"date":"` + dateStr + `", "time":"` + timeStr + `", "partySize":` + partySize +
+    `"date":"` + dateStr + `", "time":"` + timeStr + `", "partySize":` + partySizeStr +
     `,"databaseRegion": "NA"}`
     extensionStr := `"extensions": {"persistedQuery": {"version": 1, "sha256Hash": "` +
     a.FindKey + `"}}`
@@ -62,7 +92,7 @@ func (a *API) getSlotMetadata(params api.ReserveParam, time api.Time) (*string,
     request, err := http.NewRequest("POST", findUrl, bytes.NewBuffer(bodyBytes))
 
     if err != nil {
-        return nil, nil, err
+        return nil, err
     }
     
     request.Header.Set("Content-Type", "application/json")
@@ -73,60 +103,102 @@ func (a *API) getSlotMetadata(params api.ReserveParam, time api.Time) (*string,
     request.Header.Set("Origin", "https://www.opentable.com")
     request.Header.Set("user-agent", "Resolved-Server")
 
-    client := &http.Client{}
+    client := a.httpClient()
 
+    request, span := trace.Start("opentable", api.FindStep, request)
     response, err := client.Do(request)
+    span.End(response, err)
 
     if err != nil {
-        return nil, nil, err
+        return nil, err
     }
 
+    defer response.Body.Close()
+
     if isCodeFail(response.StatusCode) {
-        return nil, nil, api.ErrNetwork
+        return nil, api.NewProviderError("opentable", api.FindStep, response, api.ErrNetwork)
     }
 
-    defer response.Body.Close()
-
     responseBody, err := io.ReadAll(response.Body)
     if err != nil {
-        return nil, nil, err
+        return nil, err
     }
 
     var jsonTopLevelMap map[string]interface{}
     err = json.Unmarshal(responseBody, &jsonTopLevelMap)
     if err != nil {
-        return nil, nil, err
+        return nil, err
     }
 
     jsonDataMap := jsonTopLevelMap["data"].(map[string]interface{})
     if jsonDataMap["availability"] == nil {
-        return nil, nil, ErrBadData
+        return nil, ErrBadData
     }
 
     jsonAvailabilityMap := jsonDataMap["availability"].([]interface{})[0].(map[string]interface{})
     jsonAvailabilityDaysMap := jsonAvailabilityMap["availabilityDays"].([]interface{})[0].(map[string]interface{})
     jsonHitsMap := jsonAvailabilityDaysMap["slots"].([]interface{})
+    return jsonHitsMap, nil
+}
+
+// An open opentable slot and the values needed to book it
+type slotMetadata struct {
+    time    time.Time
+    hash    string
+    token   string
+}
+
+// Find the open slots in a window, ordered by how much the
+// window's policy prefers them. Opentable lists slots as offsets
+// around a time, so we ask around the window's preferred time
+func (a *API) getSlotMetadata(params api.ReserveParam, window api.TimeWindow) ([]slotMetadata, error) {
+    anchor := window.Preferred()
+    jsonHitsMap, err := a.availability(params.VenueID, params.PartySize, anchor, params.Trace)
+    if err != nil {
+        return nil, err
+    }
+    slots := []slotMetadata{}
     for i := 0; i < len(jsonHitsMap); i++ {
-        jsonHitMap := jsonHitsMap[i].(map[string]interface{})
-        if jsonHitMap["isAvailable"].(bool) != true {
+        jsonHitMap, ok := jsonHitsMap[i].(map[string]interface{})
+        if !ok {
             continue
         }
-        if int(jsonHitMap["timeOffsetMinutes"].(float64)) != 0 {
+        if available, _ := jsonHitMap["isAvailable"].(bool); !available {
             continue
         }
-        slotHash := jsonHitMap["slotHash"].(string)
-        slotToken := jsonHitMap["slotAvailabilityToken"].(string)
-        return &slotHash, &slotToken, nil
-
+        offset, ok := jsonHitMap["timeOffsetMinutes"].(float64)
+        if !ok {
+            continue
+        }
+        slotTime := anchor.Add(time.Duration(offset) * time.Minute)
+        if !window.Contains(slotTime) {
+            continue
+        }
+        slotHash, _ := jsonHitMap["slotHash"].(string)
+        slotToken, _ := jsonHitMap["slotAvailabilityToken"].(string)
+        slots = append(slots, slotMetadata{time: slotTime, hash: slotHash, token: slotToken})
+    }
+    if len(slots) == 0 {
+        return nil, api.ErrNoTable
     }
-    return nil, nil, api.ErrNoTable 
+    sort.SliceStable(slots, func(i, j int) bool {
+        return window.Prefers(slots[i].time, slots[j].time)
+    })
+    return slots, nil
+}
 
+func (a *API) finalizeReservation(hash string, token string, resTime time.Time, params api.ReserveParam) (*api.ReserveResponse, error) {
+    start := time.Now()
+    res, err := a.makeReservation(hash, token, resTime, params)
+    api.ObserveStep("opentable", api.BookStep, start, err)
+    return res, err
 }
 
-func (a *API) finalizeReservation(hash string, token string, time api.Time, params api.ReserveParam) (*api.ReserveResponse, error) {
+// finalizeReservation without recording metrics
+func (a *API) makeReservation(hash string, token string, resTime time.Time, params api.ReserveParam) (*api.ReserveResponse, error) {
     resUrl := "https://www.opentable.com/dapi/booking/make-reservation"
-    dateStr := params.Year + "-" + params.Month + "-" + params.Day
-    timeStr := time.Hour + ":" + time.Minute
+    dateStr := resTime.Format("2006-01-02")
+    timeStr := resTime.Format("15:04")
     dateTimeStr := dateStr + "T" + timeStr
     venueId := strconv.FormatInt(params.VenueID, 10)
     partySize := strconv.Itoa(params.PartySize)
@@ -162,20 +234,24 @@ func (a *API) finalizeReservation(hash string, token string, time api.Time, para
     request.Header.Set("Origin", "https://www.opentable.com")
     request.Header.Set("user-agent", "Resolved-Server")
 
-    client := &http.Client{}
+    client := a.httpClient()
 
+    // a booking that failed server side may have gone through
+    request = api.NotIdempotent(api.WithRetryDeadline(request, params.Deadline))
+    request, span := params.Trace.Start("opentable", api.BookStep, request)
     response, err := client.Do(request)
+    span.End(response, err)
 
     if err != nil {
         return nil, err
     }
 
+    defer response.Body.Close()
+
     if isCodeFail(response.StatusCode) {
-        return nil, api.ErrNetwork
+        return nil, api.NewProviderError("opentable", api.BookStep, response, api.ErrNetwork)
     }
 
-    defer response.Body.Close()
-
     responseBody, err := io.ReadAll(response.Body)
     if err != nil {
         return nil, err
@@ -188,33 +264,176 @@ func (a *API) finalizeReservation(hash string, token string, time api.Time, para
         return nil, err
     }
 
-    if jsonTopLevelMap["success"].(bool) {
+    if success, _ := jsonTopLevelMap["success"].(bool); success {
         return &api.ReserveResponse{
-            ReservationTime: time,
+            ReservationTime: resTime,
+            PartySize: params.PartySize,
         }, nil
     }
 
-    return nil, api.ErrNoTable
-
-
+    // the body says why the booking was turned down
+    return nil, api.BodyProviderError("opentable", api.BookStep, response.StatusCode, responseBody, api.ErrNoTable)
 }
 
+// A booking or listing opentable turned down says more than
+// a bare no table error, so the last one is returned if
+// nothing books
 func (a *API) Reserve(params api.ReserveParam) (*api.ReserveResponse, error) {
-    for _, v := range params.ReservationTimes {
-        hash, token, err := a.getSlotMetadata(params, v)
-        if err != nil {
-            continue
-        } 
-        res, err := a.finalizeReservation(*hash, *token, v, params)
+    logger := api.PickLogger(params.Logger, a.Logger)
+    candidates, listErr := a.candidates(params)
+    logger.Debug("ranked slots", "candidates", len(candidates))
+    var bookErr *api.ProviderError
+    for _, candidate := range candidates {
+        // there is no step before the booking itself,
+        // so a dry run stops at the first candidate
+        if params.DryRun {
+            return &api.ReserveResponse{
+                ReservationTime: candidate.Time,
+                PartySize: candidate.PartySize,
+                DryRun: true,
+            }, nil
+        }
+        sizeParams := params
+        sizeParams.PartySize = candidate.PartySize
+        res, err := a.finalizeReservation(candidate.hash, candidate.token, candidate.Time, sizeParams)
         if err != nil {
+            logger.Warn("booking slot failed", "slot_time", candidate.Time.Format("2006-01-02 15:04"), "party_size", candidate.PartySize, "err", err)
+            if providerErr, ok := err.(*api.ProviderError); ok {
+                bookErr = providerErr
+            }
             continue
         }
+        logger.Info("booked", "slot_time", candidate.Time.Format("2006-01-02 15:04"), "party_size", candidate.PartySize)
         return res, nil
     }
+    logger.Info("no slot could be booked")
+    if bookErr != nil {
+        return nil, bookErr
+    }
+    if listErr != nil {
+        return nil, listErr
+    }
     return nil, api.ErrNoTable 
 }
 
+// A candidate slot along with the hash and token
+// needed to book it
+type otCandidate struct {
+    api.Candidate
+    hash            string
+    token           string
+}
+
+// Availability is queried per window and party size,
+// and since the queries overlap a slot seen twice is
+// only listed once. The list is ranked by the request's
+// selector. A query which fails is skipped, and if none
+// got an answer the last one opentable turned down is
+// returned alongside the list
+func (a *API) candidates(params api.ReserveParam) ([]otCandidate, *api.ProviderError) {
+    var listErr *api.ProviderError
+    loaded := false
+    // availability doesn't name the seating, so
+    // table types can't be matched on
+    listParams := params
+    listParams.TableTypes = nil
+    candidates := []otCandidate{}
+    seen := map[string]bool{}
+    for _, partySize := range params.PartySizes() {
+        sizeParams := params
+        sizeParams.PartySize = partySize
+        for _, window := range params.Windows() {
+            slots, err := a.getSlotMetadata(sizeParams, window)
+            if err != nil {
+                api.PickLogger(params.Logger, a.Logger).Warn("listing slots failed", "window_start", window.Start.Format("2006-01-02 15:04"), "party_size", partySize, "err", err)
+                if providerErr, ok := err.(*api.ProviderError); ok {
+                    listErr = providerErr
+                } else if err == api.ErrNoTable {
+                    loaded = true
+                }
+                continue
+            }
+            loaded = true
+            for _, slot := range slots {
+                key := strconv.Itoa(partySize) + "|" + slot.time.Format(time.RFC3339)
+                if seen[key] {
+                    continue
+                }
+                seen[key] = true
+                candidate, ok := listParams.Candidate(slot.time, "", partySize)
+                if !ok {
+                    continue
+                }
+                candidates = append(candidates, otCandidate{Candidate: candidate, hash: slot.hash, token: slot.token})
+            }
+        }
+    }
+    selector := listParams.SlotSelector()
+    sort.SliceStable(candidates, func(i, j int) bool {
+        return selector.Less(candidates[i].Candidate, candidates[j].Candidate)
+    })
+    if loaded {
+        return candidates, nil
+    }
+    return candidates, listErr
+}
+
+// Opentable only lists slots near a time, so we look around
+// the clock time of the day given
+func (a *API) Find(params api.FindParam) (*api.FindResponse, error) {
+    jsonHitsMap, err := a.availability(params.VenueID, params.PartySize, params.Day, params.Trace)
+    if err != nil {
+        return nil, err
+    }
+    slots := []api.Slot{}
+    for _, jsonHit := range jsonHitsMap {
+        jsonHitMap, ok := jsonHit.(map[string]interface{})
+        if !ok {
+            continue
+        }
+        if available, _ := jsonHitMap["isAvailable"].(bool); !available {
+            continue
+        }
+        offset, ok := jsonHitMap["timeOffsetMinutes"].(float64)
+        if !ok {
+            continue
+        }
+        slots = append(slots, api.Slot{
+            Time: params.Day.Add(time.Duration(offset) * time.Minute),
+        })
+    }
+    return &api.FindResponse{Slots: slots}, nil
+}
+
+// There is no login to refresh, so warming only
+// opens connections
+func (a *API) Warm(params api.WarmParam) (*api.LoginResponse, error) {
+    err := api.WarmConnections(a.httpClient(), "https://www.opentable.com/", params.Conns)
+    if err != nil {
+        api.PickLogger(params.Logger, a.Logger).Warn("opening connections failed", "err", err)
+    }
+    return a.Login(params.Login)
+}
+
+// Read the Date headers of www.opentable.com
+func (a *API) ServerClock(params api.ClockParam) (*api.ClockOffset, error) {
+    return api.MeasureClock(a.httpClient(), "https://www.opentable.com/", params.Samples, api.PickLogger(params.Logger, a.Logger))
+}
+
+// Opentable logins never go stale since there is no login
+func (a *API) AuthMinExpire() (time.Duration) {
+    return 0
+}
+
 func (a *API) Search(params api.SearchParam) (*api.SearchResponse, error) {
+    start := time.Now()
+    resp, err := a.search(params)
+    api.ObserveStep("opentable", api.SearchStep, start, err)
+    return resp, err
+}
+
+// Search without recording metrics
+func (a *API) search(params api.SearchParam) (*api.SearchResponse, error) {
     searchUrl := "https://www.opentable.com/dapi/fe/gql?optype=query&opname=Autocomplete"
 
     variableStr := `"variables": {"term": "` + params.Name +`", "latitude": 1, "longitude": 1, "useNewVersion": true}`
@@ -236,7 +455,7 @@ func (a *API) Search(params api.SearchParam) (*api.SearchResponse, error) {
     request.Header.Set("Origin", "https://www.opentable.com")
     request.Header.Set("user-agent", "Resolved-Server")
 
-    client := &http.Client{}
+    client := a.httpClient()
 
     response, err := client.Do(request)
 
@@ -244,12 +463,12 @@ func (a *API) Search(params api.SearchParam) (*api.SearchResponse, error) {
         return nil, err
     }
 
+    defer response.Body.Close()
+
     if isCodeFail(response.StatusCode) {
-        return nil, api.ErrNetwork
+        return nil, api.NewProviderError("opentable", api.SearchStep, response, api.ErrNetwork)
     }
 
-    defer response.Body.Close()
-
     responseBody, err := io.ReadAll(response.Body)
     if err != nil {
         return nil, err
@@ -0,0 +1,88 @@
+package opentable
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+// A RoundTripper standing in for opentable, answering
+// availability and make-reservation with canned replies
+type otStub struct {
+    findStatus      int
+    bookStatus      int
+    bookBody        string
+    books           int
+}
+
+func (s *otStub) RoundTrip(req *http.Request) (*http.Response, error) {
+    if strings.Contains(req.URL.Path, "make-reservation") {
+        s.books += 1
+        return stubResponse(s.bookStatus, s.bookBody), nil
+    }
+    if s.findStatus != 200 {
+        return stubResponse(s.findStatus, `{"errorCode":"UNAVAILABLE"}`), nil
+    }
+    slots := []map[string]interface{}{
+        {"isAvailable": true, "timeOffsetMinutes": 0, "slotHash": "h0", "slotAvailabilityToken": "t0"},
+        {"isAvailable": true, "timeOffsetMinutes": 15, "slotHash": "h1", "slotAvailabilityToken": "t1"},
+    }
+    body, _ := json.Marshal(map[string]interface{}{
+        "data": map[string]interface{}{
+            "availability": []interface{}{map[string]interface{}{
+                "availabilityDays": []interface{}{map[string]interface{}{"slots": slots}},
+            }},
+        },
+    })
+    return stubResponse(200, string(body)), nil
+}
+
+func stubResponse(status int, body string) (*http.Response) {
+    return &http.Response{
+        StatusCode: status,
+        Header: http.Header{"Content-Type": []string{"application/json"}},
+        Body: io.NopCloser(strings.NewReader(body)),
+    }
+}
+
+func TestReserveReturnsProviderErrors(t *testing.T) {
+    at := time.Date(2026, 11, 2, 19, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name        string
+        stub        otStub
+        wantStep    string
+        wantCode    string
+        wantStatus  int
+        wantBooks   int
+    }{
+        {"booking refused", otStub{findStatus: 200, bookStatus: 200, bookBody: `{"success":false,"errorCode":"SLOT_TAKEN"}`}, api.BookStep, "SLOT_TAKEN", 200, 2},
+        {"booking failed", otStub{findStatus: 200, bookStatus: 409, bookBody: `{"errorCode":"CONFLICT"}`}, api.BookStep, "CONFLICT", 409, 2},
+        {"listing failed", otStub{findStatus: 503}, api.FindStep, "UNAVAILABLE", 503, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            stub := tt.stub
+            a := API{Client: &http.Client{Transport: &stub}}
+            _, err := a.Reserve(api.ReserveParam{
+                VenueID: 1,
+                PartySize: 2,
+                TimeWindows: []api.TimeWindow{{Start: at, End: at.Add(30 * time.Minute)}},
+            })
+            var providerErr *api.ProviderError
+            if !errors.As(err, &providerErr) {
+                t.Fatalf("err = %v, want a ProviderError", err)
+            }
+            if providerErr.Step != tt.wantStep || providerErr.Code != tt.wantCode || providerErr.Status != tt.wantStatus {
+                t.Errorf("err = %+v, want step %s, code %s, status %d", providerErr, tt.wantStep, tt.wantCode, tt.wantStatus)
+            }
+            if stub.books != tt.wantBooks {
+                t.Errorf("books = %d, want %d", stub.books, tt.wantBooks)
+            }
+        })
+    }
+}
@@ -0,0 +1,140 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "encoding/json"
+    "io"
+    "net/http"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+const (
+    // Bytes of a response body a ProviderError keeps
+    MaxErrorBody = 512
+)
+
+/*
+Name: ProviderError
+Type: API Error
+Purpose: Explain a response a service turned a request down
+with: the service, the step, the HTTP status, the service's
+own error code if it gave one, and the start of the body,
+scrubbed of secrets. Wraps the sentinel the failure maps to,
+such as ErrNetwork or ErrLoginWrong, so errors.Is still
+sees it
+*/
+type ProviderError struct {
+    // Service name, as passed to ObserveStep
+    Provider         string
+    // One of the Step consts
+    Step             string
+    Status           int
+    // Empty if the body had none
+    Code             string
+    // At most MaxErrorBody bytes, redacted
+    Body             string
+    Err              error
+}
+
+/*
+Name: Error
+Type: error method
+Purpose: Satisfy the error interface
+*/
+func (e *ProviderError) Error() (string) {
+    str := e.Err.Error() + " (" + e.Provider + " " + e.Step
+    if e.Status != 0 {
+        str += ", status " + strconv.Itoa(e.Status)
+    }
+    if e.Code != "" {
+        str += ", code " + e.Code
+    }
+    return str + ")"
+}
+
+/*
+Name: Unwrap
+Type: error method
+Purpose: Let errors.Is see the sentinel underneath
+*/
+func (e *ProviderError) Unwrap() (error) {
+    return e.Err
+}
+
+/*
+Name: Class
+Type: API Func
+Purpose: Name the kind of failure the status is, see
+'ClassifyStatus'
+*/
+func (e *ProviderError) Class() (ErrorClass) {
+    return ClassifyStatus(e.Status)
+}
+
+/*
+Name: NewProviderError
+Type: API Func
+Purpose: Make a ProviderError from a response a service
+turned a request down with, reading the start of its body
+for the error code. The caller still closes the body
+*/
+func NewProviderError(provider string, step string, resp *http.Response, err error) (*ProviderError) {
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxErrorBody + 1))
+    return BodyProviderError(provider, step, resp.StatusCode, body, err)
+}
+
+/*
+Name: BodyProviderError
+Type: API Func
+Purpose: Make a ProviderError from a status and a body
+already read, such as a success response that didn't hold
+what it should have
+*/
+func BodyProviderError(provider string, step string, status int, body []byte, err error) (*ProviderError) {
+    e := &ProviderError{
+        Provider: provider,
+        Step: step,
+        Status: status,
+        Code: errorCode(body),
+        Err: err,
+    }
+    if len(body) > MaxErrorBody {
+        // don't cut a character in half
+        cut := MaxErrorBody
+        for cut > 0 && !utf8.RuneStart(body[cut]) {
+            cut -= 1
+        }
+        e.Body = Redact(string(body[:cut])) + "..."
+    } else {
+        e.Body = Redact(string(body))
+    }
+    e.Body = strings.TrimSpace(e.Body)
+    return e
+}
+
+/*
+Name: errorCode
+Type: Internal Func
+Purpose: Pull a service's error code out of a JSON body,
+from the fields services put it in, or "" if there is none
+*/
+func errorCode(body []byte) (string) {
+    var fields map[string]interface{}
+    if json.Unmarshal(body, &fields) != nil {
+        return ""
+    }
+    for _, key := range []string{"code", "error_code", "errorCode"} {
+        switch code := fields[key].(type) {
+        case string:
+            return code
+        case float64:
+            return strconv.FormatFloat(code, 'f', -1, 64)
+        }
+    }
+    return ""
+}
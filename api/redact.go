@@ -0,0 +1,359 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "context"
+    "io"
+    "log/slog"
+    "net/http"
+    "net/http/httputil"
+    "regexp"
+    "strings"
+    "sync"
+    "unicode"
+)
+
+const (
+    // What a secret is replaced by
+    Redacted = "[REDACTED]"
+    // Registered secrets shorter than this are ignored,
+    // since replacing them would mangle unrelated text
+    MinSecretLen = 6
+)
+
+/*
+Name: SecretKeys
+Type: API Var
+Purpose: Names of fields, query params and headers whose
+values are always secret, in lower case
+*/
+var SecretKeys = []string{
+    "password",
+    "token",
+    "auth_token",
+    "book_token",
+    "resy_token",
+    "api_key",
+    "apikey",
+    "authorization",
+    "x-resy-auth-token",
+    "x-resy-universal-auth",
+    "x-csrf-token",
+    "payment_method_id",
+    "struct_payment_method",
+    "secret",
+}
+
+/*
+Name: secretRegistry
+Type: Internal Var
+Purpose: Secret values seen at runtime, such as auth
+tokens and passwords, scrubbed wherever they appear
+*/
+var secretRegistry = struct {
+    mu          sync.Mutex
+    secrets     []string
+}{}
+
+var (
+    secretKeyPattern = strings.Join(SecretKeys, "|")
+    // key=value, as in query strings and form bodies. Quoted
+    // values are left to quotedSecretRegexp
+    formSecretRegexp = regexp.MustCompile(`(?i)\b(` + secretKeyPattern + `)=[^&\s"']+`)
+    // "key": value, for string, number and flat object values
+    jsonSecretRegexp = regexp.MustCompile(`(?i)"(` + secretKeyPattern + `)"\s*:\s*("(?:[^"\\]|\\.)*"|\{[^{}]*\}|[^,}\]\s]+)`)
+    // Key: value, as in header dumps
+    headerSecretRegexp = regexp.MustCompile(`(?im)^(` + secretKeyPattern + `):[^\r\n]*`)
+    // ResyAPI api_key="...", wherever it is quoted
+    quotedSecretRegexp = regexp.MustCompile(`(?i)\b(` + secretKeyPattern + `)="[^"]*"`)
+)
+
+/*
+Name: AddSecret
+Type: API Func
+Purpose: Register secret values, such as an auth token,
+password or payment method id, to scrub from everything
+Redact sees from now on. Values shorter than MinSecretLen
+are ignored
+*/
+func AddSecret(secrets ...string) {
+    secretRegistry.mu.Lock()
+    defer secretRegistry.mu.Unlock()
+    for _, secret := range secrets {
+        if len(secret) < MinSecretLen {
+            continue
+        }
+        known := false
+        for _, s := range secretRegistry.secrets {
+            if s == secret {
+                known = true
+                break
+            }
+        }
+        if !known {
+            secretRegistry.secrets = append(secretRegistry.secrets, secret)
+        }
+    }
+}
+
+/*
+Name: Redact
+Type: API Func
+Purpose: Scrub secrets from a string, replacing the values
+of SecretKeys fields, params and headers and every
+registered secret with Redacted
+*/
+func Redact(s string) (string) {
+    s = headerSecretRegexp.ReplaceAllString(s, "$1: " + Redacted)
+    s = quotedSecretRegexp.ReplaceAllString(s, `$1="` + Redacted + `"`)
+    s = formSecretRegexp.ReplaceAllString(s, "$1=" + Redacted)
+    s = jsonSecretRegexp.ReplaceAllString(s, `"$1":"` + Redacted + `"`)
+    secretRegistry.mu.Lock()
+    secrets := secretRegistry.secrets
+    secretRegistry.mu.Unlock()
+    for _, secret := range secrets {
+        s = replaceSecret(s, secret)
+    }
+    return s
+}
+
+/*
+Name: replaceSecret
+Type: Internal Func
+Purpose: Replace a registered secret in a string. An all
+digit secret, like a payment method id, is only replaced
+where it isn't part of a longer number
+*/
+func replaceSecret(s string, secret string) (string) {
+    if strings.IndexFunc(secret, func(r rune) bool { return !unicode.IsDigit(r) }) != -1 {
+        return strings.ReplaceAll(s, secret, Redacted)
+    }
+    var b strings.Builder
+    for {
+        i := strings.Index(s, secret)
+        if i == -1 {
+            b.WriteString(s)
+            return b.String()
+        }
+        end := i + len(secret)
+        before := i == 0 || !unicode.IsDigit(rune(s[i-1]))
+        after := end == len(s) || !unicode.IsDigit(rune(s[end]))
+        b.WriteString(s[:i])
+        if before && after {
+            b.WriteString(Redacted)
+        } else {
+            b.WriteString(secret)
+        }
+        s = s[end:]
+    }
+}
+
+/*
+Name: RedactedError
+Type: API Error
+Purpose: An error whose message has been scrubbed of
+secrets. Wraps the original, so errors.Is still sees
+the sentinels underneath
+*/
+type RedactedError struct {
+    Err              error
+}
+
+/*
+Name: Error
+Type: error method
+Purpose: Satisfy the error interface
+*/
+func (e *RedactedError) Error() (string) {
+    return Redact(e.Err.Error())
+}
+
+/*
+Name: Unwrap
+Type: error method
+Purpose: Let errors.Is see the original error
+*/
+func (e *RedactedError) Unwrap() (error) {
+    return e.Err
+}
+
+/*
+Name: RedactError
+Type: API Func
+Purpose: Wrap an error so its message is scrubbed of
+secrets, nil stays nil
+*/
+func RedactError(err error) (error) {
+    if err == nil {
+        return nil
+    }
+    if _, ok := err.(*RedactedError); ok {
+        return err
+    }
+    return &RedactedError{Err: err}
+}
+
+/*
+Name: DumpRequest
+Type: API Func
+Purpose: Return a request as it goes out on the wire,
+body included, scrubbed of secrets. The request body
+can still be sent afterwards
+*/
+func DumpRequest(req *http.Request) (string) {
+    dump, err := httputil.DumpRequestOut(req, true)
+    if err != nil {
+        return ""
+    }
+    return Redact(string(dump))
+}
+
+/*
+Name: DumpResponse
+Type: API Func
+Purpose: Return a response with its body, scrubbed of
+secrets. The body can still be read afterwards
+*/
+func DumpResponse(resp *http.Response) (string) {
+    dump, err := httputil.DumpResponse(resp, true)
+    if err != nil {
+        return ""
+    }
+    return Redact(string(dump))
+}
+
+/*
+Name: redactWriter
+Type: Internal struct
+Purpose: io.Writer scrubbing each write before passing
+it on, see 'RedactWriter'
+*/
+type redactWriter struct {
+    w                io.Writer
+}
+
+/*
+Name: Write
+Type: io.Writer method
+Purpose: Satisfy the io.Writer interface
+*/
+func (r redactWriter) Write(p []byte) (int, error) {
+    _, err := io.WriteString(r.w, Redact(string(p)))
+    if err != nil {
+        return 0, err
+    }
+    return len(p), nil
+}
+
+/*
+Name: RedactWriter
+Type: API Func
+Purpose: Wrap a writer so everything written to it is
+scrubbed of secrets
+Note: Each write is scrubbed on its own, so a secret
+split across two writes gets through. Write whole
+lines or documents at a time
+*/
+func RedactWriter(w io.Writer) (io.Writer) {
+    return redactWriter{w: w}
+}
+
+/*
+Name: RedactHandler
+Type: slog.Handler
+Purpose: Wrap a slog handler so every record is scrubbed
+of secrets before it is written. Attrs named in SecretKeys
+are replaced whole, and the message and every other string,
+error or value attr are passed through Redact
+*/
+type RedactHandler struct {
+    inner            slog.Handler
+}
+
+/*
+Name: NewRedactHandler
+Type: API Func
+Purpose: Wrap a slog handler in a RedactHandler
+*/
+func NewRedactHandler(inner slog.Handler) (*RedactHandler) {
+    return &RedactHandler{inner: inner}
+}
+
+/*
+Name: Enabled
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) (bool) {
+    return h.inner.Enabled(ctx, level)
+}
+
+/*
+Name: Handle
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) (error) {
+    redacted := slog.NewRecord(r.Time, r.Level, Redact(r.Message), r.PC)
+    r.Attrs(func(attr slog.Attr) bool {
+        redacted.AddAttrs(redactAttr(attr))
+        return true
+    })
+    return h.inner.Handle(ctx, redacted)
+}
+
+/*
+Name: WithAttrs
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) (slog.Handler) {
+    redacted := make([]slog.Attr, len(attrs))
+    for i, attr := range attrs {
+        redacted[i] = redactAttr(attr)
+    }
+    return &RedactHandler{inner: h.inner.WithAttrs(redacted)}
+}
+
+/*
+Name: WithGroup
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *RedactHandler) WithGroup(name string) (slog.Handler) {
+    return &RedactHandler{inner: h.inner.WithGroup(name)}
+}
+
+/*
+Name: redactAttr
+Type: Internal Func
+Purpose: Scrub one log attr, going into groups
+*/
+func redactAttr(attr slog.Attr) (slog.Attr) {
+    attr.Value = attr.Value.Resolve()
+    for _, key := range SecretKeys {
+        if strings.EqualFold(attr.Key, key) {
+            return slog.String(attr.Key, Redacted)
+        }
+    }
+    switch attr.Value.Kind() {
+    case slog.KindString:
+        return slog.String(attr.Key, Redact(attr.Value.String()))
+    case slog.KindGroup:
+        group := attr.Value.Group()
+        redacted := make([]slog.Attr, len(group))
+        for i, member := range group {
+            redacted[i] = redactAttr(member)
+        }
+        return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}
+    case slog.KindAny:
+        if err, ok := attr.Value.Any().(error); ok {
+            return slog.String(attr.Key, Redact(err.Error()))
+        }
+        return slog.String(attr.Key, Redact(attr.Value.String()))
+    }
+    return attr
+}
@@ -0,0 +1,194 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "bytes"
+    "errors"
+    "io"
+    "log/slog"
+    "net/http"
+    "strings"
+    "testing"
+)
+
+func TestRedact(t *testing.T) {
+    // registered once, the registry only grows
+    AddSecret("tok_3f9a8b7c6d5e", "123456", "12345")
+    tests := []struct {
+        name        string
+        in          string
+        want        string
+    }{
+        {"plain text", "no secrets here", "no secrets here"},
+        {"query param", "GET /3/venue?token=abc123&day=2026-11-02", "GET /3/venue?token=" + Redacted + "&day=2026-11-02"},
+        {"form body", "email=a%40b.com&password=hunter22", "email=a%40b.com&password=" + Redacted},
+        {"json string", `{"book_token":"xyz","day":"2026-11-02"}`, `{"book_token":"` + Redacted + `","day":"2026-11-02"}`},
+        {"json number", `{"payment_method_id": 998877, "party_size": 2}`, `{"payment_method_id":"` + Redacted + `", "party_size": 2}`},
+        {"json object", `{"struct_payment_method": {"id": 998877}}`, `{"struct_payment_method":"` + Redacted + `"}`},
+        {"header", "X-Resy-Auth-Token: abc.def\r\nAccept: */*", "X-Resy-Auth-Token: " + Redacted + "\r\nAccept: */*"},
+        {"quoted", `Authorization: ResyAPI api_key="VbWkabc"`, "Authorization: " + Redacted},
+        {"quoted mid line", `ResyAPI api_key="VbWkabc"`, `ResyAPI api_key="` + Redacted + `"`},
+        {"key case", "TOKEN=abc", "TOKEN=" + Redacted},
+        {"registered secret", "auth failed for tok_3f9a8b7c6d5e today", "auth failed for " + Redacted + " today"},
+        {"digit secret alone", "payment 123456 declined", "payment " + Redacted + " declined"},
+        {"digit secret at ends", "123456", Redacted},
+        {"digit secret in a longer number", "order 91234567 placed", "order 91234567 placed"},
+        {"digit secret prefix of a number", "id 1234567", "id 1234567"},
+        {"digit secret suffix of a number", "id 0123456", "id 0123456"},
+        {"digit secret next to letters", "pm123456x", "pm" + Redacted + "x"},
+        {"shorter than MinSecretLen", "party of 12345", "party of 12345"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if got := Redact(tt.in); got != tt.want {
+                t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+            }
+        })
+    }
+}
+
+func TestAddSecretMinLen(t *testing.T) {
+    short := strings.Repeat("q", MinSecretLen - 1)
+    exact := strings.Repeat("z", MinSecretLen)
+    AddSecret(short, exact, "")
+    if got := Redact("a " + short + " b"); got != "a " + short + " b" {
+        t.Errorf("secret under MinSecretLen was redacted: %q", got)
+    }
+    if got := Redact("a " + exact + " b"); got != "a " + Redacted + " b" {
+        t.Errorf("secret of MinSecretLen not redacted: %q", got)
+    }
+}
+
+func TestRedactError(t *testing.T) {
+    AddSecret("err-secret-value")
+    base := errors.New("login failed for err-secret-value")
+    tests := []struct {
+        name        string
+        err         error
+        want        string
+    }{
+        {"registered secret", base, "login failed for " + Redacted},
+        {"wrapped sentinel", &ProviderError{Provider: "resy", Step: LoginStep, Status: 419, Err: ErrLoginWrong}, ErrLoginWrong.Error() + " (resy " + LoginStep + ", status 419)"},
+        {"query in message", errors.New(`Get "https://api.resy.com/3/x?token=abc": EOF`), `Get "https://api.resy.com/3/x?token=` + Redacted + `": EOF`},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            err := RedactError(tt.err)
+            if err.Error() != tt.want {
+                t.Errorf("Error() = %q, want %q", err.Error(), tt.want)
+            }
+            if !errors.Is(err, tt.err) {
+                t.Error("errors.Is lost the original error")
+            }
+            if RedactError(err) != err {
+                t.Error("redacting twice wrapped again")
+            }
+        })
+    }
+    if RedactError(nil) != nil {
+        t.Error("RedactError(nil) != nil")
+    }
+    if !errors.Is(RedactError(&ProviderError{Err: ErrLoginWrong}), ErrLoginWrong) {
+        t.Error("errors.Is can't see the sentinel under a redacted provider error")
+    }
+}
+
+func TestRedactHandler(t *testing.T) {
+    AddSecret("handler-secret")
+    tests := []struct {
+        name        string
+        log         func(*slog.Logger)
+        want        []string
+        notWant     []string
+    }{
+        {"message", func(l *slog.Logger) { l.Info("token=abc sent") }, []string{"token=" + Redacted}, []string{"abc"}},
+        {"secret key attr", func(l *slog.Logger) { l.Info("login", "password", "hunter22") }, []string{"password=" + Redacted}, []string{"hunter22"}},
+        {"secret key any case", func(l *slog.Logger) { l.Info("login", "Auth_Token", 42) }, []string{"Auth_Token=" + Redacted}, []string{"42"}},
+        {"string attr", func(l *slog.Logger) { l.Info("call", "url", "/x?api_key=k1") }, []string{"api_key=" + Redacted}, []string{"k1"}},
+        {"error attr", func(l *slog.Logger) { l.Warn("failed", "err", errors.New("bad handler-secret")) }, []string{"bad " + Redacted}, []string{"handler-secret"}},
+        {"group", func(l *slog.Logger) { l.Info("req", slog.Group("auth", "token", "abc", "user", "handler-secret")) }, []string{"auth.token=" + Redacted, "auth.user=" + Redacted}, []string{"abc", "handler-secret"}},
+        {"with attrs", func(l *slog.Logger) { l.With("secret", "s1").With("who", "handler-secret").Info("hi") }, []string{"secret=" + Redacted, "who=" + Redacted}, []string{"s1", "handler-secret"}},
+        {"with group", func(l *slog.Logger) { l.WithGroup("g").Info("hi", "token", "abc") }, []string{"g.token=" + Redacted}, []string{"abc"}},
+        {"untouched", func(l *slog.Logger) { l.Info("booked", "party_size", 2, "venue", "Carbone") }, []string{"party_size=2", "venue=Carbone"}, []string{Redacted}},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var out bytes.Buffer
+            // no timestamps, their digits can look like a leak
+            options := &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) (slog.Attr) {
+                if len(groups) == 0 && a.Key == slog.TimeKey {
+                    return slog.Attr{}
+                }
+                return a
+            }}
+            logger := slog.New(NewRedactHandler(slog.NewTextHandler(&out, options)))
+            tt.log(logger)
+            line := out.String()
+            for _, want := range tt.want {
+                if !strings.Contains(line, want) {
+                    t.Errorf("missing %q in %q", want, line)
+                }
+            }
+            for _, notWant := range tt.notWant {
+                if strings.Contains(line, notWant) {
+                    t.Errorf("leaked %q in %q", notWant, line)
+                }
+            }
+        })
+    }
+}
+
+func TestDumpRequest(t *testing.T) {
+    body := "email=a%40b.com&password=hunter22"
+    req, err := http.NewRequest("POST", "https://api.resy.com/3/auth/password?api_key=k1", strings.NewReader(body))
+    if err != nil {
+        t.Fatal(err)
+    }
+    req.Header.Set("Authorization", `ResyAPI api_key="VbWkabc"`)
+    req.Header.Set("X-Resy-Auth-Token", "tok.abc")
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+    dump := DumpRequest(req)
+    for _, leak := range []string{"hunter22", "VbWkabc", "tok.abc", "k1"} {
+        if strings.Contains(dump, leak) {
+            t.Errorf("dump leaked %q:\n%s", leak, dump)
+        }
+    }
+    for _, want := range []string{"POST /3/auth/password", "Content-Type: application/x-www-form-urlencoded", "email=a%40b.com"} {
+        if !strings.Contains(dump, want) {
+            t.Errorf("dump missing %q:\n%s", want, dump)
+        }
+    }
+    sent, err := io.ReadAll(req.Body)
+    if err != nil || string(sent) != body {
+        t.Errorf("body after dump = %q, %v, want %q", sent, err, body)
+    }
+}
+
+func TestDumpResponse(t *testing.T) {
+    body := `{"token":"tok.abc","first_name":"Ann","payment_method_id":998877}`
+    resp := &http.Response{
+        StatusCode: 200,
+        ProtoMajor: 1,
+        ProtoMinor: 1,
+        Header: http.Header{"Set-Cookie": []string{"sid=1"}, "Content-Type": []string{"application/json"}},
+        Body: io.NopCloser(strings.NewReader(body)),
+        ContentLength: int64(len(body)),
+    }
+    dump := DumpResponse(resp)
+    for _, leak := range []string{"tok.abc", "998877"} {
+        if strings.Contains(dump, leak) {
+            t.Errorf("dump leaked %q:\n%s", leak, dump)
+        }
+    }
+    if !strings.Contains(dump, `"first_name":"Ann"`) {
+        t.Errorf("dump missing body:\n%s", dump)
+    }
+    read, err := io.ReadAll(resp.Body)
+    if err != nil || string(read) != body {
+        t.Errorf("body after dump = %q, %v, want %q", read, err, body)
+    }
+}
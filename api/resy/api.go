@@ -11,10 +11,14 @@ import (
     "encoding/json"
     "io"
     "bytes"
+    "sort"
     "strconv"
     "strings"
     "time"
-    "fmt"
+    "context"
+    "log/slog"
+    "sync"
+    "errors"
 )
 
 /*
@@ -29,6 +33,32 @@ setting
 */
 type API struct {
     APIKey      string 
+    // Logs calls made without a request logger, nothing
+    // is logged if unset
+    Logger      *slog.Logger
+    // Client requests are sent with, the package's shared
+    // keep-alive client if unset
+    Client      *http.Client
+}
+
+/*
+Name: sharedClient
+Type: Internal Var
+Purpose: Client shared by every resy API without one of
+its own, so connections stay open between calls
+*/
+var sharedClient = api.NewClient()
+
+/*
+Name: httpClient
+Type: Internal Func
+Purpose: Return the client to send requests with
+*/
+func (a *API) httpClient() (*http.Client) {
+    if a.Client != nil {
+        return a.Client
+    }
+    return sharedClient
 }
 
 /*
@@ -73,6 +103,83 @@ func min(a,b int) (int) {
     return b
 }
 
+/*
+Name: jsonIDString 
+Type: Internal Func 
+Purpose: Resy sends ids as either JSON numbers, strings,
+or objects like {"resy": 123}, this normalizes all to a string
+*/
+func jsonIDString(raw interface{}) (string) {
+    switch v := raw.(type) {
+    case string:
+        return v
+    case float64:
+        return strconv.FormatInt(int64(v), 10)
+    case map[string]interface{}:
+        return jsonIDString(v["resy"])
+    }
+    return ""
+}
+
+/*
+Name: parseVenueInfo 
+Type: Internal Func 
+Purpose: Pull the venue name and a one line address
+out of a find or reservations venue JSON map, either
+may come back empty if Resy omits them
+*/
+func parseVenueInfo(jsonVenueMap map[string]interface{}) (string, string) {
+    // find nests the venue details one level down
+    if jsonInnerMap, ok := jsonVenueMap["venue"].(map[string]interface{}); ok {
+        jsonVenueMap = jsonInnerMap
+    }
+    name, _ := jsonVenueMap["name"].(string)
+    jsonLocationMap, ok := jsonVenueMap["location"].(map[string]interface{})
+    if !ok {
+        return name, ""
+    }
+    addressFields := []string{}
+    for _, key := range []string{"address_1", "address_2", "locality", "region", "postal_code"} {
+        if field, ok := jsonLocationMap[key].(string); ok && field != "" {
+            addressFields = append(addressFields, field)
+        }
+    }
+    return name, strings.Join(addressFields, ", ")
+}
+
+/*
+Name: parseFeePolicy
+Type: Internal Func
+Purpose: Read the fee terms out of a find slot or a details
+response. Both may hold a "payment" map, with deposit and
+cancellation fees that are null when there is none and an
+is_paid flag for prepaid slots, and details may hold a
+"cancellation" map whose "fee" has the amount charged for
+a late cancel. Missing fields read as no fee
+*/
+func parseFeePolicy(jsonMap map[string]interface{}) (api.FeePolicy) {
+    fees := api.FeePolicy{}
+    if jsonPaymentMap, ok := jsonMap["payment"].(map[string]interface{}); ok {
+        if depositFee, ok := jsonPaymentMap["deposit_fee"].(float64); ok {
+            fees.Deposit = depositFee
+        }
+        if cancellationFee, ok := jsonPaymentMap["cancellation_fee"].(float64); ok {
+            fees.CancellationFee = cancellationFee
+        }
+        if isPaid, ok := jsonPaymentMap["is_paid"].(bool); ok {
+            fees.Prepay = isPaid
+        }
+    }
+    if jsonCancellationMap, ok := jsonMap["cancellation"].(map[string]interface{}); ok {
+        if jsonFeeMap, ok := jsonCancellationMap["fee"].(map[string]interface{}); ok {
+            if amount, ok := jsonFeeMap["amount"].(float64); ok && amount > fees.CancellationFee {
+                fees.CancellationFee = amount
+            }
+        }
+    }
+    return fees
+}
+
 /*
 Name: GetDefaultAPI 
 Type: External Func 
@@ -80,8 +187,10 @@ Purpose: Function that provides an out of the box
 working API struct
 */
 func GetDefaultAPI() (API){
+    apiKey := "VbWk7s3L4KiK5fzlO7JD3Q5EYolJI7n5"
+    api.AddSecret(apiKey)
     return API{
-        APIKey: "VbWk7s3L4KiK5fzlO7JD3Q5EYolJI7n5",
+        APIKey: apiKey,
     }
 }
 
@@ -93,7 +202,21 @@ Note: The only required login fields for this func
 are Email and Password.
 */
 func (a *API) Login(params api.LoginParam) (*api.LoginResponse, error) {
+    start := time.Now()
+    resp, err := a.login(params)
+    api.ObserveStep("resy", api.LoginStep, start, err)
+    return resp, err
+}
+
+/*
+Name: login
+Type: Internal Func
+Purpose: Login without recording metrics
+*/
+func (a *API) login(params api.LoginParam) (*api.LoginResponse, error) {
     authUrl := "https://api.resy.com/3/auth/password"
+    // keep the credentials out of every log and error
+    api.AddSecret(params.Password, a.APIKey)
     email := url.QueryEscape(params.Email)
     password := url.QueryEscape(params.Password)
     bodyStr :=`email=` + email + `&password=` + password
@@ -107,24 +230,26 @@ func (a *API) Login(params api.LoginParam) (*api.LoginResponse, error) {
     request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
     request.Header.Set("Authorization", `ResyAPI api_key="` + a.APIKey + `"`)
 
-    client := &http.Client{}
+    client := a.httpClient()
+    request, span := params.Trace.Start("resy", api.LoginStep, request)
     response, err := client.Do(request)
+    span.End(response, err)
 
     if err != nil {
         return nil, err
     }
 
+    defer response.Body.Close()
+
     // Resy servers return a 419 is the auth parameters were invalid
     if response.StatusCode == 419 {
-        return nil, api.ErrLoginWrong
+        return nil, api.NewProviderError("resy", api.LoginStep, response, api.ErrLoginWrong)
     }
 
     if isCodeFail(response.StatusCode) {
-        return nil, api.ErrNetwork
+        return nil, api.NewProviderError("resy", api.LoginStep, response, api.ErrNetwork)
     }
 
-    defer response.Body.Close()
-
     responseBody, err := io.ReadAll(response.Body)
 
     if err != nil {
@@ -143,13 +268,16 @@ func (a *API) Login(params api.LoginParam) (*api.LoginResponse, error) {
     }
 
 
+    paymentMethodID := int64(jsonMap["payment_method_id"].(float64))
+    api.AddSecret(jsonMap["token"].(string), strconv.FormatInt(paymentMethodID, 10))
+
     loginResponse := api.LoginResponse{
         ID:              int64(jsonMap["id"].(float64)),
         FirstName:       jsonMap["first_name"].(string),
         LastName:        jsonMap["last_name"].(string),
         Mobile:          jsonMap["mobile_number"].(string),
         Email:           jsonMap["em_address"].(string),
-        PaymentMethodID: int64(jsonMap["payment_method_id"].(float64)),
+        PaymentMethodID: paymentMethodID,
         AuthToken:       jsonMap["token"].(string),
     }
 
@@ -163,8 +291,20 @@ Type: API Func
 Purpose: Resy implementation of the Search api func
 */
 func (a *API) Search(params api.SearchParam) (*api.SearchResponse, error) {
+    start := time.Now()
+    resp, err := a.search(params)
+    api.ObserveStep("resy", api.SearchStep, start, err)
+    return resp, err
+}
+
+/*
+Name: search
+Type: Internal Func
+Purpose: Search without recording metrics
+*/
+func (a *API) search(params api.SearchParam) (*api.SearchResponse, error) {
     searchUrl := "https://api.resy.com/3/venuesearch/search"
-    fmt.Print("s3earch url",searchUrl)
+    api.PickLogger(a.Logger).Debug("searching venues", "name", params.Name)
 
     bodyStr :=`{"query":"` + params.Name +`"}`
     bodyBytes := []byte(bodyStr)
@@ -179,19 +319,19 @@ func (a *API) Search(params api.SearchParam) (*api.SearchResponse, error) {
     request.Header.Set("Origin", `https://resy.com`)
     request.Header.Set("Referer", `https://resy.com/`)
 
-    client := &http.Client{}
+    client := a.httpClient()
     response, err := client.Do(request)
 
     if err != nil {
         return nil, err
     }
 
+    defer response.Body.Close()
+
     if isCodeFail(response.StatusCode) {
-        return nil, api.ErrNetwork
+        return nil, api.NewProviderError("resy", api.SearchStep, response, api.ErrNetwork)
     }
 
-    defer response.Body.Close()
-
     responseBody, err := io.ReadAll(response.Body)
     if err != nil {
         return nil, err
@@ -239,354 +379,786 @@ func (a *API) Search(params api.SearchParam) (*api.SearchResponse, error) {
 }
 
 /*
-Name: Reserve
-Type: API Func 
-Purpose: Resy implementation of the Reserve api func
+Name: findResult
+Type: Internal Struct
+Purpose: The parsed response of a find request, holding
+the venue info and every open slot on the day
 */
-func (a *API) Reserve(params api.ReserveParam) (*api.ReserveResponse, error) {
-    fmt.Println("Starting Reserve function")
-    defer fmt.Println("Exiting Reserve function")
+type findResult struct {
+    // Day in the format the later booking steps expect
+    Date            string
+    VenueName       string
+    VenueAddress    string
+    Slots           []findSlot
+}
+
+/*
+Name: findSlot
+Type: Internal Struct
+Purpose: One open slot from a find request
+*/
+type findSlot struct {
+    Time            time.Time
+    TableType       string
+    // config token which starts the booking steps
+    Token           string
+    // fees as listed with the slot
+    Fees            api.FeePolicy
+}
 
+/*
+Name: find
+Type: Internal Func
+Purpose: Run the find request for a venue, day and party size
+and parse the open slots. Slot times are put in the location
+of the given day. Malformed slots are skipped
+*/
+func (a *API) find(venueID int64, day time.Time, partySize int, authToken string, trace *api.Trace, deadline time.Time) (*findResult, error) {
+    start := time.Now()
+    found, err := a.findSlots(venueID, day, partySize, authToken, trace, deadline)
+    api.ObserveStep("resy", api.FindStep, start, err)
+    return found, err
+}
+
+/*
+Name: findSlots
+Type: Internal Func
+Purpose: find without recording metrics
+*/
+func (a *API) findSlots(venueID int64, day time.Time, partySize int, authToken string, trace *api.Trace, deadline time.Time) (*findResult, error) {
     // Converting fields to URL query format
-    fmt.Println("Converting reservation times to date string")
-    year := strconv.Itoa(params.ReservationTimes[0].Year())
-    month := strconv.Itoa(int(params.ReservationTimes[0].Month()))
-    day := strconv.Itoa(params.ReservationTimes[0].Day())
-    date := year + "-" + month + "-" + day
-    fmt.Printf("Formatted date: %s\n", date)
+    year := strconv.Itoa(day.Year())
+    month := strconv.Itoa(int(day.Month()))
+    dayNum := strconv.Itoa(day.Day())
+    date := year + "-" + month + "-" + dayNum
 
     dayField := `day=` + date
-    authField := `x-resy-auth-token=` + params.LoginResp.AuthToken
+    authField := `x-resy-auth-token=` + authToken
     latField := `lat=0`
     longField := `long=0`
-    venueIDField := `venue_id=` + strconv.FormatInt(params.VenueID, 10)
-    partySizeField := `party_size=` + strconv.Itoa(params.PartySize)
+    venueIDField := `venue_id=` + strconv.FormatInt(venueID, 10)
+    partySizeField := `party_size=` + strconv.Itoa(partySize)
     fields := []string{dayField, authField, latField, longField, venueIDField, partySizeField}
 
     findUrl := `https://api.resy.com/4/find?` + strings.Join(fields, "&")
-    fmt.Printf("Find URL: %s\n", findUrl)
 
     request, err := http.NewRequest("GET", findUrl, bytes.NewBuffer([]byte{}))
     if err != nil {
-        fmt.Printf("Error creating find request: %v\n", err)
         return nil, err
     }
 
-    // Setting headers
-    fmt.Println("Setting headers for find request")
     request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
     request.Header.Set("Authorization", `ResyAPI api_key="`+a.APIKey+`"`)
-    request.Header.Set("X-Resy-Auth-Token", params.LoginResp.AuthToken)
-    request.Header.Set("X-Resy-Universal-Auth", params.LoginResp.AuthToken)
+    request.Header.Set("X-Resy-Auth-Token", authToken)
+    request.Header.Set("X-Resy-Universal-Auth", authToken)
     request.Header.Set("Referer", "https://resy.com/")
 
-    client := &http.Client{}
-    fmt.Println("Sending find request")
+    client := a.httpClient()
+    request, span := trace.Start("resy", api.FindStep, api.WithRetryDeadline(request, deadline))
     response, err := client.Do(request)
+    span.End(response, err)
     if err != nil {
-        fmt.Printf("Error sending find request: %v\n", err)
         return nil, err
     }
-    fmt.Printf("Received find response with status code: %d\n", response.StatusCode)
+
+    defer response.Body.Close()
 
     if isCodeFail(response.StatusCode) {
-        fmt.Printf("Find request failed with status code: %d\n", response.StatusCode)
-        return nil, api.ErrNetwork
+        return nil, api.NewProviderError("resy", api.FindStep, response, api.ErrNetwork)
     }
 
-    defer response.Body.Close()
-
     responseBody, err := io.ReadAll(response.Body)
     if err != nil {
-        fmt.Printf("Error reading find response body: %v\n", err)
         return nil, err
     }
-    fmt.Printf("Find response body: %s\n", string(responseBody))
 
     var jsonTopLevelMap map[string]interface{}
     err = json.Unmarshal(responseBody, &jsonTopLevelMap)
     if err != nil {
-        fmt.Printf("Error unmarshaling find response JSON: %v\n", err)
         return nil, err
     }
 
-    // Navigate JSON structure
-    fmt.Println("Parsing JSON response for venues and slots")
     jsonResultsMap, ok := jsonTopLevelMap["results"].(map[string]interface{})
     if !ok {
-        fmt.Println("Error: 'results' key not found or invalid in JSON response")
-        return nil, api.ErrNetwork
+        return nil, api.BodyProviderError("resy", api.FindStep, response.StatusCode, responseBody, api.ErrNetwork)
     }
 
     jsonVenuesList, ok := jsonResultsMap["venues"].([]interface{})
     if !ok {
-        fmt.Println("Error: 'venues' key not found or invalid in JSON response")
-        return nil, api.ErrNetwork
+        return nil, api.BodyProviderError("resy", api.FindStep, response.StatusCode, responseBody, api.ErrNetwork)
     }
 
     if len(jsonVenuesList) == 0 {
-        fmt.Println("No venues found in the response")
         return nil, api.ErrNoOffer
     }
 
     jsonVenueMap, ok := jsonVenuesList[0].(map[string]interface{})
     if !ok {
-        fmt.Println("Error: Invalid venue structure in JSON response")
-        return nil, api.ErrNetwork
+        return nil, api.BodyProviderError("resy", api.FindStep, response.StatusCode, responseBody, api.ErrNetwork)
     }
 
+    result := findResult{Date: date}
+    result.VenueName, result.VenueAddress = parseVenueInfo(jsonVenueMap)
+
     jsonSlotsList, ok := jsonVenueMap["slots"].([]interface{})
     if !ok {
-        fmt.Println("Error: 'slots' key not found or invalid in venue JSON")
-        return nil, api.ErrNetwork
+        return nil, api.BodyProviderError("resy", api.FindStep, response.StatusCode, responseBody, api.ErrNetwork)
     }
 
-    fmt.Printf("Number of slots available: %d\n", len(jsonSlotsList))
-
-    // Iterate over table types and reservation times
-    for k := 0; k < len(params.TableTypes) || (len(params.TableTypes) == 0 && k == 0); k++ {
-        var currentTableType api.TableType
-        if len(params.TableTypes) != 0 {
-            currentTableType = params.TableTypes[k]
-            fmt.Printf("Searching for table type: %s\n", currentTableType)
-        } else {
-            currentTableType = api.DiningRoom
-            fmt.Printf("No specific table type provided. Using default: %s\n", currentTableType)
+    for _, jsonSlot := range jsonSlotsList {
+        jsonSlotMap, ok := jsonSlot.(map[string]interface{})
+        if !ok {
+            continue
+        }
+        jsonDateMap, ok := jsonSlotMap["date"].(map[string]interface{})
+        if !ok {
+            continue
         }
+        startRaw, ok := jsonDateMap["start"].(string)
+        if !ok {
+            continue
+        }
+        startFields := strings.Split(startRaw, " ")
+        if len(startFields) != 2 {
+            continue
+        }
+        timeFields := strings.Split(startFields[1], ":")
+        if len(timeFields) != 3 {
+            continue
+        }
+        hourFieldInt, err := strconv.Atoi(timeFields[0])
+        if err != nil {
+            continue
+        }
+        minFieldInt, err := strconv.Atoi(timeFields[1])
+        if err != nil {
+            continue
+        }
+        jsonConfigMap, ok := jsonSlotMap["config"].(map[string]interface{})
+        if !ok {
+            continue
+        }
+        tableType, ok := jsonConfigMap["type"].(string)
+        if !ok {
+            continue
+        }
+        configToken, ok := jsonConfigMap["token"].(string)
+        if !ok {
+            continue
+        }
+        slot := findSlot{
+            Time: time.Date(day.Year(), day.Month(), day.Day(), hourFieldInt, minFieldInt, 0, 0, day.Location()),
+            TableType: tableType,
+            Token: configToken,
+        }
+        slot.Fees = parseFeePolicy(jsonSlotMap)
+        result.Slots = append(result.Slots, slot)
+    }
 
-        for i := 0; i < len(params.ReservationTimes); i++ {
-            currentTime := params.ReservationTimes[i]
-            fmt.Printf("Checking reservation time: %s\n", currentTime.Format("2006-01-02 15:04:00"))
+    return &result, nil
+}
 
-            for j := 0; j < len(jsonSlotsList); j++ {
-                fmt.Printf("Evaluating slot %d\n", j)
-                jsonSlotMap, ok := jsonSlotsList[j].(map[string]interface{})
-                if !ok {
-                    fmt.Printf("Error: Invalid slot structure at index %d\n", j)
-                    continue
-                }
+/*
+Name: resyCandidate
+Type: Internal Struct
+Purpose: A candidate slot along with what the booking
+steps need to book it
+*/
+type resyCandidate struct {
+    api.Candidate
+    // config token which starts the booking steps
+    Token           string
+    // day as sent to the find request
+    Date            string
+    VenueName       string
+    VenueAddress    string
+}
 
-                jsonDateMap, ok := jsonSlotMap["date"].(map[string]interface{})
-                if !ok {
-                    fmt.Printf("Error: 'date' key missing or invalid in slot %d\n", j)
-                    continue
+/*
+Name: candidates
+Type: Internal Func
+Purpose: List every open slot that fits a reserve request,
+ranked by its selector. The find request only lists one day,
+so it runs once per day and party size. A find which fails
+is skipped, and its error is only returned if no find
+succeeded at all
+*/
+func (a *API) candidates(params api.ReserveParam) ([]resyCandidate, error) {
+    logger := api.PickLogger(params.Logger, a.Logger)
+    var firstErr error
+    loaded := false
+    candidates := []resyCandidate{}
+    for _, partySize := range params.PartySizes() {
+        seen := map[string]bool{}
+        for _, window := range params.Windows() {
+            key := window.Start.Format("2006-01-02")
+            if seen[key] {
+                continue
+            }
+            seen[key] = true
+            found, err := a.find(params.VenueID, window.Start, partySize, params.LoginResp.AuthToken, params.Trace, params.Deadline)
+            if err != nil {
+                logger.Warn("find failed", "day", key, "party_size", partySize, "err", err)
+                if firstErr == nil {
+                    firstErr = err
                 }
-
-                startRaw, ok := jsonDateMap["start"].(string)
+                continue
+            }
+            loaded = true
+            logger.Debug("found slots", "day", key, "party_size", partySize, "slots", len(found.Slots))
+            for _, slot := range found.Slots {
+                candidate, ok := params.Candidate(slot.Time, slot.TableType, partySize)
                 if !ok {
-                    fmt.Printf("Error: 'start' key missing or invalid in slot %d\n", j)
                     continue
                 }
-                fmt.Printf("Slot start time: %s\n", startRaw)
+                candidate.Fees = slot.Fees
+                candidates = append(candidates, resyCandidate{
+                    Candidate: candidate,
+                    Token: slot.Token,
+                    Date: found.Date,
+                    VenueName: found.VenueName,
+                    VenueAddress: found.VenueAddress,
+                })
+            }
+        }
+    }
+    if !loaded && firstErr != nil {
+        return nil, firstErr
+    }
+    selector := params.SlotSelector()
+    sort.SliceStable(candidates, func(i, j int) bool {
+        return selector.Less(candidates[i].Candidate, candidates[j].Candidate)
+    })
+    return candidates, nil
+}
 
-                startFields := strings.Split(startRaw, " ")
-                if len(startFields) != 2 {
-                    fmt.Printf("Error: Unexpected 'start' format in slot %d\n", j)
-                    continue
-                }
+/*
+Name: Find
+Type: API Func 
+Purpose: Resy implementation of the optional 
+Find api func
+*/
+func (a *API) Find(params api.FindParam) (*api.FindResponse, error) {
+    logger := api.PickLogger(params.Logger, a.Logger)
+    found, err := a.find(params.VenueID, params.Day, params.PartySize, params.LoginResp.AuthToken, params.Trace, time.Time{})
+    if err != nil {
+        logger.Warn("find failed", "day", params.Day.Format("2006-01-02"), "party_size", params.PartySize, "err", err)
+        return nil, err
+    }
+    logger.Debug("found slots", "day", params.Day.Format("2006-01-02"), "party_size", params.PartySize, "slots", len(found.Slots))
+    slots := make([]api.Slot, len(found.Slots))
+    for i, slot := range found.Slots {
+        slots[i] = api.Slot{
+            Time: slot.Time,
+            TableType: slot.TableType,
+        }
+    }
+    return &api.FindResponse{
+        VenueName: found.VenueName,
+        VenueAddress: found.VenueAddress,
+        Slots: slots,
+    }, nil
+}
 
-                timeFields := strings.Split(startFields[1], ":")
-                if len(timeFields) != 3 {
-                    fmt.Printf("Error: Unexpected time format in slot %d\n", j)
-                    continue
-                }
+/*
+Name: Reserve
+Type: API Func 
+Purpose: Resy implementation of the Reserve api func
+Note: Open slots are tried in the order the request's
+selector ranks them, see 'candidates'
+*/
+func (a *API) Reserve(params api.ReserveParam) (*api.ReserveResponse, error) {
+    logger := api.PickLogger(params.Logger, a.Logger)
 
-                hourFieldInt, err := strconv.Atoi(timeFields[0])
-                if err != nil {
-                    fmt.Printf("Error parsing hour in slot %d: %v\n", j, err)
-                    continue
-                }
+    if len(params.Windows()) == 0 {
+        return nil, api.ErrTimeNull
+    }
 
-                minFieldInt, err := strconv.Atoi(timeFields[1])
-                if err != nil {
-                    fmt.Printf("Error parsing minute in slot %d: %v\n", j, err)
-                    continue
-                }
+    candidates, err := a.candidates(params)
+    if err != nil {
+        return nil, err
+    }
 
-                jsonConfigMap, ok := jsonSlotMap["config"].(map[string]interface{})
-                if !ok {
-                    fmt.Printf("Error: 'config' key missing or invalid in slot %d\n", j)
-                    continue
+    logger.Debug("ranked slots", "candidates", len(candidates))
+
+    client := a.httpClient()
+    // the first slot turned down for its fees, reported
+    // if nothing else could be booked either
+    var feeErr error
+    // the first book Resy turned down, which says more
+    // than a slot turned down for its fees
+    var bookErr *api.ProviderError
+    // the first details request that failed, which
+    // only fails the reserve if nothing else books
+    var detailsErr error
+
+    // Details are fetched for up to ParallelDetails slots at
+    // once, then booked in rank order. Booking only ever
+    // happens here, one slot at a time, and stops at the
+    // first confirmation or the first book without a clear
+    // answer, so at most one slot is booked
+    batchSize := params.ParallelDetails
+    if batchSize < 1 {
+        batchSize = 1
+    }
+    for batchStart := 0; batchStart < len(candidates); batchStart += batchSize {
+        batch := candidates[batchStart:min(batchStart + batchSize, len(candidates))]
+        slotLoggers := make([]*slog.Logger, len(batch))
+        for i, slot := range batch {
+            slotLoggers[i] = logger.With("rank", batchStart + i, "slot_time", slot.Time.Format("2006-01-02 15:04"), "seating", slot.Seating, "party_size", slot.PartySize)
+            slotLoggers[i].Info("trying slot")
+        }
+        results := make([]slotDetails, len(batch))
+        if len(batch) == 1 {
+            results[0] = a.details(client, batch[0], params, slotLoggers[0])
+        } else {
+            var wg sync.WaitGroup
+            for i := range batch {
+                wg.Add(1)
+                go func(i int) {
+                    defer wg.Done()
+                    results[i] = a.details(client, batch[i], params, slotLoggers[i])
+                }(i)
+            }
+            wg.Wait()
+        }
+
+        // Iterate over the ranked slots until one books
+        for i, slot := range batch {
+            result := results[i]
+            slotLogger := slotLoggers[i]
+            if result.detailsErr != nil {
+                // one slot's details failing says nothing
+                // about the rest, so move on to the next
+                slotLogger.Info("skipping slot", "err", result.detailsErr)
+                if detailsErr == nil {
+                    detailsErr = result.detailsErr
                 }
+                continue
+            }
+            if result.feeErr != nil {
+                if feeErr == nil {
+                    feeErr = result.feeErr
+                }
+                continue
+            }
+            if result.bookToken == "" {
+                continue
+            }
+            currentTableType := params.TableType(slot.Candidate)
+
+            // a dry run stops short of the book call, the
+            // token proves the slot could have been booked
+            if params.DryRun {
+                slotLogger.Info("dry run, not booking")
+                resp := api.ReserveResponse{
+                    ReservationTime: slot.Time,
+                    VenueName: slot.VenueName,
+                    VenueAddress: slot.VenueAddress,
+                    PartySize: slot.PartySize,
+                    Fees: result.fees,
+                    DryRun: true,
+                }
+                if len(params.TableTypes) != 0 {
+                    resp.TableType = currentTableType
+                }
+                return &resp, nil
+            }
 
-                tableType, ok := jsonConfigMap["type"].(string)
-                if !ok {
-                    fmt.Printf("Error: 'type' key missing or invalid in config of slot %d\n", j)
-                    continue
+            resp, err := a.book(client, slot, result, params, slotLogger)
+            if err != nil {
+                // only a book Resy turned down is safe to
+                // follow with another, any other failure
+                // may have booked this slot
+                var providerErr *api.ProviderError
+                if !errors.Is(err, api.ErrNoTable) || !errors.As(err, &providerErr) {
+                    slotLogger.Warn("book outcome unknown, not trying more slots", "err", err)
+                    return nil, err
                 }
-                fmt.Printf("Slot table type: %s\n", tableType)
-
-                // Check if the slot matches the desired time and table type
-                if hourFieldInt == currentTime.Hour() && minFieldInt == currentTime.Minute() &&
-                    (len(params.TableTypes) == 0 || strings.Contains(strings.ToLower(tableType), string(currentTableType))) {
-                    fmt.Printf("Found matching slot at index %d for time %s and table type %s\n", j, currentTime.Format("15:04"), currentTableType)
-
-                    configToken, ok := jsonConfigMap["token"].(string)
-                    if !ok {
-                        fmt.Printf("Error: 'token' key missing or invalid in config of slot %d\n", j)
-                        continue
-                    }
-
-                    detailUrl := "https://api.resy.com/3/details"
-                    fmt.Printf("Detail URL: %s\n", detailUrl)
-
-                    // Prepare the request body
-                    requestBody := map[string]string{
-                        "commit":     strconv.Itoa(1),                  // Convert integer 1 to string
-                        "config_id":  configToken,                      // Assuming configToken is already a string
-                        "day":        date,                             // Assuming date is already a string
-                        "party_size": strconv.Itoa(params.PartySize),   // Convert PartySize (an int) to string
-                    }
-                    jsonBody, err := json.Marshal(requestBody)
-                     
-                    if err != nil {
-                        fmt.Printf("Error marshaling request body: %v\n", err)
-                        continue
-                    }
-                    fmt.Printf("Request Body: %s\n", string(jsonBody)) // Add this line
-
-                    requestDetail, err := http.NewRequest("POST", detailUrl, bytes.NewBuffer(jsonBody))
-                    if err != nil {
-                        fmt.Printf("Error creating detail request: %v\n", err)
-                        continue
-                    }
-
-                    // Setting headers for detail request
-                    // Set the appropriate headers
-                    requestDetail.Header.Set("Content-Type", "application/json")
-                    requestDetail.Header.Set("Authorization", "ResyAPI api_key=\"VbWk7s3L4KiK5fzlO7JD3Q5EYolJI7n5\"")
-                    requestDetail.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-                    // Log the request headers
-                    fmt.Println("Request Headers:")
-                    for key, value := range requestDetail.Header {
-                        fmt.Printf("%s: %s\n", key, strings.Join(value, ", "))
-                    }
-
-                    fmt.Println("Sending detail request")
-                    responseDetail, err := client.Do(requestDetail)
-                    print(responseDetail)
-                    if err != nil {
-                        fmt.Printf("Error sending detail request: %v\n", err)
-                        continue
-                    }
-                    fmt.Printf("Received detail response with status code: %d\n", responseDetail.StatusCode)
-
-                    if isCodeFail(responseDetail.StatusCode) {
-                        responseDetailBody, err := io.ReadAll(responseDetail.Body)
-                        if err != nil {
-                            fmt.Printf("Error reading detail response body: %v\n", err)
-                            continue
-                        }
-                        fmt.Printf("Detail response body: %s\n", string(responseDetailBody))
-                        fmt.Printf("Detail request failed with status code: %d\n", responseDetail.StatusCode)
-                        return nil, api.ErrNetwork
-                    }
-
-                    defer responseDetail.Body.Close()
-
-                    responseDetailBody, err := io.ReadAll(responseDetail.Body)
-                    fmt.Printf("Detail response body: %s\n", string(responseDetailBody))
-                    if err != nil {
-                        fmt.Printf("Error reading detail response body: %v\n", err)
-                        continue
-                    }
-                    fmt.Printf("Detail response body: %s\n", string(responseDetailBody))
-
-                    var detailTopLevelMap map[string]interface{}
-                    err = json.Unmarshal(responseDetailBody, &detailTopLevelMap)
-                    if err != nil {
-                        fmt.Printf("Error unmarshaling detail response JSON: %v\n", err)
-                        return nil, err
-                    }
-
-                    jsonBookTokenMap, ok := detailTopLevelMap["book_token"].(map[string]interface{})
-                    if !ok {
-                        fmt.Println("Error: 'book_token' key missing or invalid in detail JSON")
-                        continue
-                    }
-
-                    bookToken, ok := jsonBookTokenMap["value"].(string)
-                    if !ok {
-                        fmt.Println("Error: 'value' key missing or invalid in 'book_token'")
-                        continue
-                    }
-                    fmt.Printf("Obtained book token: %s\n", bookToken)
-
-                    // Proceed to booking step
-                    bookUrl := "https://api.resy.com/3/book"
-                    fmt.Printf("Book URL: %s\n", bookUrl)
-
-                    bookField := "book_token=" + url.QueryEscape(bookToken)
-                    paymentMethodStr := `{"id":` + strconv.FormatInt(params.LoginResp.PaymentMethodID, 10) + `}`
-                    paymentMethodField := "struct_payment_method=" + url.QueryEscape(paymentMethodStr)
-                    requestBookBodyStr := bookField + "&" + paymentMethodField + "&" + "source_id=resy.com-venue-details"
-                    fmt.Printf("Book request body: %s\n", requestBookBodyStr)
-
-                    requestBook, err := http.NewRequest("POST", bookUrl, bytes.NewBuffer([]byte(requestBookBodyStr)))
-                    if err != nil {
-                        fmt.Printf("Error creating book request: %v\n", err)
-                        continue
-                    }
-
-                    // Setting headers for book request
-                    fmt.Println("Setting headers for book request")
-                    requestBook.Header.Set("Authorization", `ResyAPI api_key="`+a.APIKey+`"`)
-                    requestBook.Header.Set("Content-Type", `application/x-www-form-urlencoded`)
-                    requestBook.Header.Set("Host", `api.resy.com`)
-                    requestBook.Header.Set("X-Resy-Auth-Token", params.LoginResp.AuthToken)
-                    requestBook.Header.Set("X-Resy-Universal-Auth", params.LoginResp.AuthToken)
-                    requestBook.Header.Set("Referer", "https://resy.com/")
-                    requestBook.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-
-                    fmt.Println("Sending book request")  
-                    responseBook, err := client.Do(requestBook)
-                    if err != nil {
-                        fmt.Printf("Error sending book request: %v\n", err)
-                        continue
-                    }
-                    fmt.Printf("Received book response with status code: %d\n", responseBook.StatusCode)
-
-                    if isCodeFail(responseBook.StatusCode) {
-                        fmt.Printf("Book request failed with status code: %d\n", responseBook.StatusCode)
-                        continue
-                    }
-
-                    responseBookBody, err := io.ReadAll(responseBook.Body)
-                    if err != nil {
-                        fmt.Printf("Error reading book response body: %v\n", err)
-                        continue
-                    }
-                    fmt.Printf("Book response body: %s\n", string(responseBookBody))
-
-                    var bookTopLevelMap map[string]interface{}
-                    err = json.Unmarshal(responseBookBody, &bookTopLevelMap)
-                    if err != nil {
-                        fmt.Printf("Error unmarshaling book response JSON: %v\n", err)
-                        continue
-                    }
-
-                    // Check if booking was successful
-                    if _, ok := bookTopLevelMap["reservation_id"]; ok {
-                        fmt.Println("Booking confirmed successfully")
-                        resp := api.ReserveResponse{
-                            ReservationTime: currentTime,
-                        }
-                        return &resp, nil
-                    } else {
-                        fmt.Println("Booking response does not contain confirmation")
-                        fmt.Printf("Book response JSON: %v\n", bookTopLevelMap)
-                        continue
-                    }
+                if bookErr == nil {
+                    bookErr = providerErr
                 }
+                continue
+            }
+            if len(params.TableTypes) != 0 {
+                resp.TableType = currentTableType
             }
+            return resp, nil
         }
     }
 
+    // A slot turned down by Resy or for its fees
+    // says more than a bare no table error
+    if bookErr != nil {
+        return nil, bookErr
+    }
+    if detailsErr != nil {
+        return nil, detailsErr
+    }
+    if feeErr != nil {
+        return nil, feeErr
+    }
     // If no table was found after all iterations
-    fmt.Println("No available tables found for the given parameters")
+    logger.Info("no slot could be booked")
     return nil, api.ErrNoTable
 }
 
+/*
+Name: slotDetails
+Type: Internal Struct
+Purpose: What the details step made of a slot. A slot
+with no book token and neither error set had nothing to
+book and is skipped
+*/
+type slotDetails struct {
+    bookToken       string
+    // full terms of the slot
+    fees            api.FeePolicy
+    // set if the slot was turned down for its fees
+    feeErr          error
+    // set if the details request failed, the slot
+    // is skipped
+    detailsErr      error
+}
+
+/*
+Name: details
+Type: Internal Func
+Purpose: Run the details step of a slot, getting the
+book token and full terms. Safe to run for several
+slots at once
+*/
+func (a *API) details(client *http.Client, slot resyCandidate, params api.ReserveParam, slotLogger *slog.Logger) (slotDetails) {
+    // skip slots whose listed fees are over the
+    // limit before spending a request on them
+    if err := params.FeeLimit.Check(slot.Time, slot.Fees); err != nil {
+        slotLogger.Info("skipping slot", "err", err)
+        return slotDetails{feeErr: err}
+    }
+
+    configToken := slot.Token
+    date := slot.Date
+    detailUrl := "https://api.resy.com/3/details"
+
+    // Prepare the request body
+    requestBody := map[string]string{
+        "commit":     strconv.Itoa(1),                  // Convert integer 1 to string
+        "config_id":  configToken,                      // Assuming configToken is already a string
+        "day":        date,                             // Assuming date is already a string
+        "party_size": strconv.Itoa(slot.PartySize),     // Convert PartySize (an int) to string
+    }
+    jsonBody, err := json.Marshal(requestBody)
+     
+    if err != nil {
+        slotLogger.Warn("marshaling details request failed", "err", err)
+        return slotDetails{detailsErr: err}
+    }
+
+    requestDetail, err := http.NewRequest("POST", detailUrl, bytes.NewBuffer(jsonBody))
+    if err != nil {
+        slotLogger.Warn("creating details request failed", "err", err)
+        return slotDetails{detailsErr: err}
+    }
+
+    // Setting headers for detail request
+    // Set the appropriate headers
+    requestDetail.Header.Set("Content-Type", "application/json")
+    requestDetail.Header.Set("Authorization", `ResyAPI api_key="` + a.APIKey + `"`)
+    requestDetail.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+
+    if slotLogger.Enabled(context.Background(), slog.LevelDebug) {
+        slotLogger.Debug("sending details request", "request", api.DumpRequest(requestDetail))
+    }
+    detailStart := time.Now()
+    requestDetail, detailSpan := params.Trace.Start("resy", api.DetailsStep, api.WithRetryDeadline(requestDetail, params.Deadline))
+    responseDetail, err := client.Do(requestDetail)
+    detailSpan.End(responseDetail, err)
+    if err != nil {
+        api.ObserveStep("resy", api.DetailsStep, detailStart, err)
+        slotLogger.Warn("details request failed", "err", err)
+        return slotDetails{detailsErr: err}
+    }
+    defer responseDetail.Body.Close()
+    if slotLogger.Enabled(context.Background(), slog.LevelDebug) {
+        slotLogger.Debug("details response", "response", api.DumpResponse(responseDetail))
+    }
+
+    if isCodeFail(responseDetail.StatusCode) {
+        detailErr := api.NewProviderError("resy", api.DetailsStep, responseDetail, api.ErrNetwork)
+        api.ObserveStep("resy", api.DetailsStep, detailStart, detailErr)
+        slotLogger.Warn("details request refused", "status", responseDetail.StatusCode, "code", detailErr.Code)
+        return slotDetails{detailsErr: detailErr}
+    }
+    api.ObserveStep("resy", api.DetailsStep, detailStart, nil)
+
+    responseDetailBody, err := io.ReadAll(responseDetail.Body)
+    if err != nil {
+        slotLogger.Warn("reading details response failed", "err", err)
+        return slotDetails{detailsErr: err}
+    }
+
+    var detailTopLevelMap map[string]interface{}
+    err = json.Unmarshal(responseDetailBody, &detailTopLevelMap)
+    if err != nil {
+        slotLogger.Warn("details response is not JSON", "err", err)
+        return slotDetails{detailsErr: err}
+    }
+
+    // details give the full terms, which can be
+    // stricter than what find listed
+    fees := slot.Fees.Merge(parseFeePolicy(detailTopLevelMap))
+    if err := params.FeeLimit.Check(slot.Time, fees); err != nil {
+        slotLogger.Info("skipping slot", "err", err)
+        return slotDetails{feeErr: err}
+    }
+
+    jsonBookTokenMap, ok := detailTopLevelMap["book_token"].(map[string]interface{})
+    if !ok {
+        slotLogger.Warn("details response has no book token")
+        return slotDetails{}
+    }
+
+    bookToken, ok := jsonBookTokenMap["value"].(string)
+    if !ok {
+        slotLogger.Warn("details response has no book token")
+        return slotDetails{}
+    }
+    slotLogger.Debug("got book token")
+    return slotDetails{bookToken: bookToken, fees: fees}
+}
+
+/*
+Name: book
+Type: Internal Func
+Purpose: Run the book step of a slot with the book token
+from its details, returning nil and why if it didn't book.
+A ProviderError wrapping ErrNoTable is returned if Resy
+turned the book down. Any other failure wraps
+ErrBookUnknown, since the book may have gone through
+*/
+func (a *API) book(client *http.Client, slot resyCandidate, details slotDetails, params api.ReserveParam, slotLogger *slog.Logger) (*api.ReserveResponse, error) {
+    bookUrl := "https://api.resy.com/3/book"
+
+    bookField := "book_token=" + url.QueryEscape(details.bookToken)
+    paymentMethodStr := `{"id":` + strconv.FormatInt(params.LoginResp.PaymentMethodID, 10) + `}`
+    paymentMethodField := "struct_payment_method=" + url.QueryEscape(paymentMethodStr)
+    requestBookBodyStr := bookField + "&" + paymentMethodField + "&" + "source_id=resy.com-venue-details"
+
+    requestBook, err := http.NewRequest("POST", bookUrl, bytes.NewBuffer([]byte(requestBookBodyStr)))
+    if err != nil {
+        slotLogger.Warn("creating book request failed", "err", err)
+        return nil, err
+    }
+
+    // Setting headers for book request
+    requestBook.Header.Set("Authorization", `ResyAPI api_key="`+a.APIKey+`"`)
+    requestBook.Header.Set("Content-Type", `application/x-www-form-urlencoded`)
+    requestBook.Header.Set("Host", `api.resy.com`)
+    requestBook.Header.Set("X-Resy-Auth-Token", params.LoginResp.AuthToken)
+    requestBook.Header.Set("X-Resy-Universal-Auth", params.LoginResp.AuthToken)
+    requestBook.Header.Set("Referer", "https://resy.com/")
+    requestBook.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
+
+    if slotLogger.Enabled(context.Background(), slog.LevelDebug) {
+        slotLogger.Debug("sending book request", "request", api.DumpRequest(requestBook))
+    }
+    bookStart := time.Now()
+    // a book that failed server side may have gone through
+    requestBook = api.NotIdempotent(api.WithRetryDeadline(requestBook, params.Deadline))
+    requestBook, bookSpan := params.Trace.Start("resy", api.BookStep, requestBook)
+    responseBook, err := client.Do(requestBook)
+    bookSpan.End(responseBook, err)
+    if err != nil {
+        api.ObserveStep("resy", api.BookStep, bookStart, err)
+        slotLogger.Warn("book request failed", "err", err)
+        return nil, errors.Join(api.ErrBookUnknown, err)
+    }
+    defer responseBook.Body.Close()
+    if slotLogger.Enabled(context.Background(), slog.LevelDebug) {
+        slotLogger.Debug("book response", "response", api.DumpResponse(responseBook))
+    }
+
+    if isCodeFail(responseBook.StatusCode) {
+        // only a 4xx is Resy turning the book down
+        sentinel := api.ErrBookUnknown
+        if responseBook.StatusCode >= 400 && responseBook.StatusCode < 500 {
+            sentinel = api.ErrNoTable
+        }
+        bookErr := api.NewProviderError("resy", api.BookStep, responseBook, sentinel)
+        api.ObserveStep("resy", api.BookStep, bookStart, bookErr)
+        slotLogger.Warn("book request refused", "status", responseBook.StatusCode, "code", bookErr.Code)
+        return nil, bookErr
+    }
+
+    responseBookBody, err := io.ReadAll(responseBook.Body)
+    if err != nil {
+        api.ObserveStep("resy", api.BookStep, bookStart, err)
+        slotLogger.Warn("reading book response failed", "err", err)
+        return nil, errors.Join(api.ErrBookUnknown, err)
+    }
+
+    var bookTopLevelMap map[string]interface{}
+    err = json.Unmarshal(responseBookBody, &bookTopLevelMap)
+    if err != nil {
+        bookErr := api.BodyProviderError("resy", api.BookStep, responseBook.StatusCode, responseBookBody, api.ErrBookUnknown)
+        api.ObserveStep("resy", api.BookStep, bookStart, bookErr)
+        slotLogger.Warn("book response is not JSON", "err", err)
+        return nil, bookErr
+    }
+
+    // Check if booking was successful
+    reservationID, ok := bookTopLevelMap["reservation_id"]
+    if !ok {
+        bookErr := api.BodyProviderError("resy", api.BookStep, responseBook.StatusCode, responseBookBody, api.ErrNoTable)
+        api.ObserveStep("resy", api.BookStep, bookStart, bookErr)
+        slotLogger.Warn("book response has no confirmation", "code", bookErr.Code)
+        return nil, bookErr
+    }
+    api.ObserveStep("resy", api.BookStep, bookStart, nil)
+    slotLogger.Info("booked", "reservation_id", jsonIDString(reservationID))
+    resp := api.ReserveResponse{
+        ReservationTime: slot.Time,
+        ReservationID: jsonIDString(reservationID),
+        VenueName: slot.VenueName,
+        VenueAddress: slot.VenueAddress,
+        PartySize: slot.PartySize,
+        Fees: details.fees,
+    }
+    if resyToken, ok := bookTopLevelMap["resy_token"].(string); ok {
+        resp.CancelToken = resyToken
+    }
+    return &resp, nil
+}
+
+
+/*
+Name: Reservations 
+Type: API Func 
+Purpose: Resy implementation of the optional 
+Reservations api func
+*/
+func (a *API) Reservations(params api.ReservationsParam) (*api.ReservationsResponse, error) {
+    start := time.Now()
+    resp, err := a.reservations(params)
+    api.ObserveStep("resy", api.ReservationsStep, start, err)
+    return resp, err
+}
+
+/*
+Name: reservations
+Type: Internal Func
+Purpose: Reservations without recording metrics
+*/
+func (a *API) reservations(params api.ReservationsParam) (*api.ReservationsResponse, error) {
+    reservationsUrl := `https://api.resy.com/3/user/reservations?limit=100&offset=1&type=upcoming`
+
+    request, err := http.NewRequest("GET", reservationsUrl, bytes.NewBuffer([]byte{}))
+    if err != nil {
+        return nil, err
+    }
+
+    request.Header.Set("Authorization", `ResyAPI api_key="` + a.APIKey + `"`)
+    request.Header.Set("X-Resy-Auth-Token", params.LoginResp.AuthToken)
+    request.Header.Set("X-Resy-Universal-Auth", params.LoginResp.AuthToken)
+    request.Header.Set("Origin", "https://resy.com")
+    request.Header.Set("Referer", "https://resy.com/")
+
+    client := a.httpClient()
+    response, err := client.Do(request)
+    if err != nil {
+        return nil, err
+    }
+
+    defer response.Body.Close()
+
+    if isCodeFail(response.StatusCode) {
+        return nil, api.NewProviderError("resy", api.ReservationsStep, response, api.ErrNetwork)
+    }
+
+    responseBody, err := io.ReadAll(response.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    var jsonTopLevelMap map[string]interface{}
+    err = json.Unmarshal(responseBody, &jsonTopLevelMap)
+    if err != nil {
+        return nil, err
+    }
+
+    // venue details are sent once per venue, keyed by venue id
+    jsonVenuesMap, _ := jsonTopLevelMap["venues"].(map[string]interface{})
+    jsonReservationsList, ok := jsonTopLevelMap["reservations"].([]interface{})
+    if !ok {
+        return nil, api.ErrNetwork
+    }
+
+    reservations := make([]api.Reservation, 0, len(jsonReservationsList))
+    for _, jsonReservation := range jsonReservationsList {
+        jsonReservationMap, ok := jsonReservation.(map[string]interface{})
+        if !ok {
+            continue
+        }
+        day, _ := jsonReservationMap["day"].(string)
+        timeSlot, _ := jsonReservationMap["time_slot"].(string)
+        resTime, err := time.ParseInLocation("2006-01-02 15:04:05", day + " " + timeSlot, time.Local)
+        if err != nil {
+            continue
+        }
+        reservation := api.Reservation{
+            ReservationID: jsonIDString(jsonReservationMap["reservation_id"]),
+            ReservationTime: resTime,
+        }
+        if numSeats, ok := jsonReservationMap["num_seats"].(float64); ok {
+            reservation.PartySize = int(numSeats)
+        }
+        if jsonVenueMap, ok := jsonReservationMap["venue"].(map[string]interface{}); ok {
+            venueID := jsonIDString(jsonVenueMap["id"])
+            reservation.VenueID, _ = strconv.ParseInt(venueID, 10, 64)
+            if jsonVenueInfoMap, ok := jsonVenuesMap[venueID].(map[string]interface{}); ok {
+                reservation.VenueName, reservation.VenueAddress = parseVenueInfo(jsonVenueInfoMap)
+            }
+        }
+        reservations = append(reservations, reservation)
+    }
+
+    return &api.ReservationsResponse{Reservations: reservations}, nil
+}
+
+/*
+Name: Warm
+Type: API Func
+Purpose: Resy implementation of the Warm api func. Logs in
+again for a fresh token while opening the rest of the
+connections alongside the login request
+*/
+func (a *API) Warm(params api.WarmParam) (*api.LoginResponse, error) {
+    logger := api.PickLogger(params.Logger, a.Logger)
+    warmed := make(chan error, 1)
+    go func() {
+        // the login request opens a connection itself
+        warmed <- api.WarmConnections(a.httpClient(), "https://api.resy.com/", params.Conns - 1)
+    }()
+    loginResp, err := a.Login(params.Login)
+    if warmErr := <-warmed; warmErr != nil {
+        logger.Warn("opening connections failed", "err", warmErr)
+    }
+    if err != nil {
+        return nil, err
+    }
+    logger.Debug("warmed up", "conns", params.Conns)
+    return loginResp, nil
+}
+
+/*
+Name: ServerClock
+Type: API Func
+Purpose: Resy implementation of the ServerClock api func,
+reading the Date headers of api.resy.com
+*/
+func (a *API) ServerClock(params api.ClockParam) (*api.ClockOffset, error) {
+    return api.MeasureClock(a.httpClient(), "https://api.resy.com/", params.Samples, api.PickLogger(params.Logger, a.Logger))
+}
 
 /*
 Name: AuthMinExpire 
@@ -600,48 +1172,74 @@ func (a *API) AuthMinExpire() (time.Duration) {
     return d
 }
 
-//func (a *API) Cancel(params api.CancelParam) (*api.CancelResponse, error) {
-//    cancelUrl := `https://api.resy.com/3/cancel` 
-//    resyToken := url.QueryEscape(params.ResyToken)
-//    requestBodyStr := "resy_token=" + resyToken
-//    request, err := http.NewRequest("POST", cancelUrl, bytes.NewBuffer([]byte(requestBodyStr)))
-//    if err != nil {
-//        return nil, err
-//    }
-//    
-//    request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-//    request.Header.Set("Authorization", `ResyAPI api_key="` + a.APIKey + `"`)
-//    request.Header.Set("X-Resy-Auth-Token", params.AuthToken)
-//    request.Header.Set("X-Resy-Universal-Auth-Token", params.AuthToken)
-//    request.Header.Set("Referer", "https://resy.com/")
-//    request.Header.Set("Origin", "https://resy.com")
-//
-//
-//    client := &http.Client{}
-//    response, err := client.Do(request)
-//    if err != nil {
-//        return nil, err
-//    }
-//
-//    if isCodeFail(response.StatusCode) {
-//        return nil, api.ErrNetwork
-//    }
-//
-//    responseBody, err := io.ReadAll(response.Body)
-//    if err != nil {
-//        return nil, err 
-//    }
-//
-//    defer response.Body.Close()
-//    var jsonTopLevelMap map[string]interface{}
-//    err = json.Unmarshal(responseBody, &jsonTopLevelMap)
-//    if err != nil {
-//        return nil, err
-//    }
-//
-//    jsonPaymentMap := jsonTopLevelMap["payment"].(map[string]interface{})
-//    jsonTransactionMap := jsonPaymentMap["transaction"].(map[string]interface{})
-//    refund := jsonTransactionMap["refund"].(int) == 1
-//    return &api.CancelResponse{Refund: refund}, nil
-//}
-//
+/*
+Name: Cancel 
+Type: API Func 
+Purpose: Resy implementation of the optional 
+Cancel api func
+*/
+func (a *API) Cancel(params api.CancelParam) (*api.CancelResponse, error) {
+    start := time.Now()
+    resp, err := a.cancel(params)
+    api.ObserveStep("resy", api.CancelStep, start, err)
+    return resp, err
+}
+
+/*
+Name: cancel
+Type: Internal Func
+Purpose: Cancel without recording metrics
+*/
+func (a *API) cancel(params api.CancelParam) (*api.CancelResponse, error) {
+    cancelUrl := `https://api.resy.com/3/cancel` 
+    resyToken := url.QueryEscape(params.CancelToken)
+    requestBodyStr := "resy_token=" + resyToken
+    request, err := http.NewRequest("POST", cancelUrl, bytes.NewBuffer([]byte(requestBodyStr)))
+    if err != nil {
+        return nil, err
+    }
+    
+    request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    request.Header.Set("Authorization", `ResyAPI api_key="` + a.APIKey + `"`)
+    request.Header.Set("X-Resy-Auth-Token", params.LoginResp.AuthToken)
+    request.Header.Set("X-Resy-Universal-Auth", params.LoginResp.AuthToken)
+    request.Header.Set("Referer", "https://resy.com/")
+    request.Header.Set("Origin", "https://resy.com")
+
+    client := a.httpClient()
+    // a cancel that failed server side may have gone
+    // through, and cancelling again is refused
+    request = api.NotIdempotent(request)
+    response, err := client.Do(request)
+    if err != nil {
+        return nil, err
+    }
+
+    defer response.Body.Close()
+
+    if isCodeFail(response.StatusCode) {
+        return nil, api.NewProviderError("resy", api.CancelStep, response, api.ErrNetwork)
+    }
+
+    responseBody, err := io.ReadAll(response.Body)
+    if err != nil {
+        return nil, err 
+    }
+
+    var jsonTopLevelMap map[string]interface{}
+    err = json.Unmarshal(responseBody, &jsonTopLevelMap)
+    if err != nil {
+        return nil, err
+    }
+
+    // the cancel already went through, so a missing refund
+    // field just means no refund, not a failure
+    refund := false
+    if jsonPaymentMap, ok := jsonTopLevelMap["payment"].(map[string]interface{}); ok {
+        if jsonTransactionMap, ok := jsonPaymentMap["transaction"].(map[string]interface{}); ok {
+            refundNum, _ := jsonTransactionMap["refund"].(float64)
+            refund = refundNum == 1
+        }
+    }
+    return &api.CancelResponse{Refund: refund}, nil
+}
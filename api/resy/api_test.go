@@ -0,0 +1,242 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package resy
+
+import (
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+/*
+Name: resyStub
+Type: Internal Test Struct
+Purpose: A RoundTripper standing in for Resy. Find lists
+a slot per config token, details answers each config with
+the status set for it, and book answers with bookStatus.
+A transport error set for a step is returned instead
+*/
+type resyStub struct {
+    // find lists these configs at 19:00, 19:30, ...
+    configs         []string
+    detailsStatus   map[string]int
+    detailsErr      error
+    // 201 if zero
+    bookStatus      int
+    // sent instead of the book JSON if set
+    bookBody        string
+    bookErr         error
+    mu              sync.Mutex
+    details         []string
+    books           int
+    authHeaders     []string
+}
+
+func (s *resyStub) RoundTrip(req *http.Request) (*http.Response, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.authHeaders = append(s.authHeaders, req.Header.Get("Authorization"))
+    switch req.URL.Path {
+    case "/4/find":
+        slots := []map[string]interface{}{}
+        for i, config := range s.configs {
+            start := time.Date(2026, 11, 2, 19, 30 * i, 0, 0, time.UTC)
+            slots = append(slots, map[string]interface{}{
+                "date": map[string]interface{}{"start": start.Format("2006-01-02 15:04:05")},
+                "config": map[string]interface{}{"type": "Dining Room", "token": config},
+            })
+        }
+        return stubResponse(200, map[string]interface{}{
+            "results": map[string]interface{}{
+                "venues": []interface{}{map[string]interface{}{"slots": slots}},
+            },
+        }), nil
+    case "/3/details":
+        var body map[string]string
+        json.NewDecoder(req.Body).Decode(&body)
+        config := body["config_id"]
+        s.details = append(s.details, config)
+        if s.detailsErr != nil {
+            return nil, s.detailsErr
+        }
+        if status := s.detailsStatus[config]; status != 0 && status != 200 {
+            return stubResponse(status, map[string]interface{}{"code": "details_down"}), nil
+        }
+        return stubResponse(200, map[string]interface{}{
+            "book_token": map[string]interface{}{"value": "bt-" + config},
+        }), nil
+    case "/3/book":
+        s.books += 1
+        if s.bookErr != nil {
+            return nil, s.bookErr
+        }
+        if s.bookBody != "" {
+            return &http.Response{StatusCode: 201, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(s.bookBody))}, nil
+        }
+        if s.bookStatus != 0 && s.bookStatus != 201 {
+            return stubResponse(s.bookStatus, map[string]interface{}{"code": "slot_taken", "message": "This slot is no longer available"}), nil
+        }
+        return stubResponse(201, map[string]interface{}{"reservation_id": 777, "resy_token": "rt-777"}), nil
+    }
+    return stubResponse(404, map[string]interface{}{}), nil
+}
+
+func stubResponse(status int, body interface{}) (*http.Response) {
+    raw, _ := json.Marshal(body)
+    return &http.Response{
+        StatusCode: status,
+        Header: http.Header{"Content-Type": []string{"application/json"}},
+        Body: io.NopCloser(strings.NewReader(string(raw))),
+    }
+}
+
+func TestReserveSkipsFailedDetails(t *testing.T) {
+    day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+    times := []time.Time{day.Add(19 * time.Hour), day.Add(19 * time.Hour + 30 * time.Minute), day.Add(20 * time.Hour)}
+    tests := []struct {
+        name            string
+        detailsStatus   map[string]int
+        parallel        int
+        wantTime        time.Time
+        wantErrStep     string
+        wantBooks       int
+    }{
+        {"first slot's details fail", map[string]int{"cfg-0": 500}, 1, times[1], "", 1},
+        {"first slots' details fail in a batch", map[string]int{"cfg-0": 502, "cfg-1": 400}, 3, times[2], "", 1},
+        {"every slot's details fail", map[string]int{"cfg-0": 500, "cfg-1": 500, "cfg-2": 503}, 2, time.Time{}, api.DetailsStep, 0},
+        {"nothing fails", nil, 1, times[0], "", 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            stub := &resyStub{configs: []string{"cfg-0", "cfg-1", "cfg-2"}, detailsStatus: tt.detailsStatus}
+            a := API{APIKey: "stub-api-key", Client: &http.Client{Transport: stub}}
+            resp, err := a.Reserve(api.ReserveParam{
+                VenueID: 1,
+                PartySize: 2,
+                ReservationTimes: times,
+                ParallelDetails: tt.parallel,
+                LoginResp: api.LoginResponse{AuthToken: "stub-auth-token", PaymentMethodID: 1},
+            })
+            if tt.wantErrStep != "" {
+                var providerErr *api.ProviderError
+                if !errors.As(err, &providerErr) || providerErr.Step != tt.wantErrStep {
+                    t.Fatalf("err = %v, want a %s ProviderError", err, tt.wantErrStep)
+                }
+            } else if err != nil {
+                t.Fatalf("Reserve: %v", err)
+            } else if !resp.ReservationTime.Equal(tt.wantTime) {
+                t.Errorf("booked %v, want %v", resp.ReservationTime, tt.wantTime)
+            }
+            if stub.books != tt.wantBooks {
+                t.Errorf("books = %d, want %d", stub.books, tt.wantBooks)
+            }
+            for _, header := range stub.authHeaders {
+                if header != `ResyAPI api_key="stub-api-key"` {
+                    t.Errorf("request sent with Authorization %q", header)
+                }
+            }
+        })
+    }
+}
+
+func TestReserveReturnsDetailsTransportError(t *testing.T) {
+    stub := &resyStub{configs: []string{"cfg-0", "cfg-1"}, detailsErr: io.ErrUnexpectedEOF}
+    a := API{APIKey: "stub-api-key", Client: &http.Client{Transport: stub}}
+    day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+    _, err := a.Reserve(api.ReserveParam{
+        VenueID: 1,
+        PartySize: 2,
+        ReservationTimes: []time.Time{day.Add(19 * time.Hour), day.Add(19 * time.Hour + 30 * time.Minute)},
+        ParallelDetails: 2,
+        LoginResp: api.LoginResponse{AuthToken: "stub-auth-token", PaymentMethodID: 1},
+    })
+    if !errors.Is(err, io.ErrUnexpectedEOF) {
+        t.Errorf("err = %v, want the details transport error", err)
+    }
+    if len(stub.details) != 2 || stub.books != 0 {
+        t.Errorf("details = %d, books = %d, want 2 and 0", len(stub.details), stub.books)
+    }
+}
+
+func TestReserveStopsOnUnknownBook(t *testing.T) {
+    day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+    tests := []struct {
+        name            string
+        bookStatus      int
+        bookBody        string
+        bookErr         error
+        wantUnknown     bool
+        wantBooks       int
+    }{
+        {"transport error", 0, "", io.ErrUnexpectedEOF, true, 1},
+        {"server error", 500, "", nil, true, 1},
+        {"bad gateway", 502, "", nil, true, 1},
+        {"not JSON", 0, "<html>upstream timed out</html>", nil, true, 1},
+        {"refused", 412, "", nil, false, 3},
+        {"no confirmation", 0, `{"status": "pending"}`, nil, false, 3},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            stub := &resyStub{configs: []string{"cfg-0", "cfg-1", "cfg-2"}, bookStatus: tt.bookStatus, bookBody: tt.bookBody, bookErr: tt.bookErr}
+            a := API{APIKey: "stub-api-key", Client: &http.Client{Transport: stub}}
+            _, err := a.Reserve(api.ReserveParam{
+                VenueID: 1,
+                PartySize: 2,
+                ReservationTimes: []time.Time{day.Add(19 * time.Hour), day.Add(19 * time.Hour + 30 * time.Minute), day.Add(20 * time.Hour)},
+                ParallelDetails: 3,
+                LoginResp: api.LoginResponse{AuthToken: "stub-auth-token", PaymentMethodID: 1},
+            })
+            if errors.Is(err, api.ErrBookUnknown) != tt.wantUnknown {
+                t.Errorf("err = %v, want ErrBookUnknown %v", err, tt.wantUnknown)
+            }
+            if !tt.wantUnknown && !errors.Is(err, api.ErrNoTable) {
+                t.Errorf("err = %v, want ErrNoTable", err)
+            }
+            if tt.bookErr != nil && !errors.Is(err, tt.bookErr) {
+                t.Errorf("err = %v, want it to wrap the transport error", err)
+            }
+            if stub.books != tt.wantBooks {
+                t.Errorf("books = %d, want %d", stub.books, tt.wantBooks)
+            }
+        })
+    }
+}
+
+func TestReplayBookRefused(t *testing.T) {
+    // recorded from a reserve where the first slot's details
+    // kept failing and Resy refused the book of the second
+    cassette, err := api.LoadCassette("testdata/reserve_book_refused.json")
+    if err != nil {
+        t.Fatalf("LoadCassette: %v", err)
+    }
+    client, replayer := api.NewReplayClient(cassette)
+    a := API{APIKey: "replayed-api-key", Client: client}
+    day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+    _, err = a.Reserve(api.ReserveParam{
+        VenueID: 1,
+        PartySize: 2,
+        ReservationTimes: []time.Time{day.Add(19 * time.Hour), day.Add(19 * time.Hour + 30 * time.Minute)},
+        LoginResp: api.LoginResponse{AuthToken: "replayed-auth-token", PaymentMethodID: 1},
+    })
+    var providerErr *api.ProviderError
+    if !errors.As(err, &providerErr) {
+        t.Fatalf("err = %v, want a ProviderError", err)
+    }
+    if providerErr.Step != api.BookStep || providerErr.Status != 412 || providerErr.Code != "slot_taken" {
+        t.Errorf("err = %v, want the refused book", err)
+    }
+    if !errors.Is(err, api.ErrNoTable) {
+        t.Errorf("err = %v, want it to wrap ErrNoTable", err)
+    }
+    if unused := replayer.Unused(); unused != 0 {
+        t.Errorf("%d recorded requests were never made", unused)
+    }
+}
@@ -27,6 +27,25 @@ General Overview of Resy API:
     setting. The specific format is discussed in the 'APIKey' section 
     of this doc.go.
 
+    The API struct also has an optional Logger. Reserve and Find log
+    each step to the request's Logger, or to this one if the request
+    has none, with the slot tried, request statuses and why a slot
+    was skipped at debug, info and warn levels. Tokens, headers and
+    request bodies are never logged. Every request to Resy is timed
+    and recorded with api.ObserveStep, and on the request's Trace.
+    Requests go through the API's Client, or a keep-alive client the
+    package shares if it has none, which retries rate limited and
+    failed requests as api.RetryTransport describes. Books and
+    cancels are marked api.NotIdempotent. Requests Resy turns down
+    fail with an api.ProviderError holding the status, error code
+    and start of the body, and if no slot booked because Resy
+    refused a book, Reserve returns the first such error, which
+    wraps api.ErrNoTable. A Client from api.NewReplayClient plays
+    back a cassette recorded through api.DefaultRecorder. Warm logs
+    in again while opening connections to api.resy.com alongside the
+    login.
+    ServerClock reads the Date headers of api.resy.com.
+
     The Login functionality of Resy requires only one request message,
     and generally takes an account email and password as input. On 
     success, we are given a token to use in future requests, along
@@ -256,6 +275,22 @@ Reserve:
     slot used in the next request-response interaction. The string token ###TABLETYPE###
     is the type of table that this slot corresponds to.
 
+    The find request only lists one day for one party size, so it is
+    run once for each day the request's windows fall on, for each of
+    its party sizes. A find which fails is skipped, and its error is
+    only returned if no find succeeded at all. Each open slot in the
+    results that falls in a window of the request, matches one of its
+    table types, and is for one of its party sizes becomes a candidate,
+    along with the fees listed under "payment": "deposit_fee" and
+    "cancellation_fee", null when there is none, and "is_paid" for
+    prepaid slots.
+    The candidates are ranked by the request's slot selector and the
+    booking steps below are tried on each in turn until one books. By
+    default that tries each party size, then each table type, then 
+    each window in priority order, then the slots in a window in the
+    order its policy prefers them. A plain reservation time is a
+    window holding only that minute.
+
     The next pair of HTTP messages is informally referred to as the 'config' 
     step. We send a GET request with no body. The request has a dynamic URL:
 
@@ -281,6 +316,29 @@ Reserve:
 
     Where ###BTOKEN### is an identifier used in the next step.
 
+    The details response also states the slot's terms in full. Its
+    "payment" map has the same fee fields as the find slot, and its
+    "cancellation" map may hold a "fee" map whose "amount" is charged
+    for a late cancel. These are merged with what find listed, keeping
+    the stricter of each, and checked against the request's fee limit
+    again before the slot is booked. The merged terms are returned as
+    the response's Fees.
+
+    With ReserveParam.ParallelDetails above one, details are requested
+    for that many slots at once. The slots which came back with a book
+    token are then booked in rank order, one at a time, stopping at the
+    first confirmation, so only one slot is ever booked. A slot whose
+    details request fails is skipped, and the failure is only returned
+    if no other slot books. The next slot is only booked once Resy has
+    turned the last book down, with a 4xx or a response without a
+    reservation_id. A book that failed any other way may have gone
+    through, so the reserve stops with an error wrapping
+    api.ErrBookUnknown.
+
+    A dry run stops here. Once the book token is in hand the slot is
+    known to be bookable, so the slot is returned as it would have
+    been booked without sending the final request.
+
     The final step, denoted 'reserve', is where the reservation curated in the 
     past 2 steps is finalized and made persistent on Resy servers. It is a POST
     request, and uses the following dynamic URL:
@@ -308,6 +366,92 @@ Reserve:
             Referer: https://resy.com/
 
     If the server response is any 200 code, the reservation has been made.    
+    The response body carries the confirmation id, and the venue name and
+    address are taken from the "venue" map of the find response:
+
+        Body:
+
+            {
+                ...
+                "reservation_id": ###RESID###,
+                "resy_token": "###RTOK###",
+                ...
+            }
+
+    The ###RTOK### value is kept on the response as the cancel token.
+
+**********************************************************************
+
+Find:
+
+    The optional Find api func sends only the first message of the
+    Reserve section, the find request, and returns the time and 
+    seating type of every slot in the response without booking any. 
+    Reserve runs the same find request before its booking steps.
+
+**********************************************************************
+
+Cancel:
+
+    The optional Cancel api func cancels a booking made by Reserve. It is
+    a POST request using the standard APIKey and Login headers, on the URL:
+
+        https://api.resy.com/3/cancel
+
+    With the form encoded body:
+
+        resy_token=###UERTOK###
+
+    Where ###UERTOK### is the url encoded cancel token from the book step.
+    If the server response is any 200 code, the booking is cancelled. The
+    response body says whether the deposit, if any, was refunded:
+
+        Body:
+
+            {
+                "payment": {
+                    "transaction": {"refund": 0 or 1, ...},
+                    ...
+                },
+                ...
+            }
+
+**********************************************************************
+
+Reservations:
+
+    The optional Reservations api func lists upcoming reservations on the
+    account. It is a GET request with no body, using the standard APIKey 
+    and Login headers, on the URL:
+
+        https://api.resy.com/3/user/reservations?limit=100&offset=1&type=upcoming
+
+    The server response looks as follows, where venue details are sent 
+    once per venue and keyed by the venue id:
+
+        Body:
+
+            {
+                "reservations": [
+                    {
+                        "reservation_id": ###RESID###,
+                        "day": "###YEAR###-###MONTH###-###DAY###",
+                        "time_slot": "###HOUR###:###MIN###:00",
+                        "num_seats": ###PS###,
+                        "venue": {"id": ###ID###, ...},
+                        ...
+                    },
+                    ...
+                ],
+                "venues": {
+                    "###ID###": {
+                        "name": "###NAME###",
+                        "location": {"address_1": "###ADDR###", ...},
+                        ...
+                    },
+                    ...
+                }
+            }
 
 **********************************************************************
 */
@@ -0,0 +1,269 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "context"
+    "errors"
+    "io"
+    "math/rand"
+    "net/http"
+    "strconv"
+    "time"
+)
+
+// ErrorClass is an enum, only use with next const def types
+type ErrorClass string
+
+const (
+    // Too many requests, worth retrying after a wait
+    RateLimitedClass ErrorClass = "rate_limited"
+    // The service failed, worth retrying
+    ServerClass ErrorClass = "server"
+    // The login was refused, retrying won't help
+    AuthClass ErrorClass = "auth"
+    // The request was refused, retrying won't help
+    ClientClass ErrorClass = "client"
+    // The request never got an answer, worth retrying
+    NetworkClass ErrorClass = "network"
+)
+
+/*
+Name: ClassifyStatus
+Type: API Func
+Purpose: Name the kind of failure an HTTP status is, or ""
+for a success. Resy answers a bad login with a 419
+*/
+func ClassifyStatus(code int) (ErrorClass) {
+    switch {
+    case code < 400:
+        return ""
+    case code == http.StatusTooManyRequests:
+        return RateLimitedClass
+    case code == http.StatusUnauthorized || code == http.StatusForbidden || code == 419:
+        return AuthClass
+    case code >= 500:
+        return ServerClass
+    }
+    return ClientClass
+}
+
+/*
+Name: Retryable
+Type: API Func
+Purpose: Report whether a failure of a class may go away
+if the request is sent again
+*/
+func (c ErrorClass) Retryable() (bool) {
+    return c == RateLimitedClass || c == ServerClass || c == NetworkClass
+}
+
+/*
+Name: RetryPolicy
+Type: API Struct
+Purpose: How often and how long a RetryTransport retries.
+Waits grow from BaseDelay, doubling per retry up to MaxDelay,
+each jittered down by up to half, unless the service asks
+for a longer wait with Retry-After
+*/
+type RetryPolicy struct {
+    // Retries after the first try
+    MaxRetries      int
+    BaseDelay       time.Duration
+    MaxDelay        time.Duration
+    // No retry is started this long after the first
+    // try, no limit if zero
+    MaxElapsed      time.Duration
+}
+
+/*
+Name: DefaultRetryPolicy
+Type: API Var
+Purpose: The policy of clients made by NewClient, short
+enough to fit inside a drop
+*/
+var DefaultRetryPolicy = RetryPolicy{
+    MaxRetries: 3,
+    BaseDelay: 100 * time.Millisecond,
+    MaxDelay: 2 * time.Second,
+    MaxElapsed: 5 * time.Second,
+}
+
+/*
+Name: Retry
+Type: API Struct
+Purpose: Record of one failed try a RetryTransport retried
+*/
+type Retry struct {
+    Class           ErrorClass
+    // HTTP status, 0 for a network error
+    Status          int
+    // Time waited before the next try
+    Wait            time.Duration
+}
+
+// Keys of the request context values read by RetryTransport
+type (
+    retryDeadlineKey struct{}
+    notIdempotentKey struct{}
+)
+
+/*
+Name: WithRetryDeadline
+Type: API Func
+Purpose: Return the request with a time no retry may start
+after, usually the deadline of the operation sending it. A
+zero deadline leaves the request as it is
+*/
+func WithRetryDeadline(req *http.Request, deadline time.Time) (*http.Request) {
+    if deadline.IsZero() {
+        return req
+    }
+    return req.WithContext(context.WithValue(req.Context(), retryDeadlineKey{}, deadline))
+}
+
+/*
+Name: NotIdempotent
+Type: API Func
+Purpose: Return the request marked as unsafe to send twice,
+such as one that books. A RetryTransport only retries it
+when it was rate limited, since then the service did
+nothing with it
+*/
+func NotIdempotent(req *http.Request) (*http.Request) {
+    return req.WithContext(context.WithValue(req.Context(), notIdempotentKey{}, true))
+}
+
+/*
+Name: RetryTransport
+Type: http.RoundTripper
+Purpose: Middleware sending a request again when it fails
+for a reason that may go away, see 'ErrorClass.Retryable',
+following its Policy. Retries are recorded on the request's
+trace span and counted in the metrics
+*/
+type RetryTransport struct {
+    Base            http.RoundTripper
+    Policy          RetryPolicy
+}
+
+/*
+Name: RoundTrip
+Type: http.RoundTripper method
+Purpose: Satisfy the http.RoundTripper interface
+*/
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+    base := t.Base
+    if base == nil {
+        base = http.DefaultTransport
+    }
+    start := time.Now()
+    deadline, hasDeadline := req.Context().Value(retryDeadlineKey{}).(time.Time)
+    if ctxDeadline, ok := req.Context().Deadline(); ok && (!hasDeadline || ctxDeadline.Before(deadline)) {
+        deadline, hasDeadline = ctxDeadline, true
+    }
+    if t.Policy.MaxElapsed > 0 && (!hasDeadline || start.Add(t.Policy.MaxElapsed).Before(deadline)) {
+        deadline, hasDeadline = start.Add(t.Policy.MaxElapsed), true
+    }
+    notIdempotent, _ := req.Context().Value(notIdempotentKey{}).(bool)
+    span, _ := req.Context().Value(activeSpanKey{}).(*ActiveSpan)
+
+    for retries := 0; ; retries++ {
+        try := req
+        if retries > 0 {
+            // each try needs a fresh copy of the body
+            try = req.Clone(req.Context())
+            if req.GetBody != nil {
+                body, err := req.GetBody()
+                if err != nil {
+                    return nil, err
+                }
+                try.Body = body
+            }
+        }
+        resp, err := base.RoundTrip(try)
+
+        class := NetworkClass
+        status := 0
+        if err == nil {
+            status = resp.StatusCode
+            class = ClassifyStatus(status)
+        }
+        if class == "" || !class.Retryable() || retries >= t.Policy.MaxRetries {
+            return resp, err
+        }
+        if notIdempotent && class != RateLimitedClass {
+            return resp, err
+        }
+        if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+            return resp, err
+        }
+        if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+            return resp, err
+        }
+        wait := t.Policy.backoff(retries)
+        if resp != nil {
+            if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok && after > wait {
+                wait = after
+            }
+        }
+        if hasDeadline && time.Now().Add(wait).After(deadline) {
+            return resp, err
+        }
+        if resp != nil {
+            // let the connection go back to the pool
+            io.Copy(io.Discard, io.LimitReader(resp.Body, 64 << 10))
+            resp.Body.Close()
+        }
+        span.retried(Retry{Class: class, Status: status, Wait: wait})
+        retryCount.Inc(req.URL.Host, string(class))
+
+        select {
+        case <-time.After(wait):
+        case <-req.Context().Done():
+            return nil, req.Context().Err()
+        }
+    }
+}
+
+/*
+Name: backoff
+Type: Internal Func
+Purpose: Return the wait before a retry, doubling from
+BaseDelay per retry up to MaxDelay and jittered down by
+up to half so clients don't retry in step
+*/
+func (p RetryPolicy) backoff(retries int) (time.Duration) {
+    wait := p.BaseDelay
+    for i := 0; i < retries && wait < p.MaxDelay; i++ {
+        wait *= 2
+    }
+    if p.MaxDelay > 0 && wait > p.MaxDelay {
+        wait = p.MaxDelay
+    }
+    if wait <= 0 {
+        return 0
+    }
+    return wait / 2 + time.Duration(rand.Int63n(int64(wait / 2) + 1))
+}
+
+/*
+Name: retryAfter
+Type: Internal Func
+Purpose: Parse a Retry-After header, given either as
+seconds or as an HTTP date
+*/
+func retryAfter(header string) (time.Duration, bool) {
+    if header == "" {
+        return 0, false
+    }
+    if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+        return time.Duration(secs) * time.Second, true
+    }
+    if at, err := http.ParseTime(header); err == nil {
+        return time.Until(at), true
+    }
+    return 0, false
+}
@@ -0,0 +1,186 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+/*
+Name: retryServer
+Type: Internal Test Struct
+Purpose: An httptest server answering with a list of
+statuses in turn, then 200, keeping the bodies sent
+*/
+type retryServer struct {
+    mu          sync.Mutex
+    statuses    []int
+    retryAfter  string
+    bodies      []string
+}
+
+func newRetryServer(t *testing.T, statuses []int, retryAfter string) (*retryServer, *httptest.Server) {
+    s := &retryServer{statuses: statuses, retryAfter: retryAfter}
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+        body, _ := io.ReadAll(req.Body)
+        s.mu.Lock()
+        defer s.mu.Unlock()
+        s.bodies = append(s.bodies, string(body))
+        status := http.StatusOK
+        if len(s.bodies) <= len(s.statuses) {
+            status = s.statuses[len(s.bodies) - 1]
+        }
+        if status != http.StatusOK && s.retryAfter != "" {
+            w.Header().Set("Retry-After", s.retryAfter)
+        }
+        w.WriteHeader(status)
+    }))
+    t.Cleanup(server.Close)
+    return s, server
+}
+
+func TestRetryTransport(t *testing.T) {
+    policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond}
+    tests := []struct {
+        name            string
+        statuses        []int
+        retryAfter      string
+        notIdempotent   bool
+        // relative to the request, none if zero
+        deadline        time.Duration
+        // drop GetBody so the body can't be resent
+        noGetBody       bool
+        wantAttempts    int
+        wantStatus      int
+        // the wait before the first retry, if set
+        wantWait        time.Duration
+    }{
+        {"ok", nil, "", false, 0, false, 1, 200, 0},
+        {"server errors retried", []int{500, 502, 200}, "", false, 0, false, 3, 200, 0},
+        {"rate limited waits for retry-after", []int{429, 200}, "1", false, 0, false, 2, 200, time.Second},
+        {"past max retries", []int{500, 500, 500, 500, 200}, "", false, 0, false, 4, 500, 0},
+        {"client error is final", []int{400, 200}, "", false, 0, false, 1, 400, 0},
+        {"auth error is final", []int{419, 200}, "", false, 0, false, 1, 419, 0},
+        {"server error not idempotent is final", []int{503, 200}, "", true, 0, false, 1, 503, 0},
+        {"rate limited not idempotent retried", []int{429, 200}, "0", true, 0, false, 2, 200, 0},
+        {"retry-after past the deadline", []int{503, 200}, "2", false, 500 * time.Millisecond, false, 1, 503, 0},
+        {"within the deadline", []int{503, 200}, "", false, time.Minute, false, 2, 200, 0},
+        {"body without GetBody is final", []int{503, 200}, "", false, 0, true, 1, 503, 0},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s, server := newRetryServer(t, tt.statuses, tt.retryAfter)
+            client := &http.Client{Transport: &RetryTransport{Policy: policy}}
+            const body = "book_token=abc&source_id=test"
+            req, err := http.NewRequest("POST", server.URL, strings.NewReader(body))
+            if err != nil {
+                t.Fatal(err)
+            }
+            if tt.noGetBody {
+                req.GetBody = nil
+            }
+            if tt.notIdempotent {
+                req = NotIdempotent(req)
+            }
+            if tt.deadline != 0 {
+                req = WithRetryDeadline(req, time.Now().Add(tt.deadline))
+            }
+            trace := NewTrace()
+            req, span := trace.Start("test", BookStep, req)
+            resp, err := client.Do(req)
+            span.End(resp, err)
+            if err != nil {
+                t.Fatalf("Do: %v", err)
+            }
+            resp.Body.Close()
+            if resp.StatusCode != tt.wantStatus {
+                t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+            }
+            if len(s.bodies) != tt.wantAttempts {
+                t.Fatalf("attempts = %d, want %d", len(s.bodies), tt.wantAttempts)
+            }
+            // every try carries the whole body
+            for i, sent := range s.bodies {
+                if sent != body {
+                    t.Errorf("try %d sent %q, want %q", i + 1, sent, body)
+                }
+            }
+            retries := trace.Spans()[0].Retries
+            if len(retries) != tt.wantAttempts - 1 {
+                t.Errorf("span retries = %d, want %d", len(retries), tt.wantAttempts - 1)
+            }
+            if tt.wantWait != 0 && (len(retries) == 0 || retries[0].Wait != tt.wantWait) {
+                t.Errorf("retries = %v, want a first wait of %v", retries, tt.wantWait)
+            }
+        })
+    }
+}
+
+func TestRetryTransportNetworkError(t *testing.T) {
+    _, server := newRetryServer(t, nil, "")
+    url := server.URL
+    server.Close()
+    client := &http.Client{Transport: &RetryTransport{Policy: RetryPolicy{MaxRetries: 2, BaseDelay: time.Millisecond}}}
+    trace := NewTrace()
+    req, _ := http.NewRequest("POST", url, strings.NewReader("x=1"))
+    req, span := trace.Start("test", BookStep, NotIdempotent(req))
+    resp, err := client.Do(req)
+    span.End(resp, err)
+    if err == nil {
+        t.Fatal("Do to a closed server succeeded")
+    }
+    // a booking that never got an answer may have gone through
+    if retries := trace.Spans()[0].Retries; len(retries) != 0 {
+        t.Errorf("not idempotent request retried %d times", len(retries))
+    }
+}
+
+func TestBackoff(t *testing.T) {
+    policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+    for retries, ceiling := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+        ceiling *= time.Millisecond
+        for i := 0; i < 50; i++ {
+            wait := policy.backoff(retries)
+            if wait < ceiling / 2 || wait > ceiling {
+                t.Fatalf("backoff(%d) = %v, want between %v and %v", retries, wait, ceiling / 2, ceiling)
+            }
+        }
+    }
+    if wait := (RetryPolicy{}).backoff(3); wait != 0 {
+        t.Errorf("backoff with no delays = %v, want 0", wait)
+    }
+}
+
+func TestRetryAfter(t *testing.T) {
+    tests := []struct {
+        header      string
+        want        time.Duration
+        wantOK      bool
+    }{
+        {"", 0, false},
+        {"0", 0, true},
+        {"3", 3 * time.Second, true},
+        {"-1", 0, false},
+        {"soon", 0, false},
+    }
+    for _, tt := range tests {
+        got, ok := retryAfter(tt.header)
+        if got != tt.want || ok != tt.wantOK {
+            t.Errorf("retryAfter(%q) = %v, %v, want %v, %v", tt.header, got, ok, tt.want, tt.wantOK)
+        }
+    }
+    // an HTTP date is a wait until then
+    at := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+    got, ok := retryAfter(at)
+    if !ok || got < 59 * time.Minute || got > time.Hour {
+        t.Errorf("retryAfter(%q) = %v, %v, want about an hour", at, got, ok)
+    }
+}
@@ -0,0 +1,154 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "errors"
+    "regexp"
+    "strings"
+    "unicode"
+)
+
+var (
+    ErrTableType = errors.New("invalid seating pattern")
+)
+
+const (
+    // Prefixes picking how a TableType matches, a
+    // TableType without one matches whole words
+    ExactTableTypePrefix = "exact:"
+    ContainsTableTypePrefix = "contains:"
+    RegexTableTypePrefix = "regex:"
+)
+
+/*
+Name: Validate
+Type: API Func
+Purpose: Report whether a TableType is a usable pattern,
+returning ErrTableType if it is empty or its regex does
+not compile
+*/
+func (t TableType) Validate() (error) {
+    raw := string(t)
+    lower := strings.ToLower(raw)
+    for _, prefix := range []string{ExactTableTypePrefix, ContainsTableTypePrefix, RegexTableTypePrefix} {
+        if strings.HasPrefix(lower, prefix) {
+            raw = raw[len(prefix):]
+            break
+        }
+    }
+    if strings.TrimSpace(raw) == "" {
+        return ErrTableType
+    }
+    if strings.HasPrefix(lower, RegexTableTypePrefix) {
+        if _, err := regexp.Compile("(?i)" + raw); err != nil {
+            return ErrTableType
+        }
+    }
+    return nil
+}
+
+/*
+Name: Matches
+Type: API Func
+Purpose: Report whether a seating type, as a service names
+it, fits the pattern. All forms ignore case. exact: must be
+the whole name, contains: anywhere in it, regex: a regular
+expression found in it, and a plain pattern must be whole
+words of it, so "bar" fits "Main Bar" but not "Barrel Room"
+*/
+func (t TableType) Matches(seating string) (bool) {
+    raw := string(t)
+    lower := strings.ToLower(raw)
+    seating = strings.TrimSpace(seating)
+    switch {
+    case strings.HasPrefix(lower, ExactTableTypePrefix):
+        return strings.EqualFold(strings.TrimSpace(raw[len(ExactTableTypePrefix):]), seating)
+    case strings.HasPrefix(lower, ContainsTableTypePrefix):
+        pattern := strings.TrimSpace(lower[len(ContainsTableTypePrefix):])
+        if pattern == "" {
+            return false
+        }
+        return strings.Contains(strings.ToLower(seating), pattern)
+    case strings.HasPrefix(lower, RegexTableTypePrefix):
+        re, err := regexp.Compile("(?i)" + raw[len(RegexTableTypePrefix):])
+        if err != nil {
+            return false
+        }
+        return re.MatchString(seating)
+    }
+    return containsWords(strings.ToLower(seating), strings.TrimSpace(lower))
+}
+
+/*
+Name: containsWords
+Type: Internal Func
+Purpose: Report whether words appears in text with no
+letter or digit right before or after it
+*/
+func containsWords(text string, words string) (bool) {
+    if words == "" {
+        return false
+    }
+    for start := 0; start <= len(text) - len(words); {
+        i := strings.Index(text[start:], words)
+        if i == -1 {
+            return false
+        }
+        i += start
+        end := i + len(words)
+        before := i == 0 || !isWordRune(lastRune(text[:i]))
+        after := end == len(text) || !isWordRune([]rune(text[end:])[0])
+        if before && after {
+            return true
+        }
+        start = i + 1
+    }
+    return false
+}
+
+/*
+Name: lastRune
+Type: Internal Func
+Purpose: Return the last rune of a non-empty string
+*/
+func lastRune(s string) (rune) {
+    runes := []rune(s)
+    return runes[len(runes)-1]
+}
+
+/*
+Name: isWordRune
+Type: Internal Func
+Purpose: Report whether a rune is part of a word
+*/
+func isWordRune(r rune) (bool) {
+    return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+/*
+Name: MatchSeating
+Type: API Func
+Purpose: Place a seating type on a priority list of table
+types, returning the index of the first it fits. A seating
+type fitting any of the excludes is refused, and with no
+table types listed any other seating type fits at index 0
+*/
+func MatchSeating(seating string, tableTypes []TableType, excludes []TableType) (int, bool) {
+    for _, exclude := range excludes {
+        if exclude.Matches(seating) {
+            return 0, false
+        }
+    }
+    if len(tableTypes) == 0 {
+        return 0, true
+    }
+    for i, tableType := range tableTypes {
+        if tableType.Matches(seating) {
+            return i, true
+        }
+    }
+    return 0, false
+}
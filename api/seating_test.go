@@ -0,0 +1,39 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "testing"
+)
+
+func TestTableTypeMatches(t *testing.T) {
+    tests := []struct {
+        pattern     TableType
+        seating     string
+        want        bool
+    }{
+        {"bar", "Main Bar", true},
+        {"bar", "Barrel Room", false},
+        {" Bar ", "bar", true},
+        {"exact:Patio", "patio", true},
+        {"exact: patio ", "Patio", true},
+        {"exact:patio", "Patio Heated", false},
+        {"contains:patio", "Heated Patio", true},
+        {"contains: patio", "Patio", true},
+        {"CONTAINS:Pat ", "patio", true},
+        {"contains:", "Patio", false},
+        {"contains:  ", "Patio", false},
+        {"contains:tio", "Dining Room", false},
+        {"regex:^(patio|terrace)$", "Terrace", true},
+        {"regex:^patio$", "Heated Patio", false},
+        {"regex:(", "(", false},
+        {"", "Patio", false},
+    }
+    for _, tt := range tests {
+        if got := tt.pattern.Matches(tt.seating); got != tt.want {
+            t.Errorf("%q.Matches(%q) = %v, want %v", tt.pattern, tt.seating, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,233 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "sort"
+    "time"
+)
+
+/*
+Name: Candidate
+Type: API Struct
+Purpose: An open slot a service could book for a reserve
+request, along with where it sits on the request's
+priority lists
+Note: The indexes are positions in the lists of the request
+the candidate was made for. A candidate matching several
+entries of a list takes the earliest
+*/
+type Candidate struct {
+    Time            time.Time
+    // Seating as the service names it, i.e. "Dining Room"
+    Seating         string
+    PartySize       int
+    // What booking the slot costs, as far as the
+    // service says when listing it
+    Fees            FeePolicy
+    // The window the slot fell in
+    Window          TimeWindow
+    WindowIdx       int
+    // Index in TableTypes, 0 if the request listed none
+    TableIdx        int
+    // Index in PartySizes()
+    PartyIdx        int
+}
+
+/*
+Name: SlotSelector
+Type: Interface
+Purpose: Strategy deciding which open slot to book first.
+Services list every candidate for a request, order them
+with RankCandidates, and try them in that order
+*/
+type SlotSelector interface {
+    // Report whether c1 should be tried before c2
+    Less(c1 Candidate, c2 Candidate) (bool)
+}
+
+/*
+Name: TableFirstSelector
+Type: SlotSelector
+Purpose: The default strategy. Tries every time on the
+first table type before the next table type, then windows
+in priority order, then the slots within a window by its
+policy. Party sizes outrank all of it
+*/
+type TableFirstSelector struct {}
+
+/*
+Name: Less
+Type: SlotSelector method
+Purpose: Satisfy the SlotSelector interface
+*/
+func (s TableFirstSelector) Less(c1 Candidate, c2 Candidate) (bool) {
+    if c1.PartyIdx != c2.PartyIdx {
+        return c1.PartyIdx < c2.PartyIdx
+    }
+    if c1.TableIdx != c2.TableIdx {
+        return c1.TableIdx < c2.TableIdx
+    }
+    if c1.WindowIdx != c2.WindowIdx {
+        return c1.WindowIdx < c2.WindowIdx
+    }
+    return c1.Window.Prefers(c1.Time, c2.Time)
+}
+
+/*
+Name: TimeFirstSelector
+Type: SlotSelector
+Purpose: Tries windows in priority order and the slots
+within a window by its policy, only then going down the
+table types for slots at the same time. Party sizes
+outrank all of it
+*/
+type TimeFirstSelector struct {}
+
+/*
+Name: Less
+Type: SlotSelector method
+Purpose: Satisfy the SlotSelector interface
+*/
+func (s TimeFirstSelector) Less(c1 Candidate, c2 Candidate) (bool) {
+    if c1.PartyIdx != c2.PartyIdx {
+        return c1.PartyIdx < c2.PartyIdx
+    }
+    if c1.WindowIdx != c2.WindowIdx {
+        return c1.WindowIdx < c2.WindowIdx
+    }
+    if !c1.Time.Equal(c2.Time) {
+        return c1.Window.Prefers(c1.Time, c2.Time)
+    }
+    return c1.TableIdx < c2.TableIdx
+}
+
+/*
+Name: WeightedSelector
+Type: SlotSelector
+Purpose: Scores each candidate as a weighted sum of how
+far it is from what the request wanted and what it costs,
+trying the lowest score first. Ties keep the order the
+service listed them
+*/
+type WeightedSelector struct {
+    // Per minute away from its window's preferred time
+    TimeWeight      float64
+    // Per place down the window list
+    WindowWeight    float64
+    // Per place down the table type list
+    SeatingWeight   float64
+    // Per unit of the largest fee
+    FeeWeight       float64
+    // Per place down the party size list
+    PartyWeight     float64
+}
+
+/*
+Name: Score
+Type: API Func
+Purpose: Return the weighted distance of a candidate
+from the request's ideal slot
+*/
+func (s WeightedSelector) Score(c Candidate) (float64) {
+    away := c.Time.Sub(c.Window.Preferred())
+    if away < 0 {
+        away = -away
+    }
+    return s.TimeWeight * away.Minutes() +
+        s.WindowWeight * float64(c.WindowIdx) +
+        s.SeatingWeight * float64(c.TableIdx) +
+        s.FeeWeight * c.Fees.Largest() +
+        s.PartyWeight * float64(c.PartyIdx)
+}
+
+/*
+Name: Less
+Type: SlotSelector method
+Purpose: Satisfy the SlotSelector interface
+*/
+func (s WeightedSelector) Less(c1 Candidate, c2 Candidate) (bool) {
+    return s.Score(c1) < s.Score(c2)
+}
+
+/*
+Name: RankCandidates
+Type: API Func
+Purpose: Order candidates by a selector, keeping the
+listed order between candidates it ranks equal
+*/
+func RankCandidates(selector SlotSelector, candidates []Candidate) {
+    sort.SliceStable(candidates, func(i, j int) bool {
+        return selector.Less(candidates[i], candidates[j])
+    })
+}
+
+/*
+Name: SlotSelector
+Type: API Func
+Purpose: Return the selector a request asked for, or
+the default table first one
+*/
+func (p ReserveParam) SlotSelector() (SlotSelector) {
+    if p.Selector == nil {
+        return TableFirstSelector{}
+    }
+    return p.Selector
+}
+
+/*
+Name: Candidate
+Type: API Func
+Purpose: Place an open slot on the request's priority
+lists. Returns false if the slot is in none of the
+windows, matches none of the table types or one of the
+excluded ones, or is for a party size the request
+doesn't take
+*/
+func (p ReserveParam) Candidate(t time.Time, seating string, partySize int) (Candidate, bool) {
+    c := Candidate{Time: t, Seating: seating, PartySize: partySize}
+    partyIdx := -1
+    for i, size := range p.PartySizes() {
+        if size == partySize {
+            partyIdx = i
+            break
+        }
+    }
+    if partyIdx == -1 {
+        return c, false
+    }
+    c.PartyIdx = partyIdx
+    windowIdx := -1
+    for i, window := range p.Windows() {
+        if window.Contains(t) {
+            windowIdx = i
+            c.Window = window
+            break
+        }
+    }
+    if windowIdx == -1 {
+        return c, false
+    }
+    c.WindowIdx = windowIdx
+    tableIdx, ok := MatchSeating(seating, p.TableTypes, p.ExcludeTableTypes)
+    if !ok {
+        return c, false
+    }
+    c.TableIdx = tableIdx
+    return c, true
+}
+
+/*
+Name: TableType
+Type: API Func
+Purpose: Return the request table type a candidate
+matched, empty if the request listed none
+*/
+func (p ReserveParam) TableType(c Candidate) (TableType) {
+    if len(p.TableTypes) == 0 {
+        return ""
+    }
+    return p.TableTypes[c.TableIdx]
+}
@@ -0,0 +1,437 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/tls"
+    "encoding/hex"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptrace"
+    "strconv"
+    "sync"
+    "time"
+)
+
+const (
+    // Spans kept on a trace, the oldest are dropped
+    // once there are more
+    MaxTraceSpans = 512
+)
+
+/*
+Name: Span
+Type: API Struct
+Purpose: Timing of one HTTP request a service made for a
+step, broken down with httptrace. DNS, Connect and TLS are
+zero when a kept-alive connection was reused, FirstByte is
+measured from Start. When the request was retried the timings
+are of the last try
+*/
+type Span struct {
+    // Attempt of the trace the request belongs to,
+    // counting from 1, 0 if none was started
+    Attempt         int
+    Service         string
+    Step            string
+    Method          string
+    // Host and path only, queries can hold tokens
+    URL             string
+    Start           time.Time
+    Duration        time.Duration
+    DNS             time.Duration
+    Connect         time.Duration
+    TLS             time.Duration
+    FirstByte       time.Duration
+    ConnReused      bool
+    // HTTP status, 0 if no response came back
+    Status          int
+    Err             string
+    // Failed tries before the last, see 'RetryTransport'
+    Retries         []Retry
+}
+
+/*
+Name: Trace
+Type: API Struct
+Purpose: Collect the spans of the requests made for an
+operation, grouped into attempts. A nil Trace records
+nothing, so services can trace unconditionally
+*/
+type Trace struct {
+    mu              sync.Mutex
+    attempt         int
+    spans           []Span
+    // set by NewOperationTrace
+    operationID     int64
+    hasOperation    bool
+}
+
+/*
+Name: NewTrace
+Type: API Func
+Purpose: Make an empty trace
+*/
+func NewTrace() (*Trace) {
+    return &Trace{}
+}
+
+/*
+Name: NewOperationTrace
+Type: API Func
+Purpose: Make an empty trace for an operation, which tags
+the requests it starts with the operation's id, see
+'WithOperation'
+*/
+func NewOperationTrace(id int64) (*Trace) {
+    return &Trace{operationID: id, hasOperation: true}
+}
+
+/*
+Name: StartAttempt
+Type: API Func
+Purpose: Begin a new attempt, spans recorded from now on
+belong to it
+*/
+func (t *Trace) StartAttempt() {
+    if t == nil {
+        return
+    }
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.attempt += 1
+}
+
+/*
+Name: Spans
+Type: API Func
+Purpose: Return a copy of the spans recorded so far,
+in the order the requests were made
+*/
+func (t *Trace) Spans() ([]Span) {
+    if t == nil {
+        return nil
+    }
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    return append([]Span(nil), t.spans...)
+}
+
+/*
+Name: add
+Type: Internal Func
+Purpose: Record a finished span under the current attempt
+*/
+func (t *Trace) add(span Span) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    span.Attempt = t.attempt
+    t.spans = append(t.spans, span)
+    if len(t.spans) > MaxTraceSpans {
+        t.spans = append([]Span(nil), t.spans[len(t.spans) - MaxTraceSpans:]...)
+    }
+}
+
+/*
+Name: ActiveSpan
+Type: API Struct
+Purpose: A span whose request is in flight, see 'Trace.Start'
+*/
+type ActiveSpan struct {
+    trace           *Trace
+    mu              sync.Mutex
+    span            Span
+    dnsStart        time.Time
+    connectStart    time.Time
+    tlsStart        time.Time
+}
+
+// Key of the ActiveSpan on the context of its request
+type activeSpanKey struct{}
+
+/*
+Name: Start
+Type: API Func
+Purpose: Begin a span for a request made for a step, returning
+the request to send, which reports its timings to the span
+and carries the trace's operation id, if it has one. Call
+End on the span once the response is in
+Note: On a nil Trace the request is returned as is, along
+with a nil span whose End does nothing
+*/
+func (t *Trace) Start(service string, step string, req *http.Request) (*http.Request, *ActiveSpan) {
+    if t == nil {
+        return req, nil
+    }
+    s := &ActiveSpan{
+        trace: t,
+        span: Span{
+            Service: service,
+            Step: step,
+            Method: req.Method,
+            URL: req.URL.Host + req.URL.Path,
+            Start: time.Now(),
+        },
+    }
+    clientTrace := &httptrace.ClientTrace{
+        DNSStart: func(httptrace.DNSStartInfo) {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.dnsStart = time.Now()
+        },
+        DNSDone: func(httptrace.DNSDoneInfo) {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.span.DNS = time.Since(s.dnsStart)
+        },
+        ConnectStart: func(string, string) {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.connectStart = time.Now()
+        },
+        ConnectDone: func(string, string, error) {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.span.Connect = time.Since(s.connectStart)
+        },
+        TLSHandshakeStart: func() {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.tlsStart = time.Now()
+        },
+        TLSHandshakeDone: func(tls.ConnectionState, error) {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.span.TLS = time.Since(s.tlsStart)
+        },
+        GotConn: func(info httptrace.GotConnInfo) {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.span.ConnReused = info.Reused
+        },
+        GotFirstResponseByte: func() {
+            s.mu.Lock()
+            defer s.mu.Unlock()
+            s.span.FirstByte = time.Since(s.span.Start)
+        },
+    }
+    ctx := context.WithValue(httptrace.WithClientTrace(req.Context(), clientTrace), activeSpanKey{}, s)
+    if t.hasOperation {
+        ctx = context.WithValue(ctx, operationKey{}, t.operationID)
+    }
+    return req.WithContext(ctx), s
+}
+
+/*
+Name: retried
+Type: Internal Func
+Purpose: Record a failed try of the span's request that
+is being sent again
+*/
+func (s *ActiveSpan) retried(retry Retry) {
+    if s == nil {
+        return
+    }
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.span.Retries = append(s.span.Retries, retry)
+}
+
+/*
+Name: End
+Type: API Func
+Purpose: Finish a span with the response and error the
+request came back with, either may be nil
+*/
+func (s *ActiveSpan) End(resp *http.Response, err error) {
+    if s == nil {
+        return
+    }
+    s.mu.Lock()
+    span := s.span
+    span.Retries = append([]Retry(nil), s.span.Retries...)
+    s.mu.Unlock()
+    span.Duration = time.Since(span.Start)
+    if resp != nil {
+        span.Status = resp.StatusCode
+    }
+    if err != nil {
+        span.Err = Redact(err.Error())
+    }
+    s.trace.add(span)
+}
+
+// OTLP/JSON shapes, as read by OpenTelemetry collectors
+type (
+    otlpExport struct {
+        ResourceSpans   []otlpResourceSpans     `json:"resourceSpans"`
+    }
+    otlpResourceSpans struct {
+        Resource        otlpResource            `json:"resource"`
+        ScopeSpans      []otlpScopeSpans        `json:"scopeSpans"`
+    }
+    otlpResource struct {
+        Attributes      []otlpAttr              `json:"attributes"`
+    }
+    otlpScopeSpans struct {
+        Scope           otlpScope               `json:"scope"`
+        Spans           []otlpSpan              `json:"spans"`
+    }
+    otlpScope struct {
+        Name            string                  `json:"name"`
+    }
+    otlpSpan struct {
+        TraceID         string                  `json:"traceId"`
+        SpanID          string                  `json:"spanId"`
+        ParentSpanID    string                  `json:"parentSpanId,omitempty"`
+        Name            string                  `json:"name"`
+        Kind            int                     `json:"kind"`
+        StartTime       string                  `json:"startTimeUnixNano"`
+        EndTime         string                  `json:"endTimeUnixNano"`
+        Attributes      []otlpAttr              `json:"attributes,omitempty"`
+        Status          otlpStatus              `json:"status"`
+    }
+    otlpAttr struct {
+        Key             string                  `json:"key"`
+        Value           otlpValue               `json:"value"`
+    }
+    otlpValue struct {
+        StringValue     *string                 `json:"stringValue,omitempty"`
+        IntValue        *string                 `json:"intValue,omitempty"`
+        BoolValue       *bool                   `json:"boolValue,omitempty"`
+    }
+    otlpStatus struct {
+        Code            int                     `json:"code,omitempty"`
+        Message         string                  `json:"message,omitempty"`
+    }
+)
+
+const (
+    // OTLP span kinds and status codes used
+    otlpKindInternal = 1
+    otlpKindClient = 3
+    otlpStatusError = 2
+)
+
+/*
+Name: WriteOTLP
+Type: API Func
+Purpose: Write spans as OpenTelemetry OTLP/JSON, one trace
+named name with a parent span per attempt and a client span
+per request, with the httptrace timings in milliseconds as
+attributes
+*/
+func WriteOTLP(w io.Writer, name string, spans []Span) (error) {
+    traceID := randomID(16)
+    scope := otlpScopeSpans{Scope: otlpScope{Name: "resolved"}, Spans: []otlpSpan{}}
+    attemptIDs := map[int]string{}
+    attemptSpans := map[int]*otlpSpan{}
+    attemptEnds := map[int]time.Time{}
+    attempts := []int{}
+    for _, span := range spans {
+        end := span.Start.Add(span.Duration)
+        parent, ok := attemptSpans[span.Attempt]
+        if !ok {
+            attemptIDs[span.Attempt] = randomID(8)
+            parent = &otlpSpan{
+                TraceID: traceID,
+                SpanID: attemptIDs[span.Attempt],
+                Name: name + " attempt " + strconv.Itoa(span.Attempt),
+                Kind: otlpKindInternal,
+                StartTime: unixNano(span.Start),
+                Attributes: []otlpAttr{intAttr("resolved.attempt", int64(span.Attempt))},
+            }
+            attemptSpans[span.Attempt] = parent
+            attempts = append(attempts, span.Attempt)
+        }
+        // spans come in order, so only the end can grow
+        if end.After(attemptEnds[span.Attempt]) {
+            attemptEnds[span.Attempt] = end
+        }
+        child := otlpSpan{
+            TraceID: traceID,
+            SpanID: randomID(8),
+            ParentSpanID: attemptIDs[span.Attempt],
+            Name: span.Service + " " + span.Step,
+            Kind: otlpKindClient,
+            StartTime: unixNano(span.Start),
+            EndTime: unixNano(end),
+            Attributes: []otlpAttr{
+                stringAttr("http.request.method", span.Method),
+                stringAttr("url.full", "https://" + span.URL),
+                intAttr("http.response.status_code", int64(span.Status)),
+                stringAttr("resolved.service", span.Service),
+                stringAttr("resolved.step", span.Step),
+                intAttr("resolved.attempt", int64(span.Attempt)),
+                boolAttr("resolved.conn_reused", span.ConnReused),
+                intAttr("resolved.dns_ms", span.DNS.Milliseconds()),
+                intAttr("resolved.connect_ms", span.Connect.Milliseconds()),
+                intAttr("resolved.tls_ms", span.TLS.Milliseconds()),
+                intAttr("resolved.first_byte_ms", span.FirstByte.Milliseconds()),
+                intAttr("resolved.retries", int64(len(span.Retries))),
+            },
+        }
+        if span.Err != "" || span.Status >= 400 {
+            child.Status = otlpStatus{Code: otlpStatusError, Message: span.Err}
+            parent.Status = otlpStatus{Code: otlpStatusError}
+        }
+        scope.Spans = append(scope.Spans, child)
+    }
+    for _, attempt := range attempts {
+        attemptSpans[attempt].EndTime = unixNano(attemptEnds[attempt])
+        scope.Spans = append(scope.Spans, *attemptSpans[attempt])
+    }
+    export := otlpExport{
+        ResourceSpans: []otlpResourceSpans{{
+            Resource: otlpResource{Attributes: []otlpAttr{stringAttr("service.name", "resolved")}},
+            ScopeSpans: []otlpScopeSpans{scope},
+        }},
+    }
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+    return encoder.Encode(export)
+}
+
+/*
+Name: randomID
+Type: Internal Func
+Purpose: Return n random bytes in hex, for trace and span ids
+*/
+func randomID(n int) (string) {
+    b := make([]byte, n)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+/*
+Name: unixNano
+Type: Internal Func
+Purpose: Format a time as OTLP/JSON wants it, nanoseconds
+since the epoch in a string
+*/
+func unixNano(t time.Time) (string) {
+    return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+/*
+Name: stringAttr, intAttr, boolAttr
+Type: Internal Func
+Purpose: Make OTLP attributes, ints are strings in OTLP/JSON
+*/
+func stringAttr(key string, v string) (otlpAttr) {
+    return otlpAttr{Key: key, Value: otlpValue{StringValue: &v}}
+}
+
+func intAttr(key string, v int64) (otlpAttr) {
+    s := strconv.FormatInt(v, 10)
+    return otlpAttr{Key: key, Value: otlpValue{IntValue: &s}}
+}
+
+func boolAttr(key string, v bool) (otlpAttr) {
+    return otlpAttr{Key: key, Value: otlpValue{BoolValue: &v}}
+}
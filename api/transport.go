@@ -0,0 +1,118 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package api
+
+import (
+    "io"
+    "log/slog"
+    "net"
+    "net/http"
+    "sync"
+    "time"
+)
+
+/*
+Name: NewClient
+Type: API Func
+Purpose: Make an HTTP client whose connections are kept
+alive between requests, so a service sharing it across
+calls only pays for TCP and TLS setup once per connection.
+Failed requests are retried under DefaultRetryPolicy, and
+every try goes through DefaultRecorder
+*/
+func NewClient() (*http.Client) {
+    base := DefaultRecorder.Transport(newKeepAliveTransport())
+    return &http.Client{Transport: &RetryTransport{Base: base, Policy: DefaultRetryPolicy}}
+}
+
+/*
+Name: newKeepAliveTransport
+Type: Internal Func
+Purpose: Make the transport under the clients NewClient
+makes, pooling kept-alive connections
+*/
+func newKeepAliveTransport() (*http.Transport) {
+    return &http.Transport{
+        Proxy: http.ProxyFromEnvironment,
+        DialContext: (&net.Dialer{
+            Timeout: 30 * time.Second,
+            KeepAlive: 30 * time.Second,
+        }).DialContext,
+        ForceAttemptHTTP2: true,
+        MaxIdleConns: 32,
+        MaxIdleConnsPerHost: 8,
+        IdleConnTimeout: 90 * time.Second,
+        TLSHandshakeTimeout: 10 * time.Second,
+        ExpectContinueTimeout: time.Second,
+    }
+}
+
+/*
+Name: WarmConnections
+Type: API Func
+Purpose: Open n connections to the host of url at once and
+leave them idle in the client's pool, by sending HEAD
+requests and draining the responses. The status of the
+responses doesn't matter, only the first error is returned
+Note: Over HTTP/2 requests share a connection, so fewer
+than n may be opened
+*/
+func WarmConnections(client *http.Client, url string, n int) (error) {
+    if n <= 0 {
+        return nil
+    }
+    var wg sync.WaitGroup
+    errs := make(chan error, n)
+    for i := 0; i < n; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            request, err := http.NewRequest("HEAD", url, nil)
+            if err != nil {
+                errs <- err
+                return
+            }
+            request.Header.Set("user-agent", "Resolved-Server")
+            response, err := client.Do(request)
+            if err != nil {
+                errs <- err
+                return
+            }
+            // a drained body hands the connection
+            // back to the pool
+            io.Copy(io.Discard, response.Body)
+            response.Body.Close()
+        }()
+    }
+    wg.Wait()
+    close(errs)
+    return <-errs
+}
+
+/*
+Name: WarmParam
+Type: API Func Input Struct
+Purpose: Input information to the 'Warm' api function
+*/
+type WarmParam struct {
+    Login           LoginParam
+    // Connections to have open once warm, at least one
+    Conns           int
+    // As in ReserveParam
+    Logger          *slog.Logger
+}
+
+/*
+Name: Warmer
+Type: Interface
+Purpose: Optional behavior for external services which can
+get ready for a request ahead of time, logging in again for
+fresh auth and opening connections to the service so the
+requests that follow skip connection setup. Consumers should
+check for it with a type assertion on an API
+*/
+type Warmer interface {
+    Warm(params WarmParam) (*LoginResponse, error)
+}
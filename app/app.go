@@ -10,6 +10,8 @@ import (
     "time"
     "strconv"
     "fmt"
+    "log/slog"
+    "sync"
 )
 
 var (
@@ -21,6 +23,18 @@ var (
     ErrCurrOp = errors.New("operation is in progress")
     ErrIdOp = errors.New("no operation has specified id")
     ErrTimeFut = errors.New("provided time has passed")
+    ErrNoSuccess = errors.New("operation did not succeed")
+    ErrDryUpgrade = errors.New("a dry run can't hunt for upgrades")
+    ErrEarlyStart = errors.New("early start can't be negative")
+)
+
+const (
+    // Default time before a reserve at time op's request
+    // time that it warms up, close enough that the server
+    // keeps the connections open
+    DefaultWarmLead = 5 * time.Second
+    // Connections a reserve at time op opens warming up
+    warmConns = 2
 )
 
 // OperationStatus type is an enum, only use with next const def types
@@ -52,6 +66,15 @@ type AppCtx struct {
     // The API to run the app on
     API         api.API
 
+    // Where the app logs, nothing is logged if unset.
+    // Operations tag it with their id and venue and hand
+    // it to the API with each request
+    Logger      *slog.Logger
+
+    // Guards the fields below, since operation go threads
+    // read them too
+    mu          sync.Mutex
+
     // List of internal concurrent operations, both completed
     // and running
     operations  []Operation    
@@ -61,6 +84,16 @@ type AppCtx struct {
 
     // Simple ID generator
     idGen       int64
+
+    // Subscribers to operation outcomes
+    notifiers   []Notifier
+
+    // User commands run on operation outcomes
+    hooks       []Hook
+
+    // How new operations treat overlapping reservations
+    conflictPolicy  ConflictPolicy
+    conflictBuffer  time.Duration
 }
 
 /*
@@ -74,8 +107,30 @@ type ReserveAtIntervalParam struct {
     VenueID          int64
     ReservationTimes []time.Time
     PartySize        int
+    // Sizes to fall back on, in priority order, if
+    // nothing is open for PartySize
+    AltPartySizes    []int
     RepeatInterval   time.Duration
     TableTypes 	     []api.TableType
+    // Seating never booked, see 'api.TableType.Matches'
+    ExcludeTableTypes []api.TableType
+    // If set, takes the place of ReservationTimes as the
+    // priority list, see 'reserveWindows'
+    TimeWindows      []api.TimeWindow
+    // Order to try open slots in, table type first
+    // if not set
+    Selector         api.SlotSelector
+    // Fees accepted, the zero value accepts any
+    FeeLimit         api.FeeLimit
+    // Keep hunting for a better slot after booking,
+    // polling every RepeatInterval
+    Upgrade          bool
+    // Stop short of booking and report the slot that
+    // would have been booked, see 'DryRunResponse'
+    DryRun           bool
+    // Slots to fetch booking details for at once, see
+    // 'api.ReserveParam'
+    ParallelDetails  int
 }
 
 /*
@@ -89,8 +144,46 @@ type ReserveAtTimeParam struct {
     VenueID          int64
     ReservationTimes []time.Time
     PartySize        int
+    // Sizes to fall back on, in priority order, if
+    // nothing is open for PartySize
+    AltPartySizes    []int
     RequestTime      time.Time
     TableTypes 	     []api.TableType
+    // Seating never booked, see 'api.TableType.Matches'
+    ExcludeTableTypes []api.TableType
+    // If set, takes the place of ReservationTimes as the
+    // priority list, see 'reserveWindows'
+    TimeWindows      []api.TimeWindow
+    // Order to try open slots in, table type first
+    // if not set
+    Selector         api.SlotSelector
+    // Fees accepted, the zero value accepts any
+    FeeLimit         api.FeeLimit
+    // Keep hunting for a better slot after booking,
+    // polling every UpgradeInterval
+    Upgrade          bool
+    UpgradeInterval  time.Duration
+    // Stop short of booking and report the slot that
+    // would have been booked, see 'DryRunResponse'
+    DryRun           bool
+    // Slots to fetch booking details for at once, see
+    // 'api.ReserveParam'
+    ParallelDetails  int
+    // How long before RequestTime to warm up if the api
+    // implements api.Warmer, DefaultWarmLead if zero.
+    // Negative skips warming up
+    WarmLead         time.Duration
+    // Time the request on the local clock instead of the
+    // service's, see 'api.ClockReader'
+    NoClockSync      bool
+    // NTP server, as host or host:port, also asked for
+    // the offset if set
+    NTPServer        string
+    // How much before RequestTime, on the service's clock,
+    // to send the request
+    EarlyStart       time.Duration
+    // Reserve requests to send at the drop, one if unset
+    Burst            DropBurst
 }
 
 /*
@@ -111,6 +204,11 @@ successful reserve at interval response
 */
 type ReserveAtIntervalResponse struct {
     ReservationTime time.Time
+    ReservationID   string
+    VenueID         int64
+    VenueName       string
+    VenueAddress    string
+    PartySize       int
 }
 
 /*
@@ -130,6 +228,11 @@ successful reserve at time response
 */
 type ReserveAtTimeResponse struct {
     ReservationTime time.Time
+    ReservationID   string
+    VenueID         int64
+    VenueName       string
+    VenueAddress    string
+    PartySize       int
 }
 
 /*
@@ -141,6 +244,79 @@ func (r ReserveAtTimeResponse) Time() (time.Time) {
     return r.ReservationTime
 }
 
+/*
+Name: DryRunResponse 
+Type: struct
+Purpose: Define the data returned by a reserve operation
+run as a dry run, describing the slot it would have booked
+Note: Nothing was booked, so this is not a Reservable and
+never shows up as a reservation or conflicts with one
+*/
+type DryRunResponse struct {
+    ReservationTime time.Time
+    VenueID         int64
+    VenueName       string
+    VenueAddress    string
+    PartySize       int
+    // The table type from the op's list the slot
+    // matched, empty if the op listed none
+    TableType       api.TableType
+    Fees            api.FeePolicy
+}
+
+/*
+Name: Time 
+Type: interface method
+Purpose: Satisfy the Timetable interface
+*/
+func (r DryRunResponse) Time() (time.Time) {
+    return r.ReservationTime
+}
+
+/*
+Name: describe
+Type: Internal Func
+Purpose: Stringify the slot a dry run would have booked,
+with its day if the op asked for several
+*/
+func (r DryRunResponse) describe(withDay bool) (string) {
+    str := r.ReservationTime.Format("15:04")
+    if withDay {
+        str += r.ReservationTime.Format(" on Mon Jan 2")
+    }
+    if r.TableType != "" {
+        str += ", " + string(r.TableType)
+    }
+    if r.PartySize != 0 {
+        str += ", party of " + strconv.Itoa(r.PartySize)
+    }
+    if r.Fees.Largest() > 0 {
+        str += ", fees up to " + strconv.FormatFloat(r.Fees.Largest(), 'f', 2, 64)
+    }
+    if r.Fees.Prepay {
+        str += ", prepaid"
+    }
+    return str
+}
+
+/*
+Name: newDryRunResponse
+Type: Internal Func
+Purpose: Build the result of a dry run op from the
+api response of its rehearsed booking
+*/
+func newDryRunResponse(venueID int64, preferred int, resp api.ReserveResponse) (DryRunResponse) {
+    return DryRunResponse{
+        ReservationTime: resp.ReservationTime,
+        VenueID: venueID,
+        VenueName: resp.VenueName,
+        VenueAddress: resp.VenueAddress,
+        PartySize: bookedPartySize(resp, preferred),
+        TableType: resp.TableType,
+        Fees: resp.Fees,
+    }
+}
+
 /*
 Name: OperationResult 
 Type: struct 
@@ -150,6 +326,14 @@ operation
 type OperationResult struct {
     Response    Timetable
     Err         error
+    // Set if any notifier failed to deliver the outcome
+    NotifyErr   error
+    // Executions of user hooks on the outcome
+    HookRuns    []HookRun
+    // Overlaps found once the op booked
+    Conflicts   []Conflict
+    // Set if upgrade hunting stopped early
+    UpgradeErr  error
 }
 
 /*
@@ -159,11 +343,37 @@ Purpose: Maintain the state associated with a running
 go thread operation
 */
 type Operation struct{
-    ID      int64
-    Cancel  chan<- bool
-    Output  <-chan OperationResult
-    Result  *OperationResult
-    Status  OperationStatus
+    ID                  int64
+    Cancel              chan<- bool
+    Output              <-chan OperationResult
+    Result              *OperationResult
+    Status              OperationStatus
+    VenueID             int64
+    PartySize           int
+    ReservationTimes    []time.Time
+    // Overlaps found when the op was scheduled
+    Conflicts           []Conflict
+    // Booking held by an op in upgrade mode while
+    // it hunts for a better one
+    Holding             *Reservation
+    Upgrades            []Upgrade
+    // Set for reserve ops that stop short of booking
+    DryRun              bool
+    // Live state of a watch op
+    Watch               bool
+    OpenSlots           []api.Slot
+    SlotChanges         []SlotChange
+    WatchErr            error
+    // Timing of the op's requests, see 'OperationTrace'
+    Trace               *api.Trace
+    // Offset of the service's clock measured by a reserve
+    // at time op, or why it couldn't be
+    Clock               *api.ClockOffset
+    ClockErr            error
+    EarlyStart          time.Duration
+    // Receives the result again once its outcome is
+    // delivered to the notifiers
+    delivery            chan OperationResult
 }
 
 
@@ -185,11 +395,91 @@ func findLastTime(times []time.Time) (*time.Time, error) {
     return &lastTime, nil
 }
 
+/*
+Name: reserveWindows
+Type: Internal Func
+Purpose: Return an op's priority list as windows, turning
+each reservation time into a point window if the op was
+given no windows
+*/
+func reserveWindows(times []time.Time, windows []api.TimeWindow) ([]api.TimeWindow) {
+    return api.ReserveParam{ReservationTimes: times, TimeWindows: windows}.Windows()
+}
+
+/*
+Name: preferredTimes
+Type: Internal Func
+Purpose: Pick the preferred time of each window, which
+stands in for the windows wherever an op's times are
+shown or compared against other ops
+*/
+func preferredTimes(windows []api.TimeWindow) ([]time.Time) {
+    times := make([]time.Time, len(windows))
+    for i, window := range windows {
+        times[i] = window.Preferred()
+    }
+    return times
+}
+
+/*
+Name: nothingBookable
+Type: Internal Func
+Purpose: Report whether a reserve error only means no
+open slot was one the op would take, which a later try
+may find differently
+*/
+func nothingBookable(err error) (bool) {
+    return errors.Is(err, api.ErrNoTable) || errors.Is(err, api.ErrFeeLimit) || errors.Is(err, api.ErrPrepay)
+}
+
+/*
+Name: bookedPartySize
+Type: Internal Func
+Purpose: Return the party size a booking was made for,
+taking the op's preferred size if the service didn't say
+*/
+func bookedPartySize(resp api.ReserveResponse, preferred int) (int) {
+    if resp.PartySize != 0 {
+        return resp.PartySize
+    }
+    return preferred
+}
+
+/*
+Name: spansDays
+Type: Internal Func
+Purpose: Report whether a priority list has times on
+more than one day, in which case the day booked is worth
+showing along with the time
+*/
+func spansDays(times []time.Time) (bool) {
+    for _, t := range times {
+        if t.Format("2006-01-02") != times[0].Format("2006-01-02") {
+            return true
+        }
+    }
+    return false
+}
+
+/*
+Name: windowEnds
+Type: Internal Func
+Purpose: Return the end of each window
+*/
+func windowEnds(windows []api.TimeWindow) ([]time.Time) {
+    ends := make([]time.Time, len(windows))
+    for i, window := range windows {
+        ends[i] = window.End
+    }
+    return ends
+}
+
 /*
 Name: updateOperationResult 
 Type: Internal Func
 Purpose: Used before querying an operation to 
 make sure state is consistent with go thread
+Note: Must be called with the lock held
 */
 func (a *AppCtx) updateOperationResult (id int64) (error) {
     for i, operation := range a.operations {
@@ -205,12 +495,38 @@ func (a *AppCtx) updateOperationResult (id int64) (error) {
                     } else {
                         a.operations[i].Status = SuccessStatusType
                     }
-                    return nil
                 default:
                     // if Output is not ready, do nothing
                     return nil
                 }
             } 
+            // a cancelled op still reports its result once
+            // it notices the cancel, keep it for display
+            if operation.Status == CancelStatusType && operation.Result == nil {
+                select {
+                case opRes, ok := <-a.operations[i].Output:
+                    if ok {
+                        a.operations[i].Result = &opRes
+                        // an op cancelled while hunting for upgrades
+                        // still holds its booking, so it succeeded
+                        if opRes.Err == nil {
+                            a.operations[i].Status = SuccessStatusType
+                        }
+                    }
+                default:
+                }
+            }
+            // the outcome is delivered after the result is sent,
+            // pick up how that went once it is done
+            if a.operations[i].Result != nil {
+                select {
+                case delivered, ok := <-a.operations[i].delivery:
+                    if ok {
+                        a.operations[i].Result = &delivered
+                    }
+                default:
+                }
+            }
             return nil
         }
     }
@@ -224,6 +540,8 @@ Purpose: Used to cancel the operation
 with the specified ID
 */
 func (a *AppCtx) CancelOperation(id int64) (error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
     // update before handling
     err := a.updateOperationResult(id)
     if err != nil {
@@ -238,8 +556,8 @@ func (a *AppCtx) CancelOperation(id int64) (error) {
 
             // we perform all stateful changes in place,
             // i.e., on a.operations[i] instead of the for loop
-            // value 'operation' 
-            a.operations[i].Cancel <- true 
+            // value 'operation'. Closing the channel wakes the
+            // go thread without blocking on it like a send would
             a.operations[i].Status = CancelStatusType
             close(a.operations[i].Cancel)
             return nil
@@ -255,6 +573,11 @@ Type: External App Func
 Purpose: Used to Schedule a reserve at interval operation, returns ID 
 */
 func (a *AppCtx) ScheduleReserveAtIntervalOperation(params ReserveAtIntervalParam) (int64, error) {
+    // logging in at the service is slow, so fetch the
+    // account reservations before taking the lock
+    accountReservations := a.scheduleReservations(params.Login)
+    a.mu.Lock()
+    defer a.mu.Unlock()
     // generate a new id
     id := a.idGen
     a.idGen += 1 
@@ -271,9 +594,31 @@ func (a *AppCtx) ScheduleReserveAtIntervalOperation(params ReserveAtIntervalPara
         params.Login.Password = a.loginInfo.Password
     }
 
-    // make cancel and output channels to manage go thread 
+    // a dry run has no booking to trade up from
+    if params.Upgrade && params.DryRun {
+        return 0, ErrDryUpgrade
+    }
+    // upgrading means cancelling the booking we replace
+    if _, ok := a.API.(api.Canceller); params.Upgrade && !ok {
+        return 0, api.ErrNoCancel
+    }
+
+    // windows stand in as their preferred times for display
+    if len(params.TimeWindows) != 0 {
+        params.ReservationTimes = preferredTimes(params.TimeWindows)
+    }
+
+    // check the times against other bookings and ops
+    windows := reserveWindows(params.ReservationTimes, params.TimeWindows)
+    conflicts, err := a.checkScheduleConflicts(id, windows, accountReservations)
+    if err != nil {
+        return 0, err
+    }
+
+    // make cancel and output channels to manage go thread,
+    // output is buffered so a cancelled op can still exit
     cancel := make(chan bool)
-    output := make(chan OperationResult)
+    output := make(chan OperationResult, 1)
 
     // add op to internal buffer list 
     a.operations = append(a.operations, Operation{
@@ -281,9 +626,18 @@ func (a *AppCtx) ScheduleReserveAtIntervalOperation(params ReserveAtIntervalPara
         Cancel: cancel,
         Output: output,
         Status: InProgressStatusType,
+        VenueID: params.VenueID,
+        PartySize: params.PartySize,
+        ReservationTimes: params.ReservationTimes,
+        Conflicts: conflicts,
+        DryRun: params.DryRun,
+        Trace: api.NewOperationTrace(id),
+        delivery: make(chan OperationResult, 1),
     })
+    meta := a.newOpMeta(a.operations[len(a.operations)-1], accountReservations)
+    meta.Logger.Info("operation scheduled", "kind", "rais", "dry_run", params.DryRun, "conflicts", len(conflicts))
     // run op
-    go a.reserveAtInterval(params, cancel, output)
+    go a.reserveAtInterval(meta, params, cancel, output)
     return id, nil
 }
 
@@ -293,25 +647,44 @@ Type: Internal App Func
 Purpose: This function is intended to run on a separate thread, and tries making
 a reservation at a given interval of time
 */
-func (a *AppCtx) reserveAtInterval(params ReserveAtIntervalParam, cancel <-chan bool, output chan<- OperationResult){
+func (a *AppCtx) reserveAtInterval(meta opMeta, params ReserveAtIntervalParam, cancel <-chan bool, output chan<- OperationResult){
 
-    // find and store last time from time priority list
-    lastTime, err := findLastTime(params.ReservationTimes)
+    // find and store last time from time priority list,
+    // a window lasts until its end
+    windows := reserveWindows(params.ReservationTimes, params.TimeWindows)
+    lastTime, err := findLastTime(windowEnds(windows))
 
     if err != nil {
-        output<-OperationResult{Response: nil, Err: err}     
-        close(output)
+        a.finishOperation(meta, output, OperationResult{Response: nil, Err: err})
         return
     }
 
     for {
         
+        // each pass from login to book is an attempt
+        meta.Trace.StartAttempt()
+
         // first run pre reservation auth 
-        loginResp, err := a.API.Login(api.LoginParam(params.Login))
+        loginResp, err := a.API.Login(tracedLogin(params.Login, meta.Trace))
         
         if err != nil {
-            output<-OperationResult{Response: nil, Err: err}     
-            close(output)
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err: err})
+            return
+        }
+
+        // a request over several days outlives its earlier
+        // days, so stop asking for windows that have passed
+        liveWindows := futureWindows(windows)
+        if len(liveWindows) == 0 {
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err: api.ErrPastDate})
+            return
+        }
+
+        // drop windows that would overlap a booking made
+        // since we started, if the policy says so
+        reservationWindows, err := a.filterConflictingWindows(meta, liveWindows)
+        if err != nil {
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err: err})
             return
         }
 
@@ -319,42 +692,89 @@ func (a *AppCtx) reserveAtInterval(params ReserveAtIntervalParam, cancel <-chan
         reserveResp, err := a.API.Reserve(
             api.ReserveParam{
                 LoginResp: *loginResp,
-                ReservationTimes: params.ReservationTimes,
+                ReservationTimes: preferredTimes(reservationWindows),
+                TimeWindows: reservationWindows,
                 PartySize: params.PartySize,
+                AltPartySizes: params.AltPartySizes,
                 VenueID: params.VenueID,
                 TableTypes: params.TableTypes,
+                Selector: params.Selector,
+                FeeLimit: params.FeeLimit,
+                ExcludeTableTypes: params.ExcludeTableTypes,
+                DryRun: params.DryRun,
+                ParallelDetails: params.ParallelDetails,
+                // retrying past the last time is pointless
+                Deadline: *lastTime,
+                Logger: meta.Logger,
+                Trace: meta.Trace,
             })
 
         // if there was an error and it wasn't due to every time being
         // taken, then it's an issue we don't know about
-        if err != nil && err != api.ErrNoTable {
-            output<-OperationResult{Response: nil, Err: err}     
-            close(output)
+        if err != nil && !nothingBookable(err) {
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err: err})
             return
         }
-        if err == api.ErrNoTable {
+        if err != nil {
             // see if last time on list is still in the future,
             // since if it isn't there's no point in trying to reserve it
             if lastTime.After(time.Now()) {
+                meta.Logger.Debug("nothing bookable, retrying", "err", err, "interval", params.RepeatInterval)
                 select {
                 case <-time.After(params.RepeatInterval):
                     continue
                 case <-cancel:
-                    output<-OperationResult{Response: nil, Err: ErrCancel}     
-                    close(output)
+                    a.finishOperation(meta, output, OperationResult{Response: nil, Err: ErrCancel})
                     return
                 }
             }
-            output<-OperationResult{Response: nil, Err: api.ErrPastDate}     
-            close(output)
+            // a slot turned down for its fees or by the
+            // service is a better reason than the times passing
+            if err != api.ErrNoTable {
+                a.finishOperation(meta, output, OperationResult{Response: nil, Err: err})
+                return
+            }
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err: api.ErrPastDate})
             return
         }
-        // if there's no error, we succeeded
-        output<-OperationResult{
-            Response: &ReserveAtIntervalResponse{ReservationTime: reserveResp.ReservationTime}, 
-            Err: nil,
+        // a dry run ends at the slot it would have booked
+        if reserveResp.DryRun {
+            a.finishOperation(meta, output, OperationResult{
+                Response: newDryRunResponse(params.VenueID, params.PartySize, *reserveResp),
+                Err: nil,
+            })
+            return
+        }
+        // if there's no error, we succeeded, and in upgrade
+        // mode we go on to look for something better
+        var upgradeErr error
+        if params.Upgrade {
+            var booked api.ReserveResponse
+            booked, upgradeErr = a.huntUpgrades(meta, upgradeHunt{
+                Login: params.Login,
+                VenueID: params.VenueID,
+                Windows: windows,
+                PartySize: bookedPartySize(*reserveResp, params.PartySize),
+                TableTypes: params.TableTypes,
+                Selector: params.Selector,
+                FeeLimit: params.FeeLimit,
+                ExcludeTableTypes: params.ExcludeTableTypes,
+                Interval: params.RepeatInterval,
+            }, *reserveResp, cancel)
+            reserveResp = &booked
         }
-        close(output)
+        a.finishOperation(meta, output, OperationResult{
+            Response: &ReserveAtIntervalResponse{
+                ReservationTime: reserveResp.ReservationTime,
+                ReservationID: reserveResp.ReservationID,
+                VenueID: params.VenueID,
+                VenueName: reserveResp.VenueName,
+                VenueAddress: reserveResp.VenueAddress,
+                PartySize: bookedPartySize(*reserveResp, params.PartySize),
+            }, 
+            Err: nil,
+            UpgradeErr: upgradeErr,
+        })
         return
     }
 }
@@ -367,6 +787,11 @@ Note: Most of this logic should probably be merged
 with the ScheduleReserveAtIntervalOperation func since it's similar logic
 */
 func (a *AppCtx) ScheduleReserveAtTimeOperation(params ReserveAtTimeParam) (int64, error) {
+    // logging in at the service is slow, so fetch the
+    // account reservations before taking the lock
+    accountReservations := a.scheduleReservations(params.Login)
+    a.mu.Lock()
+    defer a.mu.Unlock()
     id := a.idGen
     a.idGen += 1 
     if (params.Login.Email == "" || params.Login.Password == "") {
@@ -376,15 +801,45 @@ func (a *AppCtx) ScheduleReserveAtTimeOperation(params ReserveAtTimeParam) (int6
         params.Login.Email = a.loginInfo.Email
         params.Login.Password = a.loginInfo.Password
     }
+    if params.Upgrade && params.DryRun {
+        return 0, ErrDryUpgrade
+    }
+    if params.EarlyStart < 0 {
+        return 0, ErrEarlyStart
+    }
+    if err := params.Burst.validate(); err != nil {
+        return 0, err
+    }
+    if _, ok := a.API.(api.Canceller); params.Upgrade && !ok {
+        return 0, api.ErrNoCancel
+    }
+    if len(params.TimeWindows) != 0 {
+        params.ReservationTimes = preferredTimes(params.TimeWindows)
+    }
+    windows := reserveWindows(params.ReservationTimes, params.TimeWindows)
+    conflicts, err := a.checkScheduleConflicts(id, windows, accountReservations)
+    if err != nil {
+        return 0, err
+    }
     cancel := make(chan bool)
-    output := make(chan OperationResult)
+    output := make(chan OperationResult, 1)
     a.operations = append(a.operations, Operation{
         ID: id,
         Cancel: cancel,
         Output: output,
         Status: InProgressStatusType,
+        VenueID: params.VenueID,
+        PartySize: params.PartySize,
+        ReservationTimes: params.ReservationTimes,
+        Conflicts: conflicts,
+        DryRun: params.DryRun,
+        Trace: api.NewOperationTrace(id),
+        EarlyStart: params.EarlyStart,
+        delivery: make(chan OperationResult, 1),
     })
-    go a.reserveAtTime(params, cancel, output)
+    meta := a.newOpMeta(a.operations[len(a.operations)-1], accountReservations)
+    meta.Logger.Info("operation scheduled", "kind", "rats", "dry_run", params.DryRun, "conflicts", len(conflicts))
+    go a.reserveAtTime(meta, params, cancel, output)
     return id, nil
 }
 
@@ -394,66 +849,144 @@ Type: Internal App Func
 Purpose: This function is intended to run on a separate thread, and tries making
 a reservation at a given time
 */
-func (a *AppCtx) reserveAtTime(params ReserveAtTimeParam, cancel <-chan bool, output chan<- OperationResult) {
+func (a *AppCtx) reserveAtTime(meta opMeta, params ReserveAtTimeParam, cancel <-chan bool, output chan<- OperationResult) {
  
     // if this date is not in the future, err 
     if params.RequestTime.Before(time.Now().UTC()) {
-        output <- OperationResult{Response: nil, Err: ErrTimeFut}     
-        close(output)
+        a.finishOperation(meta, output, OperationResult{Response: nil, Err: ErrTimeFut})
         return
     }
 
     minAuthTime := a.API.AuthMinExpire()
     authDate := params.RequestTime.Add(-1 * minAuthTime)
+    meta.Logger.Info("waiting for request time", "request_time", params.RequestTime, "auth_time", authDate)
     if (!authDate.Before(time.Now().UTC())) {
         select {
         case <-time.After(time.Until(authDate)):
             break
         case <-cancel:
-            output<- OperationResult{Response: nil, Err:ErrCancel}
-            close(output)
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err:ErrCancel})
             return
         }
     }
 
-    loginResp, err := a.API.Login(api.LoginParam(params.Login))
+    meta.Trace.StartAttempt()
+    loginResp, err := a.API.Login(tracedLogin(params.Login, meta.Trace))
 
     if err != nil {
-       output<- OperationResult{Response: nil, Err:err}
-       close(output)
+       a.finishOperation(meta, output, OperationResult{Response: nil, Err:err})
        return
     }
  
-    // sleep with ability to cancel 
-    select {
-    case <-time.After(time.Until(params.RequestTime)):
-    case <-cancel:
-        output<- OperationResult{Response: nil, Err:ErrCancel}
-        close(output)
+    // the request time is on the service's clock, so
+    // find out where it falls on ours
+    fireAt := params.RequestTime.Add(-1 * params.EarlyStart)
+    if !params.NoClockSync {
+        if !sleepUntil(fireAt.Add(-1 * ClockSyncLead), cancel) {
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err:ErrCancel})
+            return
+        }
+        clock := a.syncClock(meta, params)
+        if clock != nil {
+            fireAt = fireAt.Add(-1 * clock.Offset)
+        }
+    }
+
+    // refresh auth and open connections just before the
+    // request time, so the first request skips the setup
+    warmer, canWarm := a.API.(api.Warmer)
+    warmLead := params.WarmLead
+    if warmLead == 0 {
+        warmLead = DefaultWarmLead
+    }
+    if canWarm && warmLead > 0 {
+        if !sleepUntil(fireAt.Add(-1 * warmLead), cancel) {
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err:ErrCancel})
+            return
+        }
+        meta.Logger.Info("warming up", "lead", warmLead)
+        warmResp, err := warmer.Warm(api.WarmParam{
+            Login: tracedLogin(params.Login, meta.Trace),
+            Conns: warmConns,
+            Logger: meta.Logger,
+        })
+        if err != nil {
+            // the earlier login is still good
+            meta.Logger.Warn("warming up failed", "err", err)
+        } else {
+            loginResp = warmResp
+        }
+    }
+
+    // conflicts are settled ahead of the drop, so
+    // nothing but the requests happens after it
+    windows := reserveWindows(params.ReservationTimes, params.TimeWindows)
+    reservationWindows, err := a.filterConflictingWindows(meta, windows)
+    if err != nil {
+        a.finishOperation(meta, output, OperationResult{Response: nil, Err:err})
         return
     }
 
-    // reserve 
-    reserveResp, err := a.API.Reserve(
+    // sleep with ability to cancel, then reserve
+    meta.Logger.Info("waiting to send", "fire_at", fireAt)
+    reserveResp, err := a.burstReserve(meta, params.Burst, fireAt,
         api.ReserveParam{
             LoginResp: *loginResp,
-            ReservationTimes: params.ReservationTimes,
+            ReservationTimes: preferredTimes(reservationWindows),
+            TimeWindows: reservationWindows,
             PartySize: params.PartySize,
+            AltPartySizes: params.AltPartySizes,
             VenueID: params.VenueID,
             TableTypes: []api.TableType(params.TableTypes),
-        })
+            Selector: params.Selector,
+            FeeLimit: params.FeeLimit,
+            ExcludeTableTypes: params.ExcludeTableTypes,
+            DryRun: params.DryRun,
+            ParallelDetails: params.ParallelDetails,
+            Logger: meta.Logger,
+            Trace: meta.Trace,
+        }, cancel)
 
     if err != nil {
-        output<- OperationResult{Response: nil, Err:err}
-        close(output)
+        a.finishOperation(meta, output, OperationResult{Response: nil, Err:err})
         return
     }
 
+    if reserveResp.DryRun {
+        a.finishOperation(meta, output, OperationResult{
+            Response: newDryRunResponse(params.VenueID, params.PartySize, *reserveResp),
+            Err: nil,
+        })
+        return
+    }
+
+    var upgradeErr error
+    if params.Upgrade {
+        var booked api.ReserveResponse
+        booked, upgradeErr = a.huntUpgrades(meta, upgradeHunt{
+            Login: params.Login,
+            VenueID: params.VenueID,
+            Windows: windows,
+            PartySize: bookedPartySize(*reserveResp, params.PartySize),
+            TableTypes: params.TableTypes,
+            Selector: params.Selector,
+            FeeLimit: params.FeeLimit,
+            ExcludeTableTypes: params.ExcludeTableTypes,
+            Interval: params.UpgradeInterval,
+        }, *reserveResp, cancel)
+        reserveResp = &booked
+    }
     
     // return value if succeeded 
-    returnValue := ReserveAtTimeResponse{ ReservationTime: reserveResp.ReservationTime }
-    output<- OperationResult{Response: returnValue, Err:nil}
-    close(output)
+    returnValue := ReserveAtTimeResponse{
+        ReservationTime: reserveResp.ReservationTime,
+        ReservationID: reserveResp.ReservationID,
+        VenueID: params.VenueID,
+        VenueName: reserveResp.VenueName,
+        VenueAddress: reserveResp.VenueAddress,
+        PartySize: bookedPartySize(*reserveResp, params.PartySize),
+    }
+    a.finishOperation(meta, output, OperationResult{Response: returnValue, Err:nil, UpgradeErr: upgradeErr})
     return
 }
 
@@ -469,6 +1002,8 @@ func (a *AppCtx) Login(params LoginParam) (error) {
     if err != nil {
         return err
     }
+    a.mu.Lock()
+    defer a.mu.Unlock()
     a.loginInfo = params
     return nil
 }
@@ -497,6 +1032,8 @@ from the internal slice of ops, but only does
 this if the op is not in progress
 */
 func (a *AppCtx) CleanOperation(id int64) (error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
     for i, operation := range a.operations {
         if operation.ID == id {
             // update before handling
@@ -523,6 +1060,8 @@ Purpose: This function removes login info
 from the AppCtx if saved from a Login call
 */
 func (a *AppCtx) Logout() (error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
     if (a.loginInfo.Email == "") && (a.loginInfo.Password == "") {
         return ErrNoLogout
     }
@@ -538,6 +1077,8 @@ Purpose: This function stringifies operations
 in a use independent manner
 */
 func (a *AppCtx) OperationsToString() (string, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
     if len(a.operations) == 0 {
         return "", ErrNoOp
     }
@@ -548,23 +1089,84 @@ func (a *AppCtx) OperationsToString() (string, error) {
         if err != nil {
             return "", err
         }
+        operation = a.operations[i]
         // stringify based on op type
         opLstStr += "\tID: " + strconv.FormatInt(operation.ID, 10) + "\n" 
         opLstStr += "\tStatus: " 
         switch operation.Status {
             case InProgressStatusType:
                 opLstStr += "In Progress"
+                if operation.DryRun {
+                    opLstStr += " (dry run)"
+                }
+                if operation.Holding != nil {
+                    time := operation.Holding.ReservationTime
+                    opLstStr += fmt.Sprintf("\n\tHolding: %02d:%02d", time.Hour(), time.Minute())
+                    if spansDays(operation.ReservationTimes) {
+                        opLstStr += time.Format(" on Mon Jan 2")
+                    }
+                    opLstStr += ", hunting for upgrades"
+                }
             case SuccessStatusType:
                 time := operation.Result.Response.Time()
+                if dryRun, ok := operation.Result.Response.(DryRunResponse); ok {
+                    opLstStr += "Would Have Booked\n"
+                    opLstStr += "\tResult: " + dryRun.describe(spansDays(operation.ReservationTimes))
+                    break
+                }
                 opLstStr += "Succeeded\n"
-                opLstStr += fmt.Sprintf("\tResult: %02d:%02d", time.Hour(), time.Minute())
+                if operation.Watch {
+                    opLstStr += "\tResult: watched until " + time.Format("15:04")
+                } else {
+                    opLstStr += fmt.Sprintf("\tResult: %02d:%02d", time.Hour(), time.Minute())
+                    if spansDays(operation.ReservationTimes) {
+                        opLstStr += time.Format(" on Mon Jan 2")
+                    }
+                    reservable, ok := operation.Result.Response.(Reservable)
+                    if ok && reservable.Reservation().PartySize != operation.PartySize {
+                        opLstStr += ", party of " + strconv.Itoa(reservable.Reservation().PartySize)
+                    }
+                }
             case FailStatusType:
                 err := operation.Result.Err.Error()
                 opLstStr += "Failed\n"
                 opLstStr += "\tResult: " + err 
+                // what the service said when it turned the op down
+                var providerErr *api.ProviderError
+                if errors.As(operation.Result.Err, &providerErr) && providerErr.Body != "" {
+                    opLstStr += "\n\tResponse: " + providerErr.Body
+                }
             case CancelStatusType:
                 opLstStr += "Cancelled"
         }
+        if operation.Watch {
+            opLstStr += watchToString(operation)
+        }
+        opLstStr += clockToString(operation)
+        if operation.Result != nil && operation.Result.NotifyErr != nil {
+            opLstStr += "\n\tNotify: " + operation.Result.NotifyErr.Error()
+        }
+        conflicts := operation.Conflicts
+        if operation.Result != nil {
+            conflicts = append(append([]Conflict{}, conflicts...), operation.Result.Conflicts...)
+        }
+        for _, conflict := range conflicts {
+            opLstStr += "\n\tConflict: " + conflict.String()
+        }
+        for _, upgrade := range operation.Upgrades {
+            opLstStr += "\n\tUpgrade: " + upgrade.String()
+        }
+        if operation.Result != nil && operation.Result.UpgradeErr != nil {
+            opLstStr += "\n\tUpgrade Stopped: " + operation.Result.UpgradeErr.Error()
+        }
+        if operation.Result != nil {
+            for _, hookRun := range operation.Result.HookRuns {
+                opLstStr += "\n\tHook: " + hookRun.Command + " exited " + strconv.Itoa(hookRun.ExitCode)
+                if hookRun.Err != nil {
+                    opLstStr += " (" + hookRun.Err.Error() + ")"
+                }
+            }
+        }
         opLstStr += "\n"
         if i != (len(opLstStr) - 1) {
             opLstStr += "\n"
@@ -579,6 +1181,18 @@ Type: External App Func
 Purpose: This function returns the status of an op 
 */
 func (a *AppCtx) OperationStatus(id int64) (OperationStatus, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    return a.operationStatus(id)
+}
+
+/*
+Name: operationStatus
+Type: Internal Func
+Purpose: OperationStatus for callers already
+holding the lock
+*/
+func (a *AppCtx) operationStatus(id int64) (OperationStatus, error) {
     for i, operation := range a.operations {
         if operation.ID == id {
             // update before handling
@@ -0,0 +1,132 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "errors"
+    "runtime"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+var (
+    ErrBurst = errors.New("burst attempts, spacing and window can't be negative")
+)
+
+const (
+    // Time between burst attempts if none is given
+    DefaultBurstSpacing = 250 * time.Millisecond
+    // How long past the last attempt of a burst with no
+    // Window its failed requests are still retried
+    DefaultBurstWindow = 5 * time.Second
+    // How long before a precise wait ends that it
+    // stops sleeping and watches the clock
+    spinLead = 20 * time.Millisecond
+)
+
+/*
+Name: DropBurst
+Type: struct
+Purpose: How a reserve at time op fires at a drop. Drops
+are often a second or two late, so rather than one
+reserve request the op sends up to Attempts of them,
+Spacing apart, until one books or Window has passed
+since the first. The zero value sends one request
+*/
+type DropBurst struct {
+    Attempts    int
+    // DefaultBurstSpacing if zero
+    Spacing     time.Duration
+    // No limit past Attempts if zero, though retries
+    // stop DefaultBurstWindow after the last attempt
+    Window      time.Duration
+}
+
+/*
+Name: validate
+Type: Internal Func
+Purpose: Check a burst's fields make sense
+*/
+func (b DropBurst) validate() (error) {
+    if b.Attempts < 0 || b.Spacing < 0 || b.Window < 0 {
+        return ErrBurst
+    }
+    return nil
+}
+
+/*
+Name: withDefaults
+Type: Internal Func
+Purpose: Fill in the zero fields of a burst
+*/
+func (b DropBurst) withDefaults() (DropBurst) {
+    if b.Attempts == 0 {
+        b.Attempts = 1
+    }
+    if b.Spacing == 0 {
+        b.Spacing = DefaultBurstSpacing
+    }
+    return b
+}
+
+/*
+Name: sleepPrecise
+Type: Internal Func
+Purpose: Wait until t on the local clock more tightly than
+a timer alone, sleeping until just before it and then
+watching the clock. Returns false if the op was cancelled
+during the sleep
+*/
+func sleepPrecise(t time.Time, cancel <-chan bool) (bool) {
+    if !sleepUntil(t.Add(-1 * spinLead), cancel) {
+        return false
+    }
+    for time.Now().Before(t) {
+        runtime.Gosched()
+    }
+    return true
+}
+
+/*
+Name: burstReserve
+Type: Internal App Func
+Purpose: Used by reserve at time op go threads to fire at
+a drop. Sends the first reserve request at fireAt and the
+rest of the burst after it, stopping at the first that
+books or fails for a reason other than nothing being
+bookable. Returns ErrCancel if the op was cancelled
+*/
+func (a *AppCtx) burstReserve(meta opMeta, burst DropBurst, fireAt time.Time, params api.ReserveParam, cancel <-chan bool) (*api.ReserveResponse, error) {
+    burst = burst.withDefaults()
+    // a request retried past the window is too late
+    window := burst.Window
+    if window == 0 {
+        window = time.Duration(burst.Attempts - 1) * burst.Spacing + DefaultBurstWindow
+    }
+    params.Deadline = fireAt.Add(window)
+    if !sleepPrecise(fireAt, cancel) {
+        return nil, ErrCancel
+    }
+    for attempt := 1; ; attempt++ {
+        // the first attempt was started at login
+        if attempt > 1 {
+            meta.Trace.StartAttempt()
+        }
+        meta.Logger.Info("sending reserve request", "attempt", attempt, "of", burst.Attempts)
+        reserveResp, err := a.API.Reserve(params)
+        if err == nil || !nothingBookable(err) || attempt >= burst.Attempts {
+            return reserveResp, err
+        }
+        next := fireAt.Add(time.Duration(attempt) * burst.Spacing)
+        if burst.Window > 0 && next.Sub(fireAt) > burst.Window {
+            meta.Logger.Info("burst window passed", "attempts", attempt)
+            return reserveResp, err
+        }
+        meta.Logger.Debug("nothing bookable yet", "attempt", attempt, "err", err)
+        if !sleepPrecise(next, cancel) {
+            return nil, ErrCancel
+        }
+    }
+}
@@ -0,0 +1,64 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "sync"
+    "testing"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+/*
+Name: deadlineAPI
+Type: Internal Test Struct
+Purpose: An api that never has a table, keeping the
+deadline of every reserve request
+*/
+type deadlineAPI struct {
+    fakeFinder
+    mu          sync.Mutex
+    deadlines   []time.Time
+}
+
+func (d *deadlineAPI) Reserve(params api.ReserveParam) (*api.ReserveResponse, error) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.deadlines = append(d.deadlines, params.Deadline)
+    return nil, api.ErrNoTable
+}
+
+func TestBurstDeadline(t *testing.T) {
+    tests := []struct {
+        name        string
+        burst       DropBurst
+        attempts    int
+        window      time.Duration
+    }{
+        {"single attempt", DropBurst{}, 1, DefaultBurstWindow},
+        {"no window", DropBurst{Attempts: 3, Spacing: time.Millisecond}, 3, 2 * time.Millisecond + DefaultBurstWindow},
+        {"window", DropBurst{Attempts: 2, Spacing: time.Millisecond, Window: time.Second}, 2, time.Second},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            fake := &deadlineAPI{}
+            a := &AppCtx{API: fake}
+            meta := opMeta{ID: 1, Logger: a.logger(), Trace: api.NewTrace()}
+            fireAt := time.Now()
+            _, err := a.burstReserve(meta, tt.burst, fireAt, api.ReserveParam{}, make(chan bool))
+            if !nothingBookable(err) {
+                t.Fatalf("err = %v, want nothing bookable", err)
+            }
+            if len(fake.deadlines) != tt.attempts {
+                t.Fatalf("attempts = %d, want %d", len(fake.deadlines), tt.attempts)
+            }
+            for i, deadline := range fake.deadlines {
+                if !deadline.Equal(fireAt.Add(tt.window)) {
+                    t.Errorf("attempt %d deadline = fire time + %v, want + %v", i + 1, deadline.Sub(fireAt), tt.window)
+                }
+            }
+        })
+    }
+}
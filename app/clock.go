@@ -0,0 +1,121 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "strconv"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+const (
+    // Time before a reserve at time op's request time
+    // that it measures the service's clock
+    ClockSyncLead = 30 * time.Second
+    // Time an NTP query may take
+    ntpTimeout = 3 * time.Second
+)
+
+/*
+Name: sleepUntil
+Type: Internal Func
+Purpose: Wait until t on the local clock, returning
+false if the op was cancelled first
+*/
+func sleepUntil(t time.Time, cancel <-chan bool) (bool) {
+    select {
+    case <-time.After(time.Until(t)):
+        return true
+    case <-cancel:
+        return false
+    }
+}
+
+/*
+Name: syncClock
+Type: Internal App Func
+Purpose: Used by reserve at time op go threads to measure
+how far the service's clock is ahead of ours, from its
+Date headers and the NTP server if one is set. Returns
+nil if it couldn't be measured, in which case the op
+times its request on the local clock
+*/
+func (a *AppCtx) syncClock(meta opMeta, params ReserveAtTimeParam) (*api.ClockOffset) {
+    var clock *api.ClockOffset
+    var clockErr error
+    if reader, ok := a.API.(api.ClockReader); ok {
+        clock, clockErr = reader.ServerClock(api.ClockParam{Logger: meta.Logger})
+    }
+    if params.NTPServer != "" {
+        ntpClock, err := api.QueryNTP(params.NTPServer, ntpTimeout)
+        switch {
+        case err != nil:
+            meta.Logger.Warn("querying NTP failed", "server", params.NTPServer, "err", err)
+            if clock == nil && clockErr == nil {
+                clockErr = err
+            }
+        case clock == nil:
+            // the service is assumed to keep true time
+            clock = ntpClock
+            clockErr = nil
+        default:
+            combined := clock.Combine(*ntpClock)
+            clock = &combined
+        }
+    }
+    if clockErr != nil {
+        meta.Logger.Warn("measuring the service's clock failed", "err", clockErr)
+    }
+    if clock != nil {
+        meta.Logger.Info("measured clock offset", "offset", clock.Offset, "uncertainty", clock.Uncertainty, "source", clock.Source)
+    }
+    a.recordClock(meta.ID, clock, clockErr)
+    return clock
+}
+
+/*
+Name: recordClock
+Type: Internal App Func
+Purpose: Used by op go threads to publish the clock
+offset they measured, or why they couldn't
+*/
+func (a *AppCtx) recordClock(id int64, clock *api.ClockOffset, err error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    for i, operation := range a.operations {
+        if operation.ID == id {
+            a.operations[i].Clock = clock
+            a.operations[i].ClockErr = api.RedactError(err)
+            return
+        }
+    }
+}
+
+/*
+Name: clockToString
+Type: Internal Func
+Purpose: Describe the clock offset an op measured, for
+the operations listing
+*/
+func clockToString(operation Operation) (string) {
+    if operation.Clock == nil {
+        if operation.ClockErr != nil {
+            return "\n\tClock: not measured, " + operation.ClockErr.Error()
+        }
+        return ""
+    }
+    clock := operation.Clock
+    sign := "+"
+    if clock.Offset < 0 {
+        sign = "-"
+    }
+    str := "\n\tClock: service " + sign + clock.Offset.Abs().Round(time.Millisecond).String()
+    str += " ± " + clock.Uncertainty.Round(time.Millisecond).String()
+    str += " (" + clock.Source + ", " + strconv.Itoa(clock.Samples) + " samples)"
+    if operation.EarlyStart > 0 {
+        str += ", starting " + operation.EarlyStart.String() + " early"
+    }
+    return str
+}
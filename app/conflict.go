@@ -0,0 +1,314 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "errors"
+    "strconv"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+var (
+    ErrConflict = errors.New("reservation times conflict with existing bookings or operations")
+)
+
+// ConflictPolicy type is an enum, only use with next const def types
+type ConflictPolicy int
+
+const (
+    // the zero value warns, so conflicts are shown by default
+    WarnConflictPolicy ConflictPolicy = iota
+    RefuseConflictPolicy
+    IgnoreConflictPolicy
+)
+
+const (
+    // Two reservations closer than this conflict by default
+    DefaultConflictBuffer = 2 * time.Hour
+)
+
+/*
+Name: Conflict
+Type: struct
+Purpose: Describe one overlap between a time an operation
+wants or booked and another booking or pending operation
+*/
+type Conflict struct {
+    // The time of ours that overlaps
+    Time            time.Time
+    // The time of the other booking or operation
+    OtherTime       time.Time
+    // ID of the other operation, -1 for account reservations
+    OperationID     int64
+    // Confirmation id of the other booking, if booked
+    ReservationID   string
+    VenueID         int64
+    // Set if the other side is a pending op's candidate time
+    Pending         bool
+}
+
+/*
+Name: String
+Type: Stringify Func
+Purpose: Provide a default string representation of
+a conflict amongst consumers of this layer
+*/
+func (c Conflict) String() (string) {
+    str := c.Time.Format("2006-01-02 15:04") + " overlaps "
+    switch {
+    case c.Pending:
+        str += "pending operation " + strconv.FormatInt(c.OperationID, 10)
+    case c.OperationID >= 0:
+        str += "booking by operation " + strconv.FormatInt(c.OperationID, 10)
+    default:
+        str += "account reservation " + c.ReservationID
+    }
+    str += " at venue " + strconv.FormatInt(c.VenueID, 10)
+    str += " for " + c.OtherTime.Format("2006-01-02 15:04")
+    return str
+}
+
+/*
+Name: SetConflictPolicy
+Type: External App Func
+Purpose: Set how operations scheduled after this call
+treat reservations closer together than the buffer
+*/
+func (a *AppCtx) SetConflictPolicy(policy ConflictPolicy, buffer time.Duration) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.conflictPolicy = policy
+    a.conflictBuffer = buffer
+}
+
+/*
+Name: getConflictBuffer
+Type: Internal App Func
+Purpose: Return the conflict buffer, or the default if
+none was set
+*/
+func (a *AppCtx) getConflictBuffer() (time.Duration) {
+    if a.conflictBuffer <= 0 {
+        return DefaultConflictBuffer
+    }
+    return a.conflictBuffer
+}
+
+/*
+Name: overlaps
+Type: Internal Func
+Purpose: Report whether two reservation times are closer
+together than the buffer
+*/
+func overlaps(t1 time.Time, t2 time.Time, buffer time.Duration) (bool) {
+    diff := t1.Sub(t2)
+    if diff < 0 {
+        diff = -diff
+    }
+    return diff < buffer
+}
+
+/*
+Name: clampToWindow
+Type: Internal Func
+Purpose: Find the time in a window closest to another
+time, which is where the window comes nearest to it
+*/
+func clampToWindow(window api.TimeWindow, t time.Time) (time.Time) {
+    if t.Before(window.Start) {
+        return window.Start
+    }
+    if t.After(window.End) {
+        return window.End
+    }
+    return t
+}
+
+/*
+Name: findConflicts
+Type: Internal App Func
+Purpose: Compare windows against the bookings of other ops,
+the account reservations given, and, if includePending is
+set, the candidate times of other in progress ops, leaving
+out dry runs since they never book. The time
+recorded on a conflict is where the window comes nearest
+the other time
+Note: Must be called with the lock held
+*/
+func (a *AppCtx) findConflicts(selfID int64, windows []api.TimeWindow, accountReservations []Reservation, buffer time.Duration, includePending bool) ([]Conflict) {
+    conflicts := []Conflict{}
+    for _, operation := range a.operations {
+        if operation.ID == selfID {
+            continue
+        }
+        // pull in any result sitting on the op's channel
+        a.updateOperationResult(operation.ID)
+    }
+    for _, window := range windows {
+        for _, operation := range a.operations {
+            if operation.ID == selfID {
+                continue
+            }
+            reservation, ok := a.operationReservation(operation.ID)
+            if ok {
+                t := clampToWindow(window, reservation.ReservationTime)
+                if overlaps(t, reservation.ReservationTime, buffer) {
+                    conflicts = append(conflicts, Conflict{
+                        Time: t,
+                        OtherTime: reservation.ReservationTime,
+                        OperationID: operation.ID,
+                        ReservationID: reservation.ReservationID,
+                        VenueID: reservation.VenueID,
+                    })
+                }
+                continue
+            }
+            if !includePending || operation.Status != InProgressStatusType || operation.DryRun {
+                continue
+            }
+            for _, otherTime := range operation.ReservationTimes {
+                t := clampToWindow(window, otherTime)
+                if overlaps(t, otherTime, buffer) {
+                    conflicts = append(conflicts, Conflict{
+                        Time: t,
+                        OtherTime: otherTime,
+                        OperationID: operation.ID,
+                        VenueID: operation.VenueID,
+                        Pending: true,
+                    })
+                    break
+                }
+            }
+        }
+        for _, reservation := range accountReservations {
+            t := clampToWindow(window, reservation.ReservationTime)
+            if overlaps(t, reservation.ReservationTime, buffer) {
+                conflicts = append(conflicts, Conflict{
+                    Time: t,
+                    OtherTime: reservation.ReservationTime,
+                    OperationID: -1,
+                    ReservationID: reservation.ReservationID,
+                    VenueID: reservation.VenueID,
+                })
+            }
+        }
+    }
+    return conflicts
+}
+
+/*
+Name: scheduleReservations
+Type: Internal App Func
+Purpose: Fetch the account reservations an op about to be
+scheduled is checked against, nil under the ignore policy.
+Conflict checks are best effort, so an account we can't
+list is treated as one with no reservations
+Note: Logs in at the external service, so must be called
+without the lock held
+*/
+func (a *AppCtx) scheduleReservations(login LoginParam) ([]Reservation) {
+    a.mu.Lock()
+    policy := a.conflictPolicy
+    login, err := a.loginDefaults(login)
+    a.mu.Unlock()
+    if policy == IgnoreConflictPolicy || err != nil {
+        return nil
+    }
+    accountReservations, _ := a.providerReservations(login)
+    return accountReservations
+}
+
+/*
+Name: checkScheduleConflicts
+Type: Internal App Func
+Purpose: Check the windows of an op about to be scheduled
+against other ops and the account reservations fetched by
+scheduleReservations. Under the refuse policy any conflict
+is an error.
+Note: Must be called with the lock held
+*/
+func (a *AppCtx) checkScheduleConflicts(id int64, windows []api.TimeWindow, accountReservations []Reservation) ([]Conflict, error) {
+    if a.conflictPolicy == IgnoreConflictPolicy {
+        return nil, nil
+    }
+    conflicts := a.findConflicts(id, windows, accountReservations, a.getConflictBuffer(), true)
+    if len(conflicts) != 0 && a.conflictPolicy == RefuseConflictPolicy {
+        return conflicts, ErrConflict
+    }
+    return conflicts, nil
+}
+
+/*
+Name: filterConflictingWindows
+Type: Internal App Func
+Purpose: Used by op go threads right before reserving.
+Under the refuse policy, drop the windows which come near
+an existing booking, failing if none are left
+*/
+func (a *AppCtx) filterConflictingWindows(meta opMeta, windows []api.TimeWindow) ([]api.TimeWindow, error) {
+    if meta.ConflictPolicy != RefuseConflictPolicy {
+        return windows, nil
+    }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    filtered := []api.TimeWindow{}
+    for _, window := range windows {
+        conflicts := a.findConflicts(meta.ID, []api.TimeWindow{window}, meta.AccountReservations, meta.ConflictBuffer, false)
+        if len(conflicts) == 0 {
+            filtered = append(filtered, window)
+        }
+    }
+    if len(filtered) == 0 {
+        return nil, ErrConflict
+    }
+    return filtered, nil
+}
+
+/*
+Name: bookingConflicts
+Type: Internal App Func
+Purpose: Used by op go threads after a successful booking
+to record what the new booking overlaps
+Note: This only warns. The booking is already made by the
+time it is checked, and under the refuse policy the windows
+that could conflict were dropped before reserving, see
+'filterConflictingWindows'. What is left is a conflict with
+an op that booked while this one was reserving, which is
+recorded on the result for the user to resolve
+*/
+func (a *AppCtx) bookingConflicts(meta opMeta, bookedTime time.Time) ([]Conflict) {
+    if meta.ConflictPolicy == IgnoreConflictPolicy {
+        return nil
+    }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    return a.findConflicts(meta.ID, []api.TimeWindow{api.PointWindow(bookedTime)}, meta.AccountReservations, meta.ConflictBuffer, true)
+}
+
+/*
+Name: OperationConflicts
+Type: External App Func
+Purpose: Return the conflicts found for an op, both when
+it was scheduled and when it booked
+*/
+func (a *AppCtx) OperationConflicts(id int64) ([]Conflict, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    err := a.updateOperationResult(id)
+    if err != nil {
+        return nil, err
+    }
+    for _, operation := range a.operations {
+        if operation.ID == id {
+            conflicts := append([]Conflict{}, operation.Conflicts...)
+            if operation.Result != nil {
+                conflicts = append(conflicts, operation.Result.Conflicts...)
+            }
+            return conflicts, nil
+        }
+    }
+    return nil, ErrIdOp
+}
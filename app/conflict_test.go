@@ -0,0 +1,46 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "testing"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+func TestFindConflictsPending(t *testing.T) {
+    at := time.Date(2026, 11, 2, 19, 0, 0, 0, time.UTC)
+    a := &AppCtx{}
+    a.operations = []Operation{
+        {ID: 1, Status: InProgressStatusType, VenueID: 10, ReservationTimes: []time.Time{at}},
+        {ID: 2, Status: InProgressStatusType, VenueID: 20, ReservationTimes: []time.Time{at}, DryRun: true},
+        {ID: 3, Status: InProgressStatusType, VenueID: 30, ReservationTimes: []time.Time{at.Add(5 * time.Hour)}},
+        {ID: 4, Status: CancelStatusType, VenueID: 40, ReservationTimes: []time.Time{at}},
+    }
+    windows := []api.TimeWindow{{Start: at.Add(-30 * time.Minute), End: at.Add(30 * time.Minute)}}
+    tests := []struct {
+        name            string
+        includePending  bool
+        wantIDs         []int64
+    }{
+        {"pending", true, []int64{1}},
+        {"bookings only", false, nil},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a.mu.Lock()
+            conflicts := a.findConflicts(5, windows, nil, 2 * time.Hour, tt.includePending)
+            a.mu.Unlock()
+            if len(conflicts) != len(tt.wantIDs) {
+                t.Fatalf("conflicts = %v, want ops %v", conflicts, tt.wantIDs)
+            }
+            for i, conflict := range conflicts {
+                if conflict.OperationID != tt.wantIDs[i] || !conflict.Pending {
+                    t.Errorf("conflict %d = %v, want pending op %d", i, conflict, tt.wantIDs[i])
+                }
+            }
+        })
+    }
+}
@@ -26,7 +26,28 @@ Public API:
               specifying the date and times to reserve at, the 
               restaurant to reserve at, party size, and an interval
               to retry the reservation on and returns the id of the
-              running operation on success
+              running operation on success. 'TimeWindows' may take
+              the place of the reservation times, each a range of
+              acceptable times with a preference policy. The
+              times and windows may fall on several days, and
+              windows which have passed are dropped as the
+              operation retries. 'AltPartySizes' are tried in
+              order if nothing is open for 'PartySize', and the
+              response's 'PartySize' is the size booked. 'Selector'
+              sets the order open slots are tried in, and 
+              'FeeLimit' the fees accepted. A slot turned down for
+              its fees is retried like a taken one, and is the
+              error the operation fails with if the times pass. With 
+              'Upgrade' set, the
+              operation keeps hunting for a better slot after it
+              books, see 'Upgrade Mode' below. With 'DryRun'
+              set, the operation stops short of booking and
+              succeeds with a DryRunResponse describing the slot
+              it would have booked, sent to the notifiers and
+              hooks as an operation.would_have_booked Event. A
+              dry run can't be combined with 'Upgrade'.
+              'ParallelDetails' is passed on to the api, see
+              api.ReserveParam
 
         2. ScheduleReserveAtTimeOperation(ReserveAtTimeParam)(int64, error)
 
@@ -35,7 +56,24 @@ Public API:
               restaurant to reserve at, party size, and a time
               to send the request to the external API at. This time
               must be in UTC. The func returns an id of the running 
-              operation on success
+              operation on success. 'Upgrade' works as in 1, polling
+              every 'UpgradeInterval', and so do 'DryRun' and
+              'ParallelDetails'.
+              If the api implements api.Warmer, the op warms up
+              'WarmLead' before the request time, DefaultWarmLead
+              if unset, logging in again and opening connections
+              so the requests at the request time skip setup.
+              'RequestTime' is on the service's clock: unless
+              'NoClockSync' is set, ClockSyncLead before it the
+              op measures the service's clock offset if the api
+              implements api.ClockReader, and with 'NTPServer'
+              an NTP offset too, and fires on the corrected time,
+              'EarlyStart' early. The offset is kept on the op
+              and shown by OperationsToString. 'Burst' fires
+              a DropBurst: the first request goes out on a
+              precise wait, and while nothing is bookable more
+              follow, 'Spacing' apart, up to 'Attempts' of them
+              or until 'Window' has passed
 
         3. CancelOperation(int64)(error) 
 
@@ -76,6 +114,145 @@ Public API:
             - Description: Returns status corresponding to
               operation
 
+        10. AddNotifier(Notifier) 
+
+            - Description: Subscribes a Notifier to the outcome
+              (success, failure or cancellation) of every operation
+              scheduled afterwards. The WebhookNotifier provided in
+              this pkg posts each outcome as a JSON Event to a list
+              of urls, signing the body with HMAC-SHA256 when given
+              a secret and retrying deliveries that got no response,
+              a 5xx or a 429, and the
+              EmailNotifier sends each outcome as a templated plain
+              text email through an SMTP server
+
+        11. TestNotifiers()(error) 
+
+            - Description: Sends a test Event through every
+              subscribed Notifier
+
+        12. AddHook(Hook)(error) 
+
+            - Description: Registers a shell command to run on the
+              outcome of every operation scheduled afterwards. The
+              event is passed as RESOLVED_* environment variables
+              and as JSON on stdin, and the exit code and captured
+              output of each run are recorded on the operation result
+
+        13. OperationHookRuns(int64)([]HookRun, error) 
+
+            - Description: Returns the hook runs recorded on the
+              operation with the given id, empty until its hooks
+              have run, which is shortly after it finishes
+
+        14. OperationReservations([]int64)([]Reservation, error) 
+
+            - Description: Returns the reservations booked by the
+              successful operations with the given ids, or by all
+              successful operations if no ids are given
+
+        15. ProviderReservations(LoginParam)([]Reservation, error) 
+
+            - Description: Returns the upcoming reservations on the
+              account, if the api implements api.ReservationLister.
+              Empty login params fall back to the login defaults
+
+        16. WriteICS(io.Writer, []Reservation)(error) 
+
+            - Description: Writes reservations as an RFC 5545 
+              iCalendar stream, with the venue name, address, 
+              party size(if known) and confirmation id on each event
+
+        17. SetConflictPolicy(ConflictPolicy, time.Duration) 
+
+            - Description: Sets how operations scheduled afterwards
+              treat reservation times closer together than the given
+              buffer. WarnConflictPolicy(the default) records the
+              overlaps on the operation, RefuseConflictPolicy makes
+              scheduling fail with ErrConflict and skips times that
+              overlap a booking, and IgnoreConflictPolicy turns 
+              checking off
+
+        18. OperationConflicts(int64)([]Conflict, error) 
+
+            - Description: Returns the overlaps found for an
+              operation when it was scheduled and when it booked.
+              Overlaps found after booking are only warnings under
+              every policy, since the booking is already made
+
+        19. ScheduleWatchOperation(WatchParam)(int64, error)
+
+            - Description: Schedules an operation which polls the
+              open slots at a venue for a time window on a day,
+              if the api implements api.Finder. It never books,
+              each slot that opens or closes between polls is 
+              sent to the notifiers and hooks as a slot.appeared
+              or slot.disappeared Event. Events are sent in the
+              background and in order, so a slow notifier never
+              holds up a poll. It runs until the window
+              passes, when it sends a watch.ended Event, or it is
+              cancelled. The window must fall on one day and the
+              'RepeatInterval' must be positive. It logs in once,
+              and again only when the login may have expired
+
+        20. OperationSlotChanges(int64)([]SlotChange, []api.Slot, error)
+
+            - Description: Returns the slot changes a watch
+              operation has seen so far and the slots open at
+              its latest poll. A change's NotifyErr and HookRuns
+              are filled in once its event has been delivered
+
+        21. SeatingTypes(SeatingParam)(*SeatingResponse, error)
+
+            - Description: Lists the seating types a venue has
+              open on a day, as the service names them, with the
+              number of slots and the first and last time of
+              each, if the api implements api.Finder. These are
+              the names the 'TableTypes' and 'ExcludeTableTypes'
+              patterns of 1 and 2 are matched against
+
+        22. OperationTrace(int64)([]api.Span, error)
+
+            - Description: Returns the timing of every request an
+              operation has made, by attempt, with DNS, connect,
+              TLS and time to first byte broken out, and any
+              retries of the request. Only the latest 
+              api.MaxTraceSpans requests are kept
+
+        23. ExportOperationTrace(int64, io.Writer)(error)
+
+            - Description: Writes the trace of an operation as
+              OpenTelemetry OTLP/JSON, with a span per attempt
+              and a child span per request
+
+        The AppCtx logs to its 'Logger' field, a log/slog logger,
+        and logs nothing if it is unset. Each operation tags it
+        with its op_id and venue_id and hands it to the api on
+        every request, so the api's logs can be traced back to
+        the operation. NewLogHandler makes a LogHandler, a slog
+        handler whose level, output and text or JSON format can
+        be changed while loggers built on it are in use.
+
+        Secrets are kept out of what the app hands back. Operation
+        errors, event errors, hook output and the confirmation ids
+        in ICS exports are all passed through api.Redact, and
+        errors wrap the original so errors.Is still works on
+        them. The logger given to the AppCtx should be wrapped
+        with api.NewRedactHandler. The operations listing shows
+        the start of the service's response under an operation
+        that failed with an api.ProviderError.
+
+        Finished operations are counted by outcome event in the
+        metrics.Default registry. RegisterMetrics adds a gauge of
+        the AppCtx's operations by status to a registry, so the
+        registry can be served with metrics.Serve.
+
+        Failed requests are retried by the api's client, see 
+        api.RetryTransport. Reserve operations stop retrying at
+        their last reservation time, and reserve at time
+        operations at the end of their burst window, or
+        DefaultBurstWindow after their last attempt if it has
+        none.
 
 **********************************************************************
 
@@ -122,6 +299,49 @@ App Internals:
         channels activates. In the cancel case, we report an error of
         cancelled, and in the time.After() case, we continue execution.
     
+    How an Operation Finishes:
+
+        Every operation go thread must end by calling the internal
+        method 'AppCtx.finishOperation' with the opMeta snapshot it
+        was started with. This writes the result to the 'Output'
+        channel first, then in the background hands an Event to the
+        notifiers that were subscribed when the operation was
+        scheduled, runs the registered hooks, and sends the result
+        again with any delivery failures and the hook runs on the
+        operation's 'delivery' channel, so a dead webhook or a slow
+        hook never holds the result back. Both channels are
+        buffered, so a cancelled operation, whose result nobody is
+        waiting on, can still exit.
+
+    Upgrade Mode:
+
+        An operation in upgrade mode does not finish when it books.
+        It calls the internal method 'AppCtx.huntUpgrades', which
+        polls the api with only the table type and time pairs that
+        rank above the held booking, and publishes the held booking
+        on the operation as 'Holding'. Only once a better booking is
+        confirmed does it cancel the earlier one through the optional
+        api.Canceller interface, which scheduling requires. If that
+        cancel fails the hunt stops so bookings can't pile up, and the
+        upgrade is recorded with both bookings held. Cancelling the
+        operation ends the hunt, and the operation succeeds with the
+        booking it holds. What counts as better follows the operation's
+        'Selector', compared on table type and window rank alone, so
+        a booking is never traded for a slot in the same window. The
+        hunt keeps to the party size of the
+        first booking, so a booking made at an alternate size is 
+        never traded for one at another size.
+
+    Locking:
+
+        Operation go threads read the operations slice to check
+        for conflicting bookings, so every field of AppCtx after
+        'API' is guarded by the 'mu' mutex. External funcs take 
+        the lock, and internal funcs which expect it to be held
+        say so in their comment. Never hold the lock while waiting
+        on an operation go thread, which is why cancelling closes
+        the 'Cancel' channel rather than sending on it.
+
     Writing Code For App Layer:
 
         If you are writing an internal function for the app layer, 
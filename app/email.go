@@ -0,0 +1,207 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "bytes"
+    "crypto/tls"
+    "errors"
+    "net"
+    "net/smtp"
+    "strings"
+    "text/template"
+    "time"
+)
+
+var (
+    ErrNoRecipients = errors.New("no email recipients provided")
+    ErrNoStartTLS = errors.New("smtp server does not support STARTTLS")
+)
+
+const (
+    DefaultEmailSubject = `Resolved: operation {{.OperationID}} {{.Type}}`
+    DefaultEmailBody = `Operation {{.OperationID}} reported {{.Type}} at {{.Timestamp.Format "2006-01-02 15:04:05 MST"}}.
+
+Venue ID: {{.VenueID}}
+Party Size: {{.PartySize}}
+{{- if .ReservationTime}}
+Reserved For: {{.ReservationTime.Format "2006-01-02 15:04"}}
+{{- end}}
+{{- if .SlotTime}}
+Slot: {{.SlotTime.Format "2006-01-02 15:04"}} {{.TableType}}
+{{- end}}
+{{- if .Error}}
+Error: {{.Error}}
+{{- end}}
+`
+)
+
+/*
+Name: EmailNotifier
+Type: Notifier struct
+Purpose: Send events as plain text emails through an
+SMTP server.
+Note: Addr is in host:port form. The connection is
+upgraded with STARTTLS whenever the server offers it,
+and if RequireTLS is set we refuse to send over a plain
+connection. Subject and Body are text/template strings
+executed against the Event, empty values fall back to
+DefaultEmailSubject and DefaultEmailBody
+*/
+type EmailNotifier struct {
+    Addr        string
+    Username    string
+    Password    string
+    From        string
+    To          []string
+    Subject     string
+    Body        string
+    RequireTLS  bool
+    Timeout     time.Duration
+}
+
+/*
+Name: NewEmailNotifier
+Type: External Func
+Purpose: Provide an email notifier which requires
+TLS and uses the default templates
+*/
+func NewEmailNotifier(addr string, from string, to []string) (*EmailNotifier) {
+    return &EmailNotifier{
+        Addr: addr,
+        From: from,
+        To: to,
+        RequireTLS: true,
+        Timeout: 30 * time.Second,
+    }
+}
+
+/*
+Name: render
+Type: Internal Func
+Purpose: Execute a notifier template against an event,
+falling back to a default template if none was given
+*/
+func render(name string, text string, fallback string, e Event) (string, error) {
+    if text == "" {
+        text = fallback
+    }
+    tmpl, err := template.New(name).Parse(text)
+    if err != nil {
+        return "", err
+    }
+    var out bytes.Buffer
+    err = tmpl.Execute(&out, e)
+    if err != nil {
+        return "", err
+    }
+    return out.String(), nil
+}
+
+/*
+Name: buildMessage
+Type: Internal Func
+Purpose: Build the RFC 5322 message for an event
+*/
+func (m *EmailNotifier) buildMessage(e Event) ([]byte, error) {
+    subject, err := render("subject", m.Subject, DefaultEmailSubject, e)
+    if err != nil {
+        return nil, err
+    }
+    body, err := render("body", m.Body, DefaultEmailBody, e)
+    if err != nil {
+        return nil, err
+    }
+    // headers can't span lines, so flatten the subject
+    subject = strings.Join(strings.Fields(subject), " ")
+    // SMTP requires CRLF line endings in the message
+    body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+
+    var msg bytes.Buffer
+    msg.WriteString("From: " + m.From + "\r\n")
+    msg.WriteString("To: " + strings.Join(m.To, ", ") + "\r\n")
+    msg.WriteString("Subject: " + subject + "\r\n")
+    msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+    msg.WriteString("MIME-Version: 1.0\r\n")
+    msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+    msg.WriteString("\r\n")
+    msg.WriteString(body)
+    return msg.Bytes(), nil
+}
+
+/*
+Name: Notify
+Type: interface method
+Purpose: Satisfy the Notifier interface
+*/
+func (m *EmailNotifier) Notify(e Event) (error) {
+    if len(m.To) == 0 {
+        return ErrNoRecipients
+    }
+    msg, err := m.buildMessage(e)
+    if err != nil {
+        return err
+    }
+
+    host, _, err := net.SplitHostPort(m.Addr)
+    if err != nil {
+        return err
+    }
+    conn, err := net.DialTimeout("tcp", m.Addr, m.Timeout)
+    if err != nil {
+        return err
+    }
+    if m.Timeout > 0 {
+        conn.SetDeadline(time.Now().Add(m.Timeout))
+    }
+    client, err := smtp.NewClient(conn, host)
+    if err != nil {
+        conn.Close()
+        return err
+    }
+    defer client.Close()
+
+    if ok, _ := client.Extension("STARTTLS"); ok {
+        err = client.StartTLS(&tls.Config{ServerName: host})
+        if err != nil {
+            return err
+        }
+    } else if m.RequireTLS {
+        return ErrNoStartTLS
+    }
+
+    if m.Username != "" {
+        // smtp.PlainAuth itself refuses to send credentials
+        // over an unencrypted connection to a remote host
+        err = client.Auth(smtp.PlainAuth("", m.Username, m.Password, host))
+        if err != nil {
+            return err
+        }
+    }
+
+    err = client.Mail(m.From)
+    if err != nil {
+        return err
+    }
+    for _, to := range m.To {
+        err = client.Rcpt(to)
+        if err != nil {
+            return err
+        }
+    }
+    writer, err := client.Data()
+    if err != nil {
+        return err
+    }
+    _, err = writer.Write(msg)
+    if err != nil {
+        return err
+    }
+    err = writer.Close()
+    if err != nil {
+        return err
+    }
+    return client.Quit()
+}
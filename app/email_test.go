@@ -0,0 +1,249 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "bufio"
+    "encoding/base64"
+    "errors"
+    "net"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+)
+
+/*
+Name: fakeSMTP
+Type: Internal Test Struct
+Purpose: An SMTP server just good enough for net/smtp,
+keeping the commands and messages it was sent. It never
+offers STARTTLS
+*/
+type fakeSMTP struct {
+    listener    net.Listener
+    mu          sync.Mutex
+    commands    []string
+    messages    []string
+}
+
+func newFakeSMTP(t *testing.T) (*fakeSMTP) {
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatalf("listen: %v", err)
+    }
+    s := &fakeSMTP{listener: listener}
+    t.Cleanup(func() { listener.Close() })
+    go s.serve()
+    return s
+}
+
+func (s *fakeSMTP) serve() {
+    for {
+        conn, err := s.listener.Accept()
+        if err != nil {
+            return
+        }
+        go s.handle(conn)
+    }
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+    defer conn.Close()
+    reader := bufio.NewReader(conn)
+    reply := func(line string) {
+        conn.Write([]byte(line + "\r\n"))
+    }
+    reply("220 fake ESMTP")
+    for {
+        line, err := reader.ReadString('\n')
+        if err != nil {
+            return
+        }
+        line = strings.TrimRight(line, "\r\n")
+        s.mu.Lock()
+        s.commands = append(s.commands, line)
+        s.mu.Unlock()
+        verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+        switch verb {
+        case "EHLO":
+            reply("250-fake")
+            reply("250 AUTH PLAIN")
+        case "AUTH":
+            reply("235 ok")
+        case "MAIL", "RCPT", "RSET", "NOOP":
+            reply("250 ok")
+        case "DATA":
+            reply("354 go ahead")
+            var msg strings.Builder
+            for {
+                data, err := reader.ReadString('\n')
+                if err != nil {
+                    return
+                }
+                if data == ".\r\n" {
+                    break
+                }
+                msg.WriteString(data)
+            }
+            s.mu.Lock()
+            s.messages = append(s.messages, msg.String())
+            s.mu.Unlock()
+            reply("250 queued")
+        case "QUIT":
+            reply("221 bye")
+            return
+        default:
+            reply("502 not implemented")
+        }
+    }
+}
+
+func (s *fakeSMTP) sent() ([]string, []string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return append([]string{}, s.commands...), append([]string{}, s.messages...)
+}
+
+func testEmailNotifier(s *fakeSMTP) (*EmailNotifier) {
+    m := NewEmailNotifier(s.listener.Addr().String(), "resolved@example.com", []string{"a@example.com", "b@example.com"})
+    m.RequireTLS = false
+    m.Timeout = 5 * time.Second
+    return m
+}
+
+func hasCommand(commands []string, prefix string) (bool) {
+    for _, command := range commands {
+        if strings.HasPrefix(command, prefix) {
+            return true
+        }
+    }
+    return false
+}
+
+func TestEmailDelivery(t *testing.T) {
+    s := newFakeSMTP(t)
+    m := testEmailNotifier(s)
+    e := Event{
+        Type: SuccessEventType,
+        OperationID: 12,
+        Timestamp: time.Date(2026, 10, 18, 19, 30, 0, 0, time.UTC),
+        VenueID: 5286,
+        PartySize: 2,
+    }
+    err := m.Notify(e)
+    if err != nil {
+        t.Fatalf("Notify: %v", err)
+    }
+    commands, messages := s.sent()
+    for _, want := range []string{"MAIL FROM:<resolved@example.com>", "RCPT TO:<a@example.com>", "RCPT TO:<b@example.com>", "QUIT"} {
+        if !hasCommand(commands, want) {
+            t.Errorf("missing command %q in %q", want, commands)
+        }
+    }
+    if hasCommand(commands, "AUTH") {
+        t.Error("authenticated without a username")
+    }
+    if len(messages) != 1 {
+        t.Fatalf("messages = %d, want 1", len(messages))
+    }
+    msg := messages[0]
+    for _, want := range []string{
+        "From: resolved@example.com\r\n",
+        "To: a@example.com, b@example.com\r\n",
+        "Subject: Resolved: operation 12 " + string(SuccessEventType) + "\r\n",
+        "Venue ID: 5286\r\n",
+        "Party Size: 2\r\n",
+    } {
+        if !strings.Contains(msg, want) {
+            t.Errorf("message missing %q:\n%s", want, msg)
+        }
+    }
+    if strings.Contains(strings.ReplaceAll(msg, "\r\n", ""), "\n") {
+        t.Error("message has bare LF line endings")
+    }
+}
+
+func TestEmailAuth(t *testing.T) {
+    s := newFakeSMTP(t)
+    m := testEmailNotifier(s)
+    m.Username = "user"
+    m.Password = "smtp-password"
+    // PlainAuth only sends credentials in the clear to localhost
+    _, port, _ := net.SplitHostPort(m.Addr)
+    m.Addr = "localhost:" + port
+    err := m.Notify(Event{Type: FailEventType, OperationID: 3})
+    if err != nil {
+        t.Fatalf("Notify: %v", err)
+    }
+    commands, _ := s.sent()
+    want := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00user\x00smtp-password"))
+    if !hasCommand(commands, want) {
+        t.Errorf("missing %q in %q", want, commands)
+    }
+}
+
+func TestEmailTemplates(t *testing.T) {
+    tests := []struct {
+        name        string
+        subject     string
+        body        string
+        wantSubject string
+        wantBody    string
+        wantErr     bool
+    }{
+        {"custom", "Op {{.OperationID}}", "Venue {{.VenueID}}\nbooked", "Subject: Op 4\r\n", "Venue 9\r\nbooked", false},
+        {"multiline subject is flattened", "Op\n{{.OperationID}}", "x", "Subject: Op 4\r\n", "x", false},
+        {"bad subject", "{{.Missing", "", "", "", true},
+        {"bad body", "", "{{.NoSuchField}}", "", "", true},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            s := newFakeSMTP(t)
+            m := testEmailNotifier(s)
+            m.Subject = tt.subject
+            m.Body = tt.body
+            err := m.Notify(Event{Type: SuccessEventType, OperationID: 4, VenueID: 9})
+            _, messages := s.sent()
+            if tt.wantErr {
+                if err == nil {
+                    t.Error("Notify succeeded with a broken template")
+                }
+                if len(messages) != 0 {
+                    t.Error("sent a message with a broken template")
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Notify: %v", err)
+            }
+            if len(messages) != 1 {
+                t.Fatalf("messages = %d, want 1", len(messages))
+            }
+            if !strings.Contains(messages[0], tt.wantSubject) || !strings.Contains(messages[0], tt.wantBody) {
+                t.Errorf("message = %q, want %q and %q", messages[0], tt.wantSubject, tt.wantBody)
+            }
+        })
+    }
+}
+
+func TestEmailRefusals(t *testing.T) {
+    s := newFakeSMTP(t)
+    m := testEmailNotifier(s)
+    m.RequireTLS = true
+    err := m.Notify(Event{Type: SuccessEventType})
+    if !errors.Is(err, ErrNoStartTLS) {
+        t.Errorf("plain server with RequireTLS: err = %v, want ErrNoStartTLS", err)
+    }
+    if _, messages := s.sent(); len(messages) != 0 {
+        t.Error("sent a message over a plain connection with RequireTLS")
+    }
+
+    m = testEmailNotifier(s)
+    m.To = nil
+    if err := m.Notify(Event{}); !errors.Is(err, ErrNoRecipients) {
+        t.Errorf("no recipients: err = %v, want ErrNoRecipients", err)
+    }
+}
@@ -0,0 +1,219 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "errors"
+    "os"
+    "os/exec"
+    "runtime"
+    "strconv"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+var (
+    ErrNoHookCmd = errors.New("hook command is empty")
+    ErrHookTimeout = errors.New("hook command timed out")
+)
+
+const (
+    // Default time a hook may run before it is killed
+    DefaultHookTimeout = 30 * time.Second
+    // Max bytes of hook output kept on an operation
+    hookOutputLimit = 4096
+)
+
+/*
+Name: Hook
+Type: struct
+Purpose: Define a user command to run when an operation
+changes state.
+Note: Command is run through the system shell. The event
+is passed both as RESOLVED_* environment variables and
+as JSON on stdin. If Events is empty the hook runs for
+every event type
+*/
+type Hook struct {
+    Command     string
+    Events      []EventType
+    Timeout     time.Duration
+}
+
+/*
+Name: HookRun
+Type: struct
+Purpose: Record of one execution of a hook, kept on
+the operation result
+*/
+type HookRun struct {
+    Command     string
+    Event       EventType
+    ExitCode    int
+    Output      string
+    Duration    time.Duration
+    Err         error
+}
+
+/*
+Name: AddHook
+Type: External App Func
+Purpose: Register a hook to run on the events of every
+operation scheduled after this call
+*/
+func (a *AppCtx) AddHook(h Hook) (error) {
+    if h.Command == "" {
+        return ErrNoHookCmd
+    }
+    if h.Timeout <= 0 {
+        h.Timeout = DefaultHookTimeout
+    }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.hooks = append(a.hooks, h)
+    return nil
+}
+
+/*
+Name: matches
+Type: Internal Func
+Purpose: Report whether a hook wants an event type
+*/
+func (h Hook) matches(t EventType) (bool) {
+    if len(h.Events) == 0 {
+        return true
+    }
+    for _, e := range h.Events {
+        if e == t {
+            return true
+        }
+    }
+    return false
+}
+
+/*
+Name: hookEnv
+Type: Internal Func
+Purpose: Flatten an event into environment variables
+for a hook command
+*/
+func hookEnv(e Event) ([]string) {
+    env := []string{
+        "RESOLVED_EVENT=" + string(e.Type),
+        "RESOLVED_OPERATION_ID=" + strconv.FormatInt(e.OperationID, 10),
+        "RESOLVED_VENUE_ID=" + strconv.FormatInt(e.VenueID, 10),
+        "RESOLVED_PARTY_SIZE=" + strconv.Itoa(e.PartySize),
+        "RESOLVED_TIMESTAMP=" + e.Timestamp.Format(time.RFC3339),
+    }
+    if e.ReservationTime != nil {
+        env = append(env, "RESOLVED_RESERVATION_TIME=" + e.ReservationTime.Format(time.RFC3339))
+    }
+    if e.SlotTime != nil {
+        env = append(env, "RESOLVED_SLOT_TIME=" + e.SlotTime.Format(time.RFC3339))
+    }
+    if e.TableType != "" {
+        env = append(env, "RESOLVED_TABLE_TYPE=" + e.TableType)
+    }
+    if e.Error != "" {
+        env = append(env, "RESOLVED_ERROR=" + e.Error)
+    }
+    return env
+}
+
+/*
+Name: run
+Type: Internal Func
+Purpose: Execute a hook for an event, killing it
+if it outlives its timeout
+*/
+func (h Hook) run(e Event) (HookRun) {
+    hookRun := HookRun{Command: h.Command, Event: e.Type, ExitCode: -1}
+    input, err := json.Marshal(e)
+    if err != nil {
+        hookRun.Err = err
+        return hookRun
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+    defer cancel()
+    var cmd *exec.Cmd
+    if runtime.GOOS == "windows" {
+        cmd = exec.CommandContext(ctx, "cmd", "/C", h.Command)
+    } else {
+        cmd = exec.CommandContext(ctx, "sh", "-c", h.Command)
+    }
+    var output bytes.Buffer
+    cmd.Stdin = bytes.NewReader(input)
+    cmd.Stdout = &output
+    cmd.Stderr = &output
+    cmd.Env = append(os.Environ(), hookEnv(e)...)
+    // don't let a child holding the output pipe open
+    // keep us waiting past the timeout
+    cmd.WaitDelay = time.Second
+
+    start := time.Now()
+    err = cmd.Run()
+    hookRun.Duration = time.Since(start)
+
+    out := output.Bytes()
+    if len(out) > hookOutputLimit {
+        out = out[:hookOutputLimit]
+    }
+    // commands can echo anything, including the
+    // secrets in their environment
+    hookRun.Output = api.Redact(string(out))
+    if cmd.ProcessState != nil {
+        hookRun.ExitCode = cmd.ProcessState.ExitCode()
+    }
+    if ctx.Err() == context.DeadlineExceeded {
+        hookRun.Err = ErrHookTimeout
+    } else {
+        hookRun.Err = err
+    }
+    return hookRun
+}
+
+/*
+Name: runHooks
+Type: Internal Func
+Purpose: Run every hook interested in an event,
+in registration order
+*/
+func runHooks(hooks []Hook, e Event) ([]HookRun) {
+    var runs []HookRun
+    for _, h := range hooks {
+        if h.matches(e.Type) {
+            runs = append(runs, h.run(e))
+        }
+    }
+    return runs
+}
+
+/*
+Name: OperationHookRuns
+Type: External App Func
+Purpose: Return the hook executions recorded on an op,
+empty until its hooks have run after it finished
+*/
+func (a *AppCtx) OperationHookRuns(id int64) ([]HookRun, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    err := a.updateOperationResult(id)
+    if err != nil {
+        return nil, err
+    }
+    for _, operation := range a.operations {
+        if operation.ID == id {
+            if operation.Result == nil {
+                return nil, nil
+            }
+            return operation.Result.HookRuns, nil
+        }
+    }
+    return nil, ErrIdOp
+}
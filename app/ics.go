@@ -0,0 +1,313 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "bufio"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+const (
+    // Length given to calendar events, since providers
+    // don't tell us how long a table is held for
+    ICSEventDuration = 2 * time.Hour
+    // RFC 5545 lines are folded past this many octets
+    icsLineLimit = 75
+    icsTimeFormat = "20060102T150405Z"
+)
+
+// Hide as much api layer details as permissible
+type Reservation api.Reservation
+
+/*
+Name: Reservable
+Type: interface
+Purpose: Provide a common definition for an operation
+result which holds a booked reservation
+*/
+type Reservable interface {
+    Reservation() (Reservation)
+}
+
+/*
+Name: Reservation
+Type: interface method
+Purpose: Satisfy the Reservable interface
+*/
+func (r ReserveAtIntervalResponse) Reservation() (Reservation) {
+    return Reservation{
+        ReservationID: r.ReservationID,
+        VenueID: r.VenueID,
+        VenueName: r.VenueName,
+        VenueAddress: r.VenueAddress,
+        ReservationTime: r.ReservationTime,
+        PartySize: r.PartySize,
+    }
+}
+
+/*
+Name: Reservation
+Type: interface method
+Purpose: Satisfy the Reservable interface
+*/
+func (r ReserveAtTimeResponse) Reservation() (Reservation) {
+    return Reservation{
+        ReservationID: r.ReservationID,
+        VenueID: r.VenueID,
+        VenueName: r.VenueName,
+        VenueAddress: r.VenueAddress,
+        ReservationTime: r.ReservationTime,
+        PartySize: r.PartySize,
+    }
+}
+
+/*
+Name: OperationReservations
+Type: External App Func
+Purpose: Collect the reservations booked by the successful
+operations with the given ids, or by every successful
+operation if no ids are given
+*/
+func (a *AppCtx) OperationReservations(ids []int64) ([]Reservation, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    for _, id := range ids {
+        stat, err := a.operationStatus(id)
+        if err != nil {
+            return nil, err
+        }
+        if stat != SuccessStatusType {
+            return nil, ErrNoSuccess
+        }
+    }
+    reservations := []Reservation{}
+    for _, operation := range a.operations {
+        if len(ids) == 0 {
+            // make sure state is up to date when
+            // we aren't checking specific ids
+            err := a.updateOperationResult(operation.ID)
+            if err != nil {
+                return nil, err
+            }
+        } else if !containsID(ids, operation.ID) {
+            continue
+        }
+        reservation, ok := a.operationReservation(operation.ID)
+        if ok {
+            reservations = append(reservations, reservation)
+        }
+    }
+    return reservations, nil
+}
+
+/*
+Name: operationReservation
+Type: Internal App Func
+Purpose: Get the reservation held by an op, if the
+op succeeded in making one
+Note: Must be called with the lock held
+*/
+func (a *AppCtx) operationReservation(id int64) (Reservation, bool) {
+    for _, operation := range a.operations {
+        if operation.ID != id {
+            continue
+        }
+        // an op hunting for upgrades already holds a booking
+        if operation.Status == InProgressStatusType && operation.Holding != nil {
+            return *operation.Holding, true
+        }
+        if operation.Status != SuccessStatusType || operation.Result == nil {
+            return Reservation{}, false
+        }
+        reservable, ok := operation.Result.Response.(Reservable)
+        if !ok {
+            return Reservation{}, false
+        }
+        return reservable.Reservation(), true
+    }
+    return Reservation{}, false
+}
+
+/*
+Name: containsID
+Type: Internal Func
+Purpose: Report whether an id list holds an id
+*/
+func containsID(ids []int64, id int64) (bool) {
+    for _, v := range ids {
+        if v == id {
+            return true
+        }
+    }
+    return false
+}
+
+/*
+Name: ProviderReservations
+Type: External App Func
+Purpose: List the upcoming reservations on the account
+at the external service, using the login defaults if
+no credentials are given
+*/
+func (a *AppCtx) ProviderReservations(params LoginParam) ([]Reservation, error) {
+    a.mu.Lock()
+    params, err := a.loginDefaults(params)
+    a.mu.Unlock()
+    if err != nil {
+        return nil, err
+    }
+    return a.providerReservations(params)
+}
+
+/*
+Name: loginDefaults
+Type: Internal App Func
+Purpose: Fill in login params left empty with the login
+defaults, failing if there are none
+Note: Must be called with the lock held
+*/
+func (a *AppCtx) loginDefaults(params LoginParam) (LoginParam, error) {
+    if params.Email != "" && params.Password != "" {
+        return params, nil
+    }
+    if a.loginInfo.Email == "" && a.loginInfo.Password == "" {
+        return LoginParam{}, ErrNoLogin
+    }
+    return a.loginInfo, nil
+}
+
+/*
+Name: providerReservations
+Type: Internal App Func
+Purpose: List the reservations on the account the login
+params name, which must already be filled in
+Note: Logs in at the external service, so must be called
+without the lock held
+*/
+func (a *AppCtx) providerReservations(params LoginParam) ([]Reservation, error) {
+    lister, ok := a.API.(api.ReservationLister)
+    if !ok {
+        return nil, api.ErrNoList
+    }
+    loginResp, err := a.API.Login(api.LoginParam(params))
+    if err != nil {
+        return nil, err
+    }
+    resp, err := lister.Reservations(api.ReservationsParam{LoginResp: *loginResp})
+    if err != nil {
+        return nil, err
+    }
+    reservations := make([]Reservation, len(resp.Reservations))
+    for i, reservation := range resp.Reservations {
+        reservations[i] = Reservation(reservation)
+    }
+    return reservations, nil
+}
+
+/*
+Name: icsEscape
+Type: Internal Func
+Purpose: Escape a TEXT value per RFC 5545 3.3.11
+*/
+func icsEscape(text string) (string) {
+    replacer := strings.NewReplacer(
+        `\`, `\\`,
+        `;`, `\;`,
+        `,`, `\,`,
+        "\r\n", `\n`,
+        "\n", `\n`,
+    )
+    return replacer.Replace(text)
+}
+
+/*
+Name: writeICSLine
+Type: Internal Func
+Purpose: Write a content line, folding it past 75 octets
+without splitting a UTF-8 sequence, per RFC 5545 3.1
+*/
+func writeICSLine(w *bufio.Writer, line string) {
+    limit := icsLineLimit
+    for len(line) > limit {
+        cut := limit
+        for cut > 0 && !utf8.RuneStart(line[cut]) {
+            cut--
+        }
+        w.WriteString(line[:cut] + "\r\n ")
+        line = line[cut:]
+        // continuation lines start with a space, which counts
+        limit = icsLineLimit - 1
+    }
+    w.WriteString(line + "\r\n")
+}
+
+/*
+Name: icsUID
+Type: Internal Func
+Purpose: Derive a stable UID for a reservation, so
+importing an export twice updates instead of duplicating.
+A confirmation id that is a secret, like a resy token,
+isn't used
+*/
+func icsUID(r Reservation) (string) {
+    if r.ReservationID != "" && api.Redact(r.ReservationID) == r.ReservationID {
+        return r.ReservationID + "@resolved"
+    }
+    return strconv.FormatInt(r.VenueID, 10) + "-" + r.ReservationTime.UTC().Format(icsTimeFormat) + "@resolved"
+}
+
+/*
+Name: WriteICS
+Type: External Func
+Purpose: Write reservations as an RFC 5545 iCalendar
+stream, one VEVENT per reservation
+Note: Only the confirmation id can hold a secret, so it
+is the only field scrubbed, see 'api.Redact'. Venue names,
+addresses and times are written as they are
+*/
+func WriteICS(out io.Writer, reservations []Reservation) (error) {
+    w := bufio.NewWriter(out)
+    stamp := time.Now().UTC().Format(icsTimeFormat)
+    writeICSLine(w, "BEGIN:VCALENDAR")
+    writeICSLine(w, "VERSION:2.0")
+    writeICSLine(w, "PRODID:-//Resolved//Resolved Bot//EN")
+    writeICSLine(w, "CALSCALE:GREGORIAN")
+    writeICSLine(w, "METHOD:PUBLISH")
+    for _, r := range reservations {
+        summary := "Reservation at " + r.VenueName
+        if r.VenueName == "" {
+            summary = "Reservation at venue " + strconv.FormatInt(r.VenueID, 10)
+        }
+        // listers that don't report a party size leave it 0
+        lines := []string{}
+        if r.PartySize > 0 {
+            lines = append(lines, "Party of " + strconv.Itoa(r.PartySize))
+        }
+        if r.ReservationID != "" {
+            lines = append(lines, "Confirmation: " + api.Redact(r.ReservationID))
+        }
+        lines = append(lines, "Venue ID: " + strconv.FormatInt(r.VenueID, 10))
+        description := strings.Join(lines, "\n")
+
+        writeICSLine(w, "BEGIN:VEVENT")
+        writeICSLine(w, "UID:" + icsUID(r))
+        writeICSLine(w, "DTSTAMP:" + stamp)
+        writeICSLine(w, "DTSTART:" + r.ReservationTime.UTC().Format(icsTimeFormat))
+        writeICSLine(w, "DTEND:" + r.ReservationTime.Add(ICSEventDuration).UTC().Format(icsTimeFormat))
+        writeICSLine(w, "SUMMARY:" + icsEscape(summary))
+        if r.VenueAddress != "" {
+            writeICSLine(w, "LOCATION:" + icsEscape(r.VenueAddress))
+        }
+        writeICSLine(w, "DESCRIPTION:" + icsEscape(description))
+        writeICSLine(w, "END:VEVENT")
+    }
+    writeICSLine(w, "END:VCALENDAR")
+    return w.Flush()
+}
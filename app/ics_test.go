@@ -0,0 +1,125 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "bytes"
+    "strings"
+    "testing"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+/*
+Name: unfoldICS
+Type: Internal Test Func
+Purpose: Undo RFC 5545 line folding so tests can look
+for whole content lines
+*/
+func unfoldICS(ics string) (string) {
+    return strings.ReplaceAll(ics, "\r\n ", "")
+}
+
+func TestWriteICS(t *testing.T) {
+    at := time.Date(2026, 11, 2, 19, 30, 0, 0, time.UTC)
+    // a registered secret that also turns up in an address
+    api.AddSecret("424242")
+    tests := []struct {
+        name        string
+        reservation Reservation
+        want        []string
+        notWant     []string
+    }{
+        {
+            name: "full",
+            reservation: Reservation{ReservationID: "R-1001", VenueID: 5286, VenueName: "Carbone", VenueAddress: "181 Thompson St, New York", ReservationTime: at, PartySize: 2},
+            want: []string{
+                "UID:R-1001@resolved\r\n",
+                "DTSTART:20261102T193000Z\r\n",
+                "DTEND:20261102T213000Z\r\n",
+                "SUMMARY:Reservation at Carbone\r\n",
+                "LOCATION:181 Thompson St\\, New York\r\n",
+                "DESCRIPTION:Party of 2\\nConfirmation: R-1001\\nVenue ID: 5286\r\n",
+            },
+        },
+        {
+            name: "no party size",
+            reservation: Reservation{ReservationID: "R-1002", VenueID: 7, ReservationTime: at},
+            want: []string{
+                "SUMMARY:Reservation at venue 7\r\n",
+                "DESCRIPTION:Confirmation: R-1002\\nVenue ID: 7\r\n",
+            },
+            notWant: []string{"Party of 0", "LOCATION:"},
+        },
+        {
+            name: "no confirmation id",
+            reservation: Reservation{VenueID: 7, ReservationTime: at, PartySize: 4},
+            want: []string{
+                "UID:7-20261102T193000Z@resolved\r\n",
+                "DESCRIPTION:Party of 4\\nVenue ID: 7\r\n",
+            },
+            notWant: []string{"Confirmation"},
+        },
+        {
+            name: "venue fields are not scrubbed",
+            reservation: Reservation{ReservationID: "R-1003", VenueID: 7, VenueName: "Token=Bar; Grill", VenueAddress: "424242 Main St", ReservationTime: at, PartySize: 2},
+            want: []string{
+                "SUMMARY:Reservation at Token=Bar\\; Grill\r\n",
+                "LOCATION:424242 Main St\r\n",
+            },
+            notWant: []string{api.Redacted},
+        },
+        {
+            name: "secret confirmation id is scrubbed",
+            reservation: Reservation{ReservationID: "resy_token=rgs://tok-9f8e7d", VenueID: 7, ReservationTime: at, PartySize: 2},
+            want: []string{
+                "UID:7-20261102T193000Z@resolved\r\n",
+                "DESCRIPTION:Party of 2\\nConfirmation: resy_token=" + api.Redacted + "\\nVenue ID: 7\r\n",
+            },
+            notWant: []string{"tok-9f8e7d"},
+        },
+        {
+            name: "registered secret confirmation id is scrubbed",
+            reservation: Reservation{ReservationID: "424242", VenueID: 7, ReservationTime: at, PartySize: 2},
+            want: []string{
+                "UID:7-20261102T193000Z@resolved\r\n",
+                "Confirmation: " + api.Redacted + "\\n",
+            },
+        },
+        {
+            name: "long names fold",
+            reservation: Reservation{VenueID: 7, VenueName: strings.Repeat("Très Long Nom ", 10), ReservationTime: at, PartySize: 2},
+            want: []string{"SUMMARY:Reservation at " + strings.TrimSpace(strings.Repeat("Très Long Nom ", 10)) + " \r\n"},
+        },
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            var out bytes.Buffer
+            err := WriteICS(&out, []Reservation{tt.reservation})
+            if err != nil {
+                t.Fatalf("WriteICS: %v", err)
+            }
+            for _, line := range strings.SplitAfter(out.String(), "\r\n") {
+                if len(line) > icsLineLimit + 2 {
+                    t.Errorf("line longer than %d octets: %q", icsLineLimit, line)
+                }
+            }
+            ics := unfoldICS(out.String())
+            if !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+                t.Errorf("not a calendar:\n%s", ics)
+            }
+            for _, want := range tt.want {
+                if !strings.Contains(ics, want) {
+                    t.Errorf("missing %q in:\n%s", want, ics)
+                }
+            }
+            for _, notWant := range tt.notWant {
+                if strings.Contains(ics, notWant) {
+                    t.Errorf("unexpected %q in:\n%s", notWant, ics)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,211 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "context"
+    "errors"
+    "io"
+    "log/slog"
+    "sync"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+var (
+    ErrLogFormat = errors.New("invalid log format")
+)
+
+// LogFormat is an enum, only use with next const def types
+type LogFormat string
+
+const (
+    TextLogFormat LogFormat = "text"
+    JSONLogFormat LogFormat = "json"
+)
+
+const (
+    // Level above every slog level, logging nothing
+    OffLogLevel = slog.Level(16)
+)
+
+/*
+Name: LogHandler
+Type: slog.Handler
+Purpose: A handler whose level, output and format can be
+changed while loggers built on it are in use, so one
+logger can be handed to the api and app at startup and
+reconfigured later
+Note: Loggers derived with With or WithGroup follow
+changes made to the handler they came from
+*/
+type LogHandler struct {
+    root        *logHandlerRoot
+    // Applied in order to the root's current handler
+    derive      []func(slog.Handler) (slog.Handler)
+}
+
+/*
+Name: logHandlerRoot
+Type: Internal struct
+Purpose: The state shared by a LogHandler and every
+handler derived from it
+*/
+type logHandlerRoot struct {
+    mu          sync.Mutex
+    level       slog.LevelVar
+    inner       slog.Handler
+    out         io.Writer
+    format      LogFormat
+}
+
+/*
+Name: NewLogHandler
+Type: External Func
+Purpose: Make a LogHandler writing records at or above
+level to w in the given format
+*/
+func NewLogHandler(w io.Writer, format LogFormat, level slog.Level) (*LogHandler, error) {
+    h := &LogHandler{root: &logHandlerRoot{}}
+    h.root.level.Set(level)
+    err := h.SetOutput(w, format)
+    if err != nil {
+        return nil, err
+    }
+    return h, nil
+}
+
+/*
+Name: SetOutput
+Type: External Func
+Purpose: Send records to w in the given format from now on
+*/
+func (h *LogHandler) SetOutput(w io.Writer, format LogFormat) (error) {
+    opts := &slog.HandlerOptions{Level: &h.root.level}
+    var inner slog.Handler
+    switch format {
+    case TextLogFormat:
+        inner = slog.NewTextHandler(w, opts)
+    case JSONLogFormat:
+        inner = slog.NewJSONHandler(w, opts)
+    default:
+        return ErrLogFormat
+    }
+    h.root.mu.Lock()
+    defer h.root.mu.Unlock()
+    h.root.inner = inner
+    h.root.out = w
+    h.root.format = format
+    return nil
+}
+
+/*
+Name: Output
+Type: External Func
+Purpose: Return where records are sent and in
+what format
+*/
+func (h *LogHandler) Output() (io.Writer, LogFormat) {
+    h.root.mu.Lock()
+    defer h.root.mu.Unlock()
+    return h.root.out, h.root.format
+}
+
+/*
+Name: SetLevel
+Type: External Func
+Purpose: Set the lowest level logged, OffLogLevel
+turns logging off
+*/
+func (h *LogHandler) SetLevel(level slog.Level) {
+    h.root.level.Set(level)
+}
+
+/*
+Name: Level
+Type: External Func
+Purpose: Return the lowest level logged
+*/
+func (h *LogHandler) Level() (slog.Level) {
+    return h.root.level.Level()
+}
+
+/*
+Name: handler
+Type: Internal Func
+Purpose: Build the handler records go to right now,
+the root's current handler with this handler's
+attrs and groups applied
+*/
+func (h *LogHandler) handler() (slog.Handler) {
+    h.root.mu.Lock()
+    inner := h.root.inner
+    h.root.mu.Unlock()
+    for _, derive := range h.derive {
+        inner = derive(inner)
+    }
+    return inner
+}
+
+/*
+Name: Enabled
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *LogHandler) Enabled(ctx context.Context, level slog.Level) (bool) {
+    return level >= h.root.level.Level()
+}
+
+/*
+Name: Handle
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *LogHandler) Handle(ctx context.Context, r slog.Record) (error) {
+    return h.handler().Handle(ctx, r)
+}
+
+/*
+Name: WithAttrs
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *LogHandler) WithAttrs(attrs []slog.Attr) (slog.Handler) {
+    return h.with(func(inner slog.Handler) (slog.Handler) {
+        return inner.WithAttrs(attrs)
+    })
+}
+
+/*
+Name: WithGroup
+Type: slog.Handler method
+Purpose: Satisfy the slog.Handler interface
+*/
+func (h *LogHandler) WithGroup(name string) (slog.Handler) {
+    return h.with(func(inner slog.Handler) (slog.Handler) {
+        return inner.WithGroup(name)
+    })
+}
+
+/*
+Name: with
+Type: Internal Func
+Purpose: Derive a handler sharing this one's root
+with one more step applied
+*/
+func (h *LogHandler) with(derive func(slog.Handler) (slog.Handler)) (*LogHandler) {
+    steps := make([]func(slog.Handler) (slog.Handler), 0, len(h.derive) + 1)
+    steps = append(append(steps, h.derive...), derive)
+    return &LogHandler{root: h.root, derive: steps}
+}
+
+/*
+Name: logger
+Type: Internal App Func
+Purpose: Return the app's logger, one that discards
+everything if none was given
+*/
+func (a *AppCtx) logger() (*slog.Logger) {
+    return api.PickLogger(a.Logger)
+}
@@ -0,0 +1,71 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "github.com/21Bruce/resolved-server/metrics"
+)
+
+var (
+    operationsFinished = metrics.Default.Counter(
+        "resolved_operations_finished_total",
+        "Operations that have finished, by outcome event",
+        "event")
+)
+
+/*
+Name: statusName
+Type: Internal Func
+Purpose: Name an operation status for metrics
+*/
+func statusName(status OperationStatus) (string) {
+    switch status {
+    case InProgressStatusType:
+        return "in_progress"
+    case SuccessStatusType:
+        return "succeeded"
+    case FailStatusType:
+        return "failed"
+    case CancelStatusType:
+        return "cancelled"
+    }
+    return "unknown"
+}
+
+/*
+Name: RegisterMetrics
+Type: External App Func
+Purpose: Register a gauge of the app's operations by
+status in a metrics registry, read each time the
+registry is written
+*/
+func (a *AppCtx) RegisterMetrics(r *metrics.Registry) {
+    r.GaugeFunc(
+        "resolved_operations",
+        "Operations known to the app, by status",
+        "status",
+        a.operationCounts)
+}
+
+/*
+Name: operationCounts
+Type: Internal Func
+Purpose: Count the operations by status, every status
+listed even when no operation has it
+*/
+func (a *AppCtx) operationCounts() (map[string]float64) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    counts := map[string]float64{}
+    for _, status := range []OperationStatus{InProgressStatusType, SuccessStatusType, FailStatusType, CancelStatusType} {
+        counts[statusName(status)] = 0
+    }
+    for i := range a.operations {
+        // see if ops finished since they were last looked at
+        a.updateOperationResult(a.operations[i].ID)
+        counts[statusName(a.operations[i].Status)] += 1
+    }
+    return counts
+}
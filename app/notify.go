@@ -0,0 +1,393 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "bytes"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+var (
+    ErrNoWebhook = errors.New("no webhook urls provided")
+    ErrWebhookStatus = errors.New("webhook receiver returned a non-success code")
+    ErrNoNotifier = errors.New("no notifiers configured")
+)
+
+// EventType is an enum, only use with next const def types
+type EventType string
+
+const (
+    SuccessEventType EventType = "operation.succeeded"
+    FailEventType    EventType = "operation.failed"
+    CancelEventType  EventType = "operation.cancelled"
+    WouldBookEventType EventType = "operation.would_have_booked"
+    TestEventType    EventType = "notify.test"
+    SlotAppearedEventType    EventType = "slot.appeared"
+    SlotDisappearedEventType EventType = "slot.disappeared"
+    WatchEndedEventType      EventType = "watch.ended"
+)
+
+/*
+Name: Event
+Type: struct
+Purpose: Describe an operation outcome in a form that
+can be handed to notifiers and serialized as JSON
+*/
+type Event struct {
+    Type             EventType   `json:"type"`
+    OperationID      int64       `json:"operation_id"`
+    VenueID          int64       `json:"venue_id"`
+    PartySize        int         `json:"party_size"`
+    ReservationTimes []time.Time `json:"reservation_times"`
+    ReservationTime  *time.Time  `json:"reservation_time,omitempty"`
+    // Set on slot events from watch operations, and
+    // TableType on dry run outcomes too
+    SlotTime         *time.Time  `json:"slot_time,omitempty"`
+    TableType        string      `json:"table_type,omitempty"`
+    Error            string      `json:"error,omitempty"`
+    Timestamp        time.Time   `json:"timestamp"`
+}
+
+/*
+Name: Notifier
+Type: interface
+Purpose: Provide a common definition for anything
+that wants to be told about operation outcomes
+*/
+type Notifier interface {
+    Notify(e Event) (error)
+}
+
+/*
+Name: opMeta
+Type: Internal struct
+Purpose: Snapshot of what an operation is trying to do,
+taken at schedule time so the go thread running the
+operation never has to read the operations slice
+*/
+type opMeta struct {
+    ID               int64
+    VenueID          int64
+    PartySize        int
+    ReservationTimes []time.Time
+    Notifiers        []Notifier
+    Hooks            []Hook
+    ConflictPolicy   ConflictPolicy
+    ConflictBuffer   time.Duration
+    // Account reservations fetched at schedule time
+    AccountReservations []Reservation
+    // The app logger tagged with the op's id and venue
+    Logger           *slog.Logger
+    // Where the op's requests are timed
+    Trace            *api.Trace
+    // Where the result goes again once its outcome is
+    // delivered, with how that went
+    Delivery         chan<- OperationResult
+    // If set, closed once the op's earlier events are
+    // delivered, which its outcome waits for
+    Delivered        <-chan struct{}
+}
+
+/*
+Name: newOpMeta
+Type: Internal App Func
+Purpose: Take the metadata snapshot for a freshly
+scheduled operation
+Note: Must be called with the lock held
+*/
+func (a *AppCtx) newOpMeta(op Operation, accountReservations []Reservation) (opMeta) {
+    return opMeta{
+        ID: op.ID,
+        VenueID: op.VenueID,
+        PartySize: op.PartySize,
+        ReservationTimes: op.ReservationTimes,
+        Notifiers: append([]Notifier(nil), a.notifiers...),
+        Hooks: append([]Hook(nil), a.hooks...),
+        ConflictPolicy: a.conflictPolicy,
+        ConflictBuffer: a.getConflictBuffer(),
+        AccountReservations: accountReservations,
+        Logger: a.logger().With("op_id", op.ID, "venue_id", op.VenueID),
+        Trace: op.Trace,
+        Delivery: op.delivery,
+    }
+}
+
+/*
+Name: newOutcomeEvent
+Type: Internal Func
+Purpose: Build the event describing how an operation
+finished from its metadata and result
+*/
+func newOutcomeEvent(meta opMeta, result OperationResult) (Event) {
+    e := Event{
+        OperationID: meta.ID,
+        VenueID: meta.VenueID,
+        PartySize: meta.PartySize,
+        ReservationTimes: meta.ReservationTimes,
+        Timestamp: time.Now().UTC(),
+    }
+    dryRun, isDryRun := result.Response.(DryRunResponse)
+    _, isWatch := result.Response.(WatchResponse)
+    switch {
+    case result.Err == nil && isDryRun:
+        e.Type = WouldBookEventType
+        resTime := dryRun.ReservationTime
+        e.ReservationTime = &resTime
+        if dryRun.PartySize != 0 {
+            e.PartySize = dryRun.PartySize
+        }
+        e.TableType = string(dryRun.TableType)
+    case result.Err == nil && isWatch:
+        // a watch never books, so it didn't succeed at one
+        e.Type = WatchEndedEventType
+    case result.Err == nil:
+        e.Type = SuccessEventType
+        // only ops that booked have a reservation time
+        if reservable, ok := result.Response.(Reservable); ok {
+            reservation := reservable.Reservation()
+            resTime := reservation.ReservationTime
+            e.ReservationTime = &resTime
+            // report the size booked, which may be
+            // one of the op's alternates
+            if reservation.PartySize != 0 {
+                e.PartySize = reservation.PartySize
+            }
+        }
+    case errors.Is(result.Err, ErrCancel):
+        e.Type = CancelEventType
+        e.Error = result.Err.Error()
+    default:
+        e.Type = FailEventType
+        e.Error = result.Err.Error()
+    }
+    return e
+}
+
+/*
+Name: notifyAll
+Type: Internal Func
+Purpose: Hand an event to every notifier, collecting
+the errors instead of stopping at the first one
+*/
+func notifyAll(notifiers []Notifier, e Event) (error) {
+    var errs []error
+    for _, n := range notifiers {
+        if err := n.Notify(e); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+/*
+Name: finishOperation
+Type: Internal App Func
+Purpose: Every operation go thread ends here. It records
+what a new booking conflicts with, reports the result on
+the output channel and closes it, then notifies subscribers
+of the outcome and runs user hooks in the background, so a
+slow webhook or hook never holds the result back
+*/
+func (a *AppCtx) finishOperation(meta opMeta, output chan<- OperationResult, result OperationResult) {
+    // errors from the api can quote urls and bodies
+    // holding tokens, scrub them before anyone sees them
+    result.Err = api.RedactError(result.Err)
+    result.UpgradeErr = api.RedactError(result.UpgradeErr)
+    if reservable, ok := result.Response.(Reservable); ok && result.Err == nil {
+        result.Conflicts = a.bookingConflicts(meta, reservable.Reservation().ReservationTime)
+    }
+    e := newOutcomeEvent(meta, result)
+    operationsFinished.Inc(string(e.Type))
+    if result.Err != nil && e.Type == FailEventType {
+        meta.Logger.Warn("operation finished", "event", e.Type, "err", result.Err)
+    } else {
+        meta.Logger.Info("operation finished", "event", e.Type)
+    }
+    output <- result
+    close(output)
+    go deliverOutcome(meta, result, e)
+}
+
+/*
+Name: deliverOutcome
+Type: Internal Func
+Purpose: Hand an op's outcome event to its notifiers and
+hooks, after any of its earlier events, then send the
+result on with how that went, see
+'AppCtx.updateOperationResult'
+*/
+func deliverOutcome(meta opMeta, result OperationResult, e Event) {
+    if meta.Delivered != nil {
+        <-meta.Delivered
+    }
+    result.NotifyErr = notifyAll(meta.Notifiers, e)
+    if result.NotifyErr != nil {
+        meta.Logger.Warn("notifying failed", "err", result.NotifyErr)
+    }
+    result.HookRuns = runHooks(meta.Hooks, e)
+    if meta.Delivery != nil {
+        meta.Delivery <- result
+        close(meta.Delivery)
+    }
+}
+
+/*
+Name: AddNotifier
+Type: External App Func
+Purpose: Subscribe a notifier to the outcomes of every
+operation scheduled after this call
+*/
+func (a *AppCtx) AddNotifier(n Notifier) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.notifiers = append(a.notifiers, n)
+}
+
+/*
+Name: TestNotifiers
+Type: External App Func
+Purpose: Send a test event to every subscribed notifier
+so a consumer can check their configuration
+*/
+func (a *AppCtx) TestNotifiers() (error) {
+    a.mu.Lock()
+    notifiers := append([]Notifier(nil), a.notifiers...)
+    a.mu.Unlock()
+    if len(notifiers) == 0 {
+        return ErrNoNotifier
+    }
+    return notifyAll(notifiers, Event{
+        Type: TestEventType,
+        OperationID: -1,
+        Timestamp: time.Now().UTC(),
+    })
+}
+
+/*
+Name: WebhookNotifier
+Type: Notifier struct
+Purpose: Post events as JSON to a list of webhook urls.
+Note: If Secret is set, each body is signed with HMAC-SHA256
+and the hex digest is sent in the X-Resolved-Signature header
+as "sha256=<digest>", so receivers can verify the sender.
+Deliveries that got no response, a 5xx or a 429 are retried
+MaxRetries times, waiting RetryDelay and doubling the wait
+after every attempt. Any other refusal is final
+*/
+type WebhookNotifier struct {
+    URLs        []string
+    Secret      string
+    MaxRetries  int
+    RetryDelay  time.Duration
+    Timeout     time.Duration
+}
+
+/*
+Name: NewWebhookNotifier
+Type: External Func
+Purpose: Provide a webhook notifier with sane retry
+and timeout defaults
+*/
+func NewWebhookNotifier(urls []string, secret string) (*WebhookNotifier) {
+    api.AddSecret(secret)
+    return &WebhookNotifier{
+        URLs: urls,
+        Secret: secret,
+        MaxRetries: 3,
+        RetryDelay: time.Second,
+        Timeout: 10 * time.Second,
+    }
+}
+
+/*
+Name: Sign
+Type: External Func
+Purpose: Compute the signature header value for a body,
+exposed so receivers written in go can verify payloads
+*/
+func (w *WebhookNotifier) Sign(body []byte) (string) {
+    mac := hmac.New(sha256.New, []byte(w.Secret))
+    mac.Write(body)
+    return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+/*
+Name: Notify
+Type: interface method
+Purpose: Satisfy the Notifier interface
+*/
+func (w *WebhookNotifier) Notify(e Event) (error) {
+    if len(w.URLs) == 0 {
+        return ErrNoWebhook
+    }
+    body, err := json.Marshal(e)
+    if err != nil {
+        return err
+    }
+    var errs []error
+    for _, url := range w.URLs {
+        if err := w.deliver(url, e.Type, body); err != nil {
+            errs = append(errs, errors.New(url + ": " + err.Error()))
+        }
+    }
+    return errors.Join(errs...)
+}
+
+/*
+Name: deliver
+Type: Internal Func
+Purpose: Post one body to one url, retrying with
+exponential backoff until it succeeds, the receiver
+refuses it for good or we run out of retries
+*/
+func (w *WebhookNotifier) deliver(url string, eventType EventType, body []byte) (error) {
+    client := &http.Client{Timeout: w.Timeout}
+    delay := w.RetryDelay
+    var err error
+    for attempt := 0; attempt <= w.MaxRetries; attempt++ {
+        if attempt > 0 {
+            time.Sleep(delay)
+            delay *= 2
+        }
+        request, reqErr := http.NewRequest("POST", url, bytes.NewBuffer(body))
+        if reqErr != nil {
+            // a malformed url will never succeed, so don't retry
+            return reqErr
+        }
+        request.Header.Set("Content-Type", "application/json")
+        request.Header.Set("User-Agent", "Resolved-Server")
+        request.Header.Set("X-Resolved-Event", string(eventType))
+        request.Header.Set("X-Resolved-Attempt", strconv.Itoa(attempt + 1))
+        if w.Secret != "" {
+            request.Header.Set("X-Resolved-Signature", w.Sign(body))
+        }
+
+        response, doErr := client.Do(request)
+        if doErr != nil {
+            err = doErr
+            continue
+        }
+        response.Body.Close()
+        if response.StatusCode / 100 == 2 {
+            return nil
+        }
+        err = ErrWebhookStatus
+        // a receiver that refused the event won't
+        // change its mind if it is sent again
+        if !api.ClassifyStatus(response.StatusCode).Retryable() {
+            return err
+        }
+    }
+    return err
+}
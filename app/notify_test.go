@@ -0,0 +1,195 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "errors"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "sync"
+    "testing"
+    "time"
+)
+
+/*
+Name: webhookReceiver
+Type: Internal Test Struct
+Purpose: An httptest server answering webhook posts with
+a list of statuses in turn, keeping what it was sent
+*/
+type webhookReceiver struct {
+    mu          sync.Mutex
+    statuses    []int
+    bodies      [][]byte
+    headers     []http.Header
+}
+
+func newWebhookReceiver(t *testing.T, statuses ...int) (*webhookReceiver, *httptest.Server) {
+    r := &webhookReceiver{statuses: statuses}
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+        body, _ := io.ReadAll(req.Body)
+        r.mu.Lock()
+        defer r.mu.Unlock()
+        r.bodies = append(r.bodies, body)
+        r.headers = append(r.headers, req.Header.Clone())
+        status := http.StatusOK
+        if len(r.bodies) <= len(r.statuses) {
+            status = r.statuses[len(r.bodies) - 1]
+        }
+        w.WriteHeader(status)
+    }))
+    t.Cleanup(server.Close)
+    return r, server
+}
+
+func (r *webhookReceiver) attempts() (int) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    return len(r.bodies)
+}
+
+func testWebhookNotifier(url string, secret string) (*WebhookNotifier) {
+    w := NewWebhookNotifier([]string{url}, secret)
+    w.RetryDelay = time.Millisecond
+    w.Timeout = 5 * time.Second
+    return w
+}
+
+func TestWebhookSignature(t *testing.T) {
+    receiver, server := newWebhookReceiver(t)
+    w := testWebhookNotifier(server.URL, "webhook-test-secret")
+    err := w.Notify(Event{Type: SuccessEventType, OperationID: 7, Timestamp: time.Now().UTC()})
+    if err != nil {
+        t.Fatalf("Notify: %v", err)
+    }
+    if receiver.attempts() != 1 {
+        t.Fatalf("attempts = %d, want 1", receiver.attempts())
+    }
+    header := receiver.headers[0]
+    if got, want := header.Get("X-Resolved-Signature"), w.Sign(receiver.bodies[0]); got != want {
+        t.Errorf("signature = %q, want %q", got, want)
+    }
+    if got := header.Get("X-Resolved-Event"); got != string(SuccessEventType) {
+        t.Errorf("event header = %q", got)
+    }
+
+    // a different secret must not verify
+    other := &WebhookNotifier{Secret: "another-secret"}
+    if other.Sign(receiver.bodies[0]) == header.Get("X-Resolved-Signature") {
+        t.Error("signature verified with the wrong secret")
+    }
+
+    // no secret, no signature
+    receiver, server = newWebhookReceiver(t)
+    err = testWebhookNotifier(server.URL, "").Notify(Event{Type: FailEventType})
+    if err != nil {
+        t.Fatalf("Notify: %v", err)
+    }
+    if got := receiver.headers[0].Get("X-Resolved-Signature"); got != "" {
+        t.Errorf("unsigned webhook sent signature %q", got)
+    }
+}
+
+func TestWebhookRetries(t *testing.T) {
+    tests := []struct {
+        name        string
+        statuses    []int
+        wantErr     bool
+        attempts    int
+    }{
+        {"ok", []int{200}, false, 1},
+        {"server errors then ok", []int{503, 502, 200}, false, 3},
+        {"rate limited then ok", []int{429, 200}, false, 2},
+        {"server errors past retries", []int{500, 500, 500, 500, 500}, true, 4},
+        {"bad request is final", []int{400, 200}, true, 1},
+        {"not found is final", []int{404, 200}, true, 1},
+        {"forbidden is final", []int{403, 200}, true, 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            receiver, server := newWebhookReceiver(t, tt.statuses...)
+            w := testWebhookNotifier(server.URL, "")
+            err := w.Notify(Event{Type: SuccessEventType})
+            if (err != nil) != tt.wantErr {
+                t.Errorf("err = %v, want error %v", err, tt.wantErr)
+            }
+            if receiver.attempts() != tt.attempts {
+                t.Errorf("attempts = %d, want %d", receiver.attempts(), tt.attempts)
+            }
+            for i, header := range receiver.headers {
+                if got, want := header.Get("X-Resolved-Attempt"), string(rune('1' + i)); got != want {
+                    t.Errorf("attempt header %d = %q, want %q", i, got, want)
+                }
+            }
+        })
+    }
+}
+
+func TestWebhookUnreachable(t *testing.T) {
+    _, server := newWebhookReceiver(t)
+    url := server.URL
+    server.Close()
+    w := testWebhookNotifier(url, "")
+    w.MaxRetries = 1
+    if err := w.Notify(Event{Type: SuccessEventType}); err == nil {
+        t.Error("Notify to a closed server succeeded")
+    }
+    if err := (&WebhookNotifier{}).Notify(Event{}); !errors.Is(err, ErrNoWebhook) {
+        t.Errorf("no urls: err = %v, want ErrNoWebhook", err)
+    }
+}
+
+/*
+Name: blockingNotifier
+Type: Internal Test Struct
+Purpose: A notifier that doesn't return until released
+*/
+type blockingNotifier struct {
+    release     chan struct{}
+}
+
+func (n blockingNotifier) Notify(e Event) (error) {
+    <-n.release
+    return ErrWebhookStatus
+}
+
+func TestFinishOperationDoesNotWaitForNotifiers(t *testing.T) {
+    a := &AppCtx{}
+    output := make(chan OperationResult, 1)
+    a.operations = []Operation{{ID: 1, Output: output, Status: InProgressStatusType, delivery: make(chan OperationResult, 1)}}
+    notifier := blockingNotifier{release: make(chan struct{})}
+    meta := a.newOpMeta(a.operations[0], nil)
+    meta.Notifiers = []Notifier{notifier}
+
+    done := make(chan struct{})
+    go func() {
+        a.finishOperation(meta, output, OperationResult{Err: ErrCancel})
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-time.After(5 * time.Second):
+        t.Fatal("finishOperation waited on a notifier")
+    }
+
+    close(notifier.release)
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        a.updateOperationResult(1)
+        result := a.operations[0].Result
+        var notifyErr error
+        if result != nil {
+            notifyErr = result.NotifyErr
+        }
+        if errors.Is(notifyErr, ErrWebhookStatus) {
+            return
+        }
+        if time.Now().After(deadline) {
+            t.Fatal("notify error never recorded on the result")
+        }
+        time.Sleep(time.Millisecond)
+    }
+}
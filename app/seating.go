@@ -0,0 +1,126 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "sort"
+    "strconv"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+/*
+Name: SeatingParam
+Type: App api func input parameters
+Purpose: Provide a means to list the seating types
+a venue offers by a consumer
+Note: Services which only list slots near a time look
+around the clock time of Day
+*/
+type SeatingParam struct {
+    Login            LoginParam
+    VenueID          int64
+    Day              time.Time
+    PartySize        int
+}
+
+/*
+Name: SeatingType
+Type: struct
+Purpose: One seating type open at a venue, named as
+the service names it
+*/
+type SeatingType struct {
+    Name            string
+    // Open slots with this seating
+    Slots           int
+    First           time.Time
+    Last            time.Time
+}
+
+/*
+Name: SeatingResponse
+Type: struct
+Purpose: Define the data returned when listing the
+seating types at a venue
+*/
+type SeatingResponse struct {
+    VenueName       string
+    SeatingTypes    []SeatingType
+}
+
+/*
+Name: String
+Type: Stringify Func
+Purpose: Provide a default string representation of
+a seating type amongst consumers of this layer
+*/
+func (s SeatingType) String() (string) {
+    name := s.Name
+    if name == "" {
+        name = "(unnamed)"
+    }
+    return name + ": " + strconv.Itoa(s.Slots) + " open, " + s.First.Format("15:04") + "-" + s.Last.Format("15:04")
+}
+
+/*
+Name: SeatingTypes
+Type: External App Func
+Purpose: List the seating types open at a venue on a day,
+so table type patterns can be written against the names
+the service actually uses. Sorted by name
+*/
+func (a *AppCtx) SeatingTypes(params SeatingParam) (*SeatingResponse, error) {
+    finder, ok := a.API.(api.Finder)
+    if !ok {
+        return nil, ErrNoFind
+    }
+    if params.Login.Email == "" || params.Login.Password == "" {
+        a.mu.Lock()
+        loginInfo := a.loginInfo
+        a.mu.Unlock()
+        if loginInfo.Email == "" && loginInfo.Password == "" {
+            return nil, ErrNoLogin
+        }
+        params.Login.Email = loginInfo.Email
+        params.Login.Password = loginInfo.Password
+    }
+    loginResp, err := a.API.Login(api.LoginParam(params.Login))
+    if err != nil {
+        return nil, err
+    }
+    findResp, err := finder.Find(api.FindParam{
+        VenueID: params.VenueID,
+        Day: params.Day,
+        PartySize: params.PartySize,
+        LoginResp: *loginResp,
+    })
+    if err != nil {
+        return nil, err
+    }
+    byName := map[string]*SeatingType{}
+    names := []string{}
+    for _, slot := range findResp.Slots {
+        seating, ok := byName[slot.TableType]
+        if !ok {
+            seating = &SeatingType{Name: slot.TableType, First: slot.Time, Last: slot.Time}
+            byName[slot.TableType] = seating
+            names = append(names, slot.TableType)
+        }
+        seating.Slots += 1
+        if slot.Time.Before(seating.First) {
+            seating.First = slot.Time
+        }
+        if slot.Time.After(seating.Last) {
+            seating.Last = slot.Time
+        }
+    }
+    sort.Strings(names)
+    resp := SeatingResponse{VenueName: findResp.VenueName}
+    for _, name := range names {
+        resp.SeatingTypes = append(resp.SeatingTypes, *byName[name])
+    }
+    return &resp, nil
+}
@@ -0,0 +1,55 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "io"
+    "strconv"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+/*
+Name: tracedLogin
+Type: Internal Func
+Purpose: Turn the stored login into login params which
+record their request on an op's trace
+*/
+func tracedLogin(params LoginParam, trace *api.Trace) (api.LoginParam) {
+    login := api.LoginParam(params)
+    login.Trace = trace
+    return login
+}
+
+/*
+Name: OperationTrace
+Type: External App Func
+Purpose: Return the timing of every request an op has made
+so far, oldest first, grouped by attempt. Only the latest
+api.MaxTraceSpans are kept
+*/
+func (a *AppCtx) OperationTrace(id int64) ([]api.Span, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    for _, operation := range a.operations {
+        if operation.ID == id {
+            return operation.Trace.Spans(), nil
+        }
+    }
+    return nil, ErrIdOp
+}
+
+/*
+Name: ExportOperationTrace
+Type: External App Func
+Purpose: Write the trace of an op as OpenTelemetry OTLP/JSON,
+which collectors and trace viewers can import
+*/
+func (a *AppCtx) ExportOperationTrace(id int64, w io.Writer) (error) {
+    spans, err := a.OperationTrace(id)
+    if err != nil {
+        return err
+    }
+    return api.WriteOTLP(w, "operation " + strconv.FormatInt(id, 10), spans)
+}
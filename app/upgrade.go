@@ -0,0 +1,319 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "errors"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+var (
+    ErrNoCancelToken = errors.New("booking has no cancel token, so it can not be upgraded")
+)
+
+const (
+    // Poll interval used while hunting if the op gives none
+    DefaultUpgradeInterval = time.Minute
+)
+
+/*
+Name: Upgrade
+Type: struct
+Purpose: Record of an op trading its booking for one
+higher on its priority lists
+Note: The replacement is always confirmed before the
+earlier booking is cancelled, so if CancelErr is set
+both bookings are still held
+*/
+type Upgrade struct {
+    From        Reservation
+    To          Reservation
+    // Whether the provider refunded the earlier booking
+    Refund      bool
+    CancelErr   error
+}
+
+/*
+Name: String
+Type: Stringify Func
+Purpose: Provide a default string representation of
+an upgrade amongst consumers of this layer
+*/
+func (u Upgrade) String() (string) {
+    str := u.From.ReservationTime.Format("15:04") + " -> " + u.To.ReservationTime.Format("15:04")
+    if u.CancelErr != nil {
+        str += " (cancel of earlier booking failed, both held: " + u.CancelErr.Error() + ")"
+    } else if u.Refund {
+        str += " (refunded)"
+    }
+    return str
+}
+
+/*
+Name: upgradeHunt
+Type: Internal struct
+Purpose: What an op in upgrade mode keeps polling for
+once it holds a booking
+Note: The hunt is for the party size booked only, the op's
+AltPartySizes are not tried. A slot for another party size
+isn't better or worse than the booking on the priority
+lists, so trading one for it is left to the user
+*/
+type upgradeHunt struct {
+    Login            LoginParam
+    VenueID          int64
+    Windows          []api.TimeWindow
+    PartySize        int
+    TableTypes       []api.TableType
+    ExcludeTableTypes []api.TableType
+    Selector         api.SlotSelector
+    FeeLimit         api.FeeLimit
+    Interval         time.Duration
+}
+
+/*
+Name: bookingRank
+Type: Internal Func
+Purpose: Find where a booking sits on the table type and
+window priority lists. A booking not found on a list ranks
+below everything on it
+*/
+func bookingRank(hunt upgradeHunt, booked api.ReserveResponse) (int, int) {
+    tableIdx := 0
+    if len(hunt.TableTypes) != 0 {
+        tableIdx = len(hunt.TableTypes)
+        for i, tableType := range hunt.TableTypes {
+            if tableType == booked.TableType {
+                tableIdx = i
+                break
+            }
+        }
+    }
+    timeIdx := len(hunt.Windows)
+    for i, window := range hunt.Windows {
+        if window.Contains(booked.ReservationTime) {
+            timeIdx = i
+            break
+        }
+    }
+    return tableIdx, timeIdx
+}
+
+/*
+Name: futureWindows
+Type: Internal Func
+Purpose: Keep the windows which haven't fully passed yet
+*/
+func futureWindows(windows []api.TimeWindow) ([]api.TimeWindow) {
+    now := time.Now()
+    future := []api.TimeWindow{}
+    for _, window := range windows {
+        if window.End.After(now) {
+            future = append(future, window)
+        }
+    }
+    return future
+}
+
+/*
+Name: rankProbe
+Type: Internal Func
+Purpose: Stand in for any slot at the given table type
+and window, at the window's preferred time, so slots can
+be compared by where they sit on the priority lists alone
+*/
+func rankProbe(hunt upgradeHunt, tableIdx int, timeIdx int) (api.Candidate) {
+    probe := api.Candidate{TableIdx: tableIdx, WindowIdx: timeIdx}
+    if timeIdx < len(hunt.Windows) {
+        probe.Window = hunt.Windows[timeIdx]
+        probe.Time = probe.Window.Preferred()
+    }
+    return probe
+}
+
+/*
+Name: betterPasses
+Type: Internal Func
+Purpose: Build the reserve requests that can only book
+something ranked above the given spot. Every table type
+and window pair the op's selector ranks above the spot
+is tried, best first, with runs of pairs on the same table
+type sharing a request. For the default selector that is
+a request per table type, holding every window for the
+earlier table types and the earlier windows for the same
+table type. Each pass is tried in order
+*/
+func betterPasses(hunt upgradeHunt, tableIdx int, timeIdx int) ([]api.ReserveParam) {
+    selector := api.ReserveParam{Selector: hunt.Selector}.SlotSelector()
+    booked := rankProbe(hunt, tableIdx, timeIdx)
+    tableCount := len(hunt.TableTypes)
+    if tableCount == 0 {
+        tableCount = 1
+    }
+    now := time.Now()
+    better := []api.Candidate{}
+    for k := 0; k < tableCount; k++ {
+        for i, window := range hunt.Windows {
+            probe := rankProbe(hunt, k, i)
+            if window.End.After(now) && selector.Less(probe, booked) {
+                better = append(better, probe)
+            }
+        }
+    }
+    api.RankCandidates(selector, better)
+
+    passes := []api.ReserveParam{}
+    for i, probe := range better {
+        if i == 0 || probe.TableIdx != better[i-1].TableIdx {
+            pass := api.ReserveParam{
+                VenueID: hunt.VenueID,
+                PartySize: hunt.PartySize,
+                Selector: hunt.Selector,
+                FeeLimit: hunt.FeeLimit,
+                ExcludeTableTypes: hunt.ExcludeTableTypes,
+            }
+            if len(hunt.TableTypes) != 0 {
+                pass.TableTypes = hunt.TableTypes[probe.TableIdx:probe.TableIdx+1]
+            }
+            passes = append(passes, pass)
+        }
+        last := &passes[len(passes)-1]
+        last.TimeWindows = append(last.TimeWindows, probe.Window)
+    }
+    return passes
+}
+
+/*
+Name: huntReservation
+Type: Internal Func
+Purpose: Describe a booking made during a hunt
+*/
+func huntReservation(hunt upgradeHunt, booked api.ReserveResponse) (Reservation) {
+    return Reservation{
+        ReservationID: booked.ReservationID,
+        VenueID: hunt.VenueID,
+        VenueName: booked.VenueName,
+        VenueAddress: booked.VenueAddress,
+        ReservationTime: booked.ReservationTime,
+        PartySize: hunt.PartySize,
+    }
+}
+
+/*
+Name: recordHolding
+Type: Internal App Func
+Purpose: Used by op go threads in upgrade mode to publish
+the booking they hold and any upgrade they made, so it
+shows while the op is still in progress
+*/
+func (a *AppCtx) recordHolding(id int64, holding Reservation, upgrade *Upgrade) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    for i, operation := range a.operations {
+        if operation.ID == id {
+            a.operations[i].Holding = &holding
+            if upgrade != nil {
+                a.operations[i].Upgrades = append(a.operations[i].Upgrades, *upgrade)
+            }
+            return
+        }
+    }
+}
+
+/*
+Name: huntUpgrades
+Type: Internal App Func
+Purpose: Used by op go threads in upgrade mode after their
+first booking. Keeps polling for a better slot, and on
+booking one cancels the booking it replaces. Returns the
+booking held when the hunt ends, which is when nothing
+better can still be booked, the op is cancelled, an
+earlier booking could not be let go of, or a replacement
+book failed without saying whether it went through.
+Note: An error means the hunt stopped early, the returned
+booking is still held either way
+*/
+func (a *AppCtx) huntUpgrades(meta opMeta, hunt upgradeHunt, booked api.ReserveResponse, cancel <-chan bool) (api.ReserveResponse, error) {
+    canceller, ok := a.API.(api.Canceller)
+    if !ok {
+        return booked, api.ErrNoCancel
+    }
+    if hunt.Interval <= 0 {
+        hunt.Interval = DefaultUpgradeInterval
+    }
+    a.recordHolding(meta.ID, huntReservation(hunt, booked), nil)
+    for {
+        if booked.CancelToken == "" {
+            return booked, ErrNoCancelToken
+        }
+        tableIdx, timeIdx := bookingRank(hunt, booked)
+        passes := betterPasses(hunt, tableIdx, timeIdx)
+        if len(passes) == 0 {
+            return booked, nil
+        }
+
+        select {
+        case <-time.After(hunt.Interval):
+        case <-cancel:
+            // a cancelled hunt keeps what it has
+            return booked, nil
+        }
+
+        meta.Trace.StartAttempt()
+        loginResp, err := a.API.Login(tracedLogin(hunt.Login, meta.Trace))
+        if err != nil {
+            // we still hold a booking, so ride out
+            // errors until the next poll
+            meta.Logger.Warn("upgrade login failed", "err", err)
+            continue
+        }
+
+        for _, pass := range passes {
+            pass.TimeWindows, err = a.filterConflictingWindows(meta, pass.TimeWindows)
+            if err != nil {
+                continue
+            }
+            pass.ReservationTimes = preferredTimes(pass.TimeWindows)
+            pass.LoginResp = *loginResp
+            pass.Logger = meta.Logger
+            pass.Trace = meta.Trace
+            reserveResp, err := a.API.Reserve(pass)
+            if errors.Is(err, api.ErrBookUnknown) {
+                // the replacement may be booked as well,
+                // booking more could leave a third table
+                meta.Logger.Warn("upgrade book outcome unknown", "err", err)
+                return booked, err
+            }
+            if err != nil {
+                continue
+            }
+
+            // the replacement is confirmed, only now is
+            // it safe to let go of the earlier booking
+            upgrade := Upgrade{
+                From: huntReservation(hunt, booked),
+                To: huntReservation(hunt, *reserveResp),
+            }
+            cancelResp, err := canceller.Cancel(api.CancelParam{
+                CancelToken: booked.CancelToken,
+                LoginResp: *loginResp,
+            })
+            booked = *reserveResp
+            meta.Logger.Info("upgraded", "from", upgrade.From.ReservationTime, "to", upgrade.To.ReservationTime)
+            if err != nil {
+                // stop here instead of piling up bookings
+                // the user has to sort out by hand
+                meta.Logger.Warn("cancelling replaced booking failed", "err", err)
+                upgrade.CancelErr = api.RedactError(err)
+                a.recordHolding(meta.ID, upgrade.To, &upgrade)
+                return booked, nil
+            }
+            upgrade.Refund = cancelResp.Refund
+            a.recordHolding(meta.ID, upgrade.To, &upgrade)
+            break
+        }
+    }
+}
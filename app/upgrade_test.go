@@ -0,0 +1,42 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "testing"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+func TestBookingRank(t *testing.T) {
+    day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+    early := api.TimeWindow{Start: day.Add(18 * time.Hour), End: day.Add(19 * time.Hour)}
+    late := api.TimeWindow{Start: day.Add(20 * time.Hour), End: day.Add(21 * time.Hour)}
+    hunt := upgradeHunt{
+        Windows: []api.TimeWindow{early, late},
+        TableTypes: []api.TableType{api.DiningRoom, api.Bar},
+    }
+    tests := []struct {
+        name        string
+        hunt        upgradeHunt
+        booked      api.ReserveResponse
+        tableIdx    int
+        timeIdx     int
+    }{
+        {"first on both", hunt, api.ReserveResponse{TableType: api.DiningRoom, ReservationTime: early.Start}, 0, 0},
+        {"last table type", hunt, api.ReserveResponse{TableType: api.Bar, ReservationTime: late.Start}, 1, 1},
+        {"unmatched table type ranks below the list", hunt, api.ReserveResponse{TableType: api.Patio, ReservationTime: early.Start}, 2, 0},
+        {"unmatched time ranks below the list", hunt, api.ReserveResponse{TableType: api.Bar, ReservationTime: day.Add(23 * time.Hour)}, 1, 2},
+        {"no table types", upgradeHunt{Windows: hunt.Windows}, api.ReserveResponse{ReservationTime: late.End}, 0, 1},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            tableIdx, timeIdx := bookingRank(tt.hunt, tt.booked)
+            if tableIdx != tt.tableIdx || timeIdx != tt.timeIdx {
+                t.Errorf("rank = (%d, %d), want (%d, %d)", tableIdx, timeIdx, tt.tableIdx, tt.timeIdx)
+            }
+        })
+    }
+}
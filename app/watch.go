@@ -0,0 +1,413 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "errors"
+    "strconv"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+var (
+    ErrNoFind = errors.New("service can not list open slots")
+    ErrWindow = errors.New("watch window ends before it starts")
+    ErrWatchDay = errors.New("watch window must start and end on the same day")
+    ErrWatchInterval = errors.New("watch repeat interval must be positive")
+    ErrNotWatch = errors.New("operation is not a watch")
+)
+
+/*
+Name: WatchParam
+Type: App api func input parameters
+Purpose: Provide a means to make a watch
+operation by a consumer
+Note: Start and End bound the slot times watched and
+must fall on the same day. The watch runs until End
+passes or it is cancelled
+*/
+type WatchParam struct {
+    Login            LoginParam
+    VenueID          int64
+    PartySize        int
+    Start            time.Time
+    End              time.Time
+    RepeatInterval   time.Duration
+    // If set, only slots whose seating matches one
+    // of these are watched
+    TableTypes       []api.TableType
+}
+
+/*
+Name: WatchResponse
+Type: struct
+Purpose: Define the data that should be returned when
+a watch runs to the end of its window
+*/
+type WatchResponse struct {
+    End         time.Time
+    // Slots open at the last poll
+    Slots       []api.Slot
+}
+
+/*
+Name: Time
+Type: interface method
+Purpose: Satisfy the Timetable interface
+*/
+func (r WatchResponse) Time() (time.Time) {
+    return r.End
+}
+
+/*
+Name: SlotChange
+Type: struct
+Purpose: Record of a slot opening or closing between
+two polls of a watch
+*/
+type SlotChange struct {
+    // SlotAppearedEventType or SlotDisappearedEventType
+    Type        EventType
+    Slot        api.Slot
+    At          time.Time
+    NotifyErr   error
+    HookRuns    []HookRun
+}
+
+/*
+Name: String
+Type: Stringify Func
+Purpose: Provide a default string representation of
+a slot change amongst consumers of this layer
+*/
+func (c SlotChange) String() (string) {
+    str := c.At.Format("15:04:05") + " "
+    if c.Type == SlotAppearedEventType {
+        str += "appeared "
+    } else {
+        str += "disappeared "
+    }
+    str += c.Slot.Time.Format("15:04")
+    if c.Slot.TableType != "" {
+        str += " " + c.Slot.TableType
+    }
+    if c.NotifyErr != nil {
+        str += " (notify failed: " + c.NotifyErr.Error() + ")"
+    }
+    return str
+}
+
+/*
+Name: slotKey
+Type: Internal Func
+Purpose: Identify a slot across polls
+*/
+func slotKey(slot api.Slot) (string) {
+    return slot.Time.Format("15:04") + "|" + slot.TableType
+}
+
+/*
+Name: watchedSlots
+Type: Internal Func
+Purpose: Keep the slots inside a watch's window and
+seating filter
+*/
+func watchedSlots(params WatchParam, slots []api.Slot) ([]api.Slot) {
+    watched := []api.Slot{}
+    for _, slot := range slots {
+        if slot.Time.Before(params.Start) || slot.Time.After(params.End) {
+            continue
+        }
+        if _, ok := api.MatchSeating(slot.TableType, params.TableTypes, nil); !ok {
+            continue
+        }
+        watched = append(watched, slot)
+    }
+    return watched
+}
+
+/*
+Name: diffSlots
+Type: Internal Func
+Purpose: Compare two polls, returning the changes
+in the order the slots were listed
+*/
+func diffSlots(prev []api.Slot, curr []api.Slot, at time.Time) ([]SlotChange) {
+    changes := []SlotChange{}
+    prevKeys := map[string]bool{}
+    for _, slot := range prev {
+        prevKeys[slotKey(slot)] = true
+    }
+    currKeys := map[string]bool{}
+    for _, slot := range curr {
+        currKeys[slotKey(slot)] = true
+        if !prevKeys[slotKey(slot)] {
+            changes = append(changes, SlotChange{Type: SlotAppearedEventType, Slot: slot, At: at})
+        }
+    }
+    for _, slot := range prev {
+        if !currKeys[slotKey(slot)] {
+            changes = append(changes, SlotChange{Type: SlotDisappearedEventType, Slot: slot, At: at})
+        }
+    }
+    return changes
+}
+
+/*
+Name: ScheduleWatchOperation
+Type: External App Func
+Purpose: Used to schedule a watch operation, which polls
+the open slots at a venue and reports slots appearing and
+disappearing without ever booking one. Returns ID
+*/
+func (a *AppCtx) ScheduleWatchOperation(params WatchParam) (int64, error) {
+    if _, ok := a.API.(api.Finder); !ok {
+        return 0, ErrNoFind
+    }
+    if params.End.Before(params.Start) {
+        return 0, ErrWindow
+    }
+    // a watch finds slots on one day only
+    startYear, startMonth, startDay := params.Start.Date()
+    endYear, endMonth, endDay := params.End.In(params.Start.Location()).Date()
+    if startYear != endYear || startMonth != endMonth || startDay != endDay {
+        return 0, ErrWatchDay
+    }
+    if params.RepeatInterval <= 0 {
+        return 0, ErrWatchInterval
+    }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    id := a.idGen
+    a.idGen += 1
+    if (params.Login.Email == "" || params.Login.Password == "") {
+        if(a.loginInfo.Email == "" && a.loginInfo.Password == "") {
+            return 0, ErrNoLogin
+        }
+        params.Login.Email = a.loginInfo.Email
+        params.Login.Password = a.loginInfo.Password
+    }
+    cancel := make(chan bool)
+    output := make(chan OperationResult, 1)
+    // a watch never books, so it leaves ReservationTimes
+    // empty to stay out of conflict checks
+    a.operations = append(a.operations, Operation{
+        ID: id,
+        Cancel: cancel,
+        Output: output,
+        Status: InProgressStatusType,
+        VenueID: params.VenueID,
+        PartySize: params.PartySize,
+        Watch: true,
+        Trace: api.NewOperationTrace(id),
+        delivery: make(chan OperationResult, 1),
+    })
+    meta := a.newOpMeta(a.operations[len(a.operations)-1], nil)
+    meta.Logger.Info("operation scheduled", "kind", "watch")
+    go a.watch(meta, params, cancel, output)
+    return id, nil
+}
+
+/*
+Name: recordWatchPoll
+Type: Internal App Func
+Purpose: Used by watch go threads to publish the slots
+open at the latest poll, the changes since the last one,
+and the poll error if it failed. Returns the index the
+changes start at among the op's changes
+*/
+func (a *AppCtx) recordWatchPoll(id int64, slots []api.Slot, changes []SlotChange, err error) (int) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    for i, operation := range a.operations {
+        if operation.ID == id {
+            first := len(operation.SlotChanges)
+            a.operations[i].WatchErr = api.RedactError(err)
+            if err == nil {
+                a.operations[i].OpenSlots = slots
+                a.operations[i].SlotChanges = append(a.operations[i].SlotChanges, changes...)
+            }
+            return first
+        }
+    }
+    return 0
+}
+
+/*
+Name: deliverSlotChanges
+Type: Internal App Func
+Purpose: Hand the events of one poll's slot changes to the
+op's notifiers and hooks once the previous poll's are out,
+so they go in order, then record how that went on the
+changes starting at first. Closes done when finished
+*/
+func (a *AppCtx) deliverSlotChanges(meta opMeta, first int, changes []SlotChange, prev <-chan struct{}, done chan<- struct{}) {
+    defer close(done)
+    <-prev
+    for i := range changes {
+        e := newSlotEvent(meta, changes[i])
+        changes[i].NotifyErr = notifyAll(meta.Notifiers, e)
+        if changes[i].NotifyErr != nil {
+            meta.Logger.Warn("notifying failed", "event", e.Type, "err", changes[i].NotifyErr)
+        }
+        changes[i].HookRuns = runHooks(meta.Hooks, e)
+    }
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    for i, operation := range a.operations {
+        if operation.ID == meta.ID {
+            for j := range changes {
+                if first + j < len(operation.SlotChanges) {
+                    a.operations[i].SlotChanges[first + j].NotifyErr = changes[j].NotifyErr
+                    a.operations[i].SlotChanges[first + j].HookRuns = changes[j].HookRuns
+                }
+            }
+            return
+        }
+    }
+}
+
+/*
+Name: watch
+Type: Internal App Func
+Purpose: This function is intended to run on a separate thread, and
+polls the open slots on the given interval until the window passes.
+The first poll reports every open slot as appeared. It logs in once,
+and again only when the login may have expired or a poll was refused
+for it. Slot events are delivered in the background, in order, so a
+slow notifier or hook never holds up a poll or a cancel
+*/
+func (a *AppCtx) watch(meta opMeta, params WatchParam, cancel <-chan bool, output chan<- OperationResult) {
+    finder := a.API.(api.Finder)
+    // services which only list slots near a time
+    // look around the middle of the window
+    day := params.Start.Add(params.End.Sub(params.Start) / 2)
+    open := []api.Slot{}
+    authExpire := a.API.AuthMinExpire()
+    var loginResp *api.LoginResponse
+    var loginTime time.Time
+    // closed once every slot event so far is delivered
+    delivered := make(chan struct{})
+    close(delivered)
+    for params.End.After(time.Now()) {
+        meta.Trace.StartAttempt()
+        var err error
+        if loginResp == nil || (authExpire > 0 && time.Since(loginTime) >= authExpire) {
+            loginTime = time.Now()
+            loginResp, err = a.API.Login(tracedLogin(params.Login, meta.Trace))
+            if err != nil {
+                loginResp = nil
+            }
+        }
+        if err == nil {
+            var findResp *api.FindResponse
+            findResp, err = finder.Find(api.FindParam{
+                VenueID: params.VenueID,
+                Day: day,
+                PartySize: params.PartySize,
+                Logger: meta.Logger,
+                Trace: meta.Trace,
+                LoginResp: *loginResp,
+            })
+            if err == nil {
+                curr := watchedSlots(params, findResp.Slots)
+                changes := diffSlots(open, curr, time.Now())
+                open = curr
+                meta.Logger.Debug("watch polled", "open", len(open), "changes", len(changes))
+                first := a.recordWatchPoll(meta.ID, open, changes, nil)
+                if len(changes) != 0 {
+                    prev := delivered
+                    next := make(chan struct{})
+                    go a.deliverSlotChanges(meta, first, changes, prev, next)
+                    delivered = next
+                }
+            }
+        }
+        if api.ErrorType(err) == string(api.AuthClass) {
+            // the token was refused, log in afresh
+            loginResp = nil
+        }
+        if err != nil {
+            // keep watching through errors, the next
+            // poll may well succeed
+            meta.Logger.Warn("watch poll failed", "err", err)
+            a.recordWatchPoll(meta.ID, nil, nil, err)
+        }
+
+        // the outcome goes out after the slot events
+        meta.Delivered = delivered
+        select {
+        case <-time.After(params.RepeatInterval):
+        case <-cancel:
+            a.finishOperation(meta, output, OperationResult{Response: nil, Err: ErrCancel})
+            return
+        }
+    }
+    meta.Delivered = delivered
+    a.finishOperation(meta, output, OperationResult{
+        Response: WatchResponse{End: params.End, Slots: open},
+        Err: nil,
+    })
+}
+
+/*
+Name: newSlotEvent
+Type: Internal Func
+Purpose: Build the event describing a slot change
+*/
+func newSlotEvent(meta opMeta, change SlotChange) (Event) {
+    slotTime := change.Slot.Time
+    return Event{
+        Type: change.Type,
+        OperationID: meta.ID,
+        VenueID: meta.VenueID,
+        PartySize: meta.PartySize,
+        ReservationTimes: meta.ReservationTimes,
+        SlotTime: &slotTime,
+        TableType: change.Slot.TableType,
+        Timestamp: change.At.UTC(),
+    }
+}
+
+/*
+Name: OperationSlotChanges
+Type: External App Func
+Purpose: Return the slot changes a watch op has seen
+so far, and the slots open at its latest poll
+*/
+func (a *AppCtx) OperationSlotChanges(id int64) ([]SlotChange, []api.Slot, error) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    err := a.updateOperationResult(id)
+    if err != nil {
+        return nil, nil, err
+    }
+    for _, operation := range a.operations {
+        if operation.ID == id {
+            if !operation.Watch {
+                return nil, nil, ErrNotWatch
+            }
+            changes := append([]SlotChange{}, operation.SlotChanges...)
+            slots := append([]api.Slot{}, operation.OpenSlots...)
+            return changes, slots, nil
+        }
+    }
+    return nil, nil, ErrIdOp
+}
+
+/*
+Name: watchToString
+Type: Internal Func
+Purpose: Stringify the live state of a watch op
+for OperationsToString
+*/
+func watchToString(operation Operation) (string) {
+    str := "\n\tOpen Slots: " + strconv.Itoa(len(operation.OpenSlots))
+    str += ", Changes Seen: " + strconv.Itoa(len(operation.SlotChanges))
+    if operation.WatchErr != nil {
+        str += "\n\tLast Poll: " + operation.WatchErr.Error()
+    }
+    return str
+}
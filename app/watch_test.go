@@ -0,0 +1,228 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package app
+
+import (
+    "errors"
+    "sync"
+    "testing"
+    "time"
+    "github.com/21Bruce/resolved-server/api"
+)
+
+/*
+Name: fakeFinder
+Type: Internal Test Struct
+Purpose: An api which lists the same slot on every find,
+counting the logins and finds made against it
+*/
+type fakeFinder struct {
+    mu          sync.Mutex
+    logins      int
+    finds       int
+}
+
+func (f *fakeFinder) Login(params api.LoginParam) (*api.LoginResponse, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.logins += 1
+    return &api.LoginResponse{ID: 1}, nil
+}
+
+func (f *fakeFinder) Search(params api.SearchParam) (*api.SearchResponse, error) {
+    return nil, api.ErrNoTable
+}
+
+func (f *fakeFinder) Reserve(params api.ReserveParam) (*api.ReserveResponse, error) {
+    return nil, api.ErrNoTable
+}
+
+func (f *fakeFinder) AuthMinExpire() (time.Duration) {
+    return time.Hour
+}
+
+func (f *fakeFinder) Find(params api.FindParam) (*api.FindResponse, error) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    f.finds += 1
+    return &api.FindResponse{Slots: []api.Slot{{Time: params.Day, TableType: "Dining Room"}}}, nil
+}
+
+func (f *fakeFinder) counts() (int, int) {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.logins, f.finds
+}
+
+/*
+Name: eventRecorder
+Type: Internal Test Struct
+Purpose: A notifier keeping the events it was handed
+*/
+type eventRecorder struct {
+    mu          sync.Mutex
+    events      []Event
+}
+
+func (r *eventRecorder) Notify(e Event) (error) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.events = append(r.events, e)
+    return nil
+}
+
+func (r *eventRecorder) types() ([]EventType) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    types := []EventType{}
+    for _, e := range r.events {
+        types = append(types, e.Type)
+    }
+    return types
+}
+
+func TestScheduleWatchValidation(t *testing.T) {
+    day := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+    login := LoginParam{Email: "a@example.com", Password: "watch-password"}
+    tests := []struct {
+        name        string
+        params      WatchParam
+        wantErr     error
+    }{
+        {"ends before it starts", WatchParam{Login: login, Start: day.Add(20 * time.Hour), End: day.Add(18 * time.Hour), RepeatInterval: time.Minute}, ErrWindow},
+        {"spans two days", WatchParam{Login: login, Start: day.Add(20 * time.Hour), End: day.Add(26 * time.Hour), RepeatInterval: time.Minute}, ErrWatchDay},
+        {"zero interval", WatchParam{Login: login, Start: day.Add(18 * time.Hour), End: day.Add(20 * time.Hour)}, ErrWatchInterval},
+        {"negative interval", WatchParam{Login: login, Start: day.Add(18 * time.Hour), End: day.Add(20 * time.Hour), RepeatInterval: -time.Minute}, ErrWatchInterval},
+    }
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            a := &AppCtx{API: &fakeFinder{}}
+            _, err := a.ScheduleWatchOperation(tt.params)
+            if !errors.Is(err, tt.wantErr) {
+                t.Errorf("err = %v, want %v", err, tt.wantErr)
+            }
+        })
+    }
+}
+
+func TestWatchDoesNotWaitForNotifiers(t *testing.T) {
+    start := time.Now()
+    end := start.Add(time.Minute)
+    if end.Day() != start.Day() {
+        t.Skip("window would cross midnight")
+    }
+    finder := &fakeFinder{}
+    blocking := blockingNotifier{release: make(chan struct{})}
+    recorder := &eventRecorder{}
+    a := &AppCtx{API: finder}
+    a.AddNotifier(blocking)
+    a.AddNotifier(recorder)
+    id, err := a.ScheduleWatchOperation(WatchParam{
+        Login: LoginParam{Email: "a@example.com", Password: "watch-password"},
+        Start: start,
+        End: end,
+        RepeatInterval: 5 * time.Millisecond,
+    })
+    if err != nil {
+        t.Fatalf("ScheduleWatchOperation: %v", err)
+    }
+
+    // the first poll's slot event is stuck in the notifier,
+    // the polls after it must go on regardless
+    deadline := time.Now().Add(5 * time.Second)
+    for _, finds := finder.counts(); finds < 3; _, finds = finder.counts() {
+        if time.Now().After(deadline) {
+            t.Fatalf("finds = %d, polling waited on a notifier", finds)
+        }
+        time.Sleep(time.Millisecond)
+    }
+    if err := a.CancelOperation(id); err != nil {
+        t.Fatalf("CancelOperation: %v", err)
+    }
+    for {
+        a.mu.Lock()
+        a.updateOperationResult(id)
+        finished := a.operations[0].Result != nil
+        a.mu.Unlock()
+        if finished {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatal("cancel waited on a notifier")
+        }
+        time.Sleep(time.Millisecond)
+    }
+    if types := recorder.types(); len(types) != 0 {
+        t.Fatalf("events %v delivered past a blocked notifier", types)
+    }
+
+    // once released, the slot event goes out before the outcome
+    close(blocking.release)
+    for {
+        types := recorder.types()
+        if len(types) == 2 {
+            if types[0] != SlotAppearedEventType || types[1] != CancelEventType {
+                t.Errorf("events = %v, want the slot then the cancel", types)
+            }
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("events = %v, want 2", types)
+        }
+        time.Sleep(time.Millisecond)
+    }
+    changes, _, err := a.OperationSlotChanges(id)
+    if err != nil {
+        t.Fatalf("OperationSlotChanges: %v", err)
+    }
+    if len(changes) != 1 || !errors.Is(changes[0].NotifyErr, ErrWebhookStatus) {
+        t.Errorf("changes = %v, want the notify error recorded on the slot change", changes)
+    }
+}
+
+func TestWatchLogsInOnce(t *testing.T) {
+    start := time.Now()
+    end := start.Add(100 * time.Millisecond)
+    if end.Day() != start.Day() {
+        t.Skip("window would cross midnight")
+    }
+    finder := &fakeFinder{}
+    recorder := &eventRecorder{}
+    a := &AppCtx{API: finder}
+    a.AddNotifier(recorder)
+    id, err := a.ScheduleWatchOperation(WatchParam{
+        Login: LoginParam{Email: "a@example.com", Password: "watch-password"},
+        Start: start,
+        End: end,
+        RepeatInterval: 10 * time.Millisecond,
+    })
+    if err != nil {
+        t.Fatalf("ScheduleWatchOperation: %v", err)
+    }
+
+    deadline := time.Now().Add(5 * time.Second)
+    for {
+        types := recorder.types()
+        if len(types) != 0 && types[len(types)-1] == WatchEndedEventType {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("watch %d never ended, events %v", id, types)
+        }
+        time.Sleep(5 * time.Millisecond)
+    }
+    logins, finds := finder.counts()
+    if finds < 2 {
+        t.Fatalf("finds = %d, want at least 2 polls", finds)
+    }
+    if logins != 1 {
+        t.Errorf("logins = %d over %d polls, want 1", logins, finds)
+    }
+    for _, eventType := range recorder.types() {
+        if eventType == SuccessEventType {
+            t.Error("watch reported operation.succeeded")
+        }
+    }
+}
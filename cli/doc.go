@@ -50,6 +50,10 @@ How To Use:
 
             - This struct defines what command strings should be 
               matched, what flags they take, and how to handle them.
+              A Name may hold several space separated words, like
+              "notify test", in which case each word must match
+              one leading token of the input. If the names of 
+              several commands match, the longest one is used.
               The description section adds an optional description,
               which is severely fucked up in the current arch,
               and I'll probably fix that soon. 
@@ -256,12 +256,34 @@ func (pc *ParseCtx) Parse(in string) (string, error) {
         return "", ErrNoCmd
     }
 
-    for _, cmd := range pc.Commands {
-        if cmd.Name == tokens[0] {
-            return pc.parseFlags(cmd, tokens[1:])
+    // command names may be multiple words, like
+    // "notify test", each matching one token. When 
+    // several commands match, the longest name wins
+    // so "hook output" is not taken for "hook"
+    matchIdx := -1
+    matchLen := 0
+    for i, cmd := range pc.Commands {
+        nameTokens := strings.Fields(cmd.Name)
+        if len(nameTokens) > len(tokens) || len(nameTokens) <= matchLen {
+            continue
+        }
+        didMatch := true
+        for j, nameToken := range nameTokens {
+            if nameToken != tokens[j] {
+                didMatch = false
+                break
+            }
+        }
+        if didMatch {
+            matchIdx = i
+            matchLen = len(nameTokens)
         }
     }
 
-    return "", ErrNoCmd
+    if matchIdx == -1 {
+        return "", ErrNoCmd
+    }
+
+    return pc.parseFlags(pc.Commands[matchIdx], tokens[matchLen:])
 }
 
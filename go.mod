@@ -1,6 +1,6 @@
 module github.com/21Bruce/resolved-server
 
-go 1.20
+go 1.21
 
 require (
 	github.com/andybalholm/brotli v1.1.1 // indirect
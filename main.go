@@ -5,20 +5,37 @@ Created On: Aug 12, 2023
 package main
 
 import (
+    "github.com/21Bruce/resolved-server/api"
     "github.com/21Bruce/resolved-server/api/resy"
     "github.com/21Bruce/resolved-server/app"
     "github.com/21Bruce/resolved-server/runnable/cli"
+    "log/slog"
     "os"
 )
 
 func main() {
+    // warnings only by default, so the REPL stays
+    // readable, the 'loglevel' command changes this
+    logHandler, err := app.NewLogHandler(os.Stderr, app.TextLogFormat, slog.LevelWarn)
+    if err != nil {
+        panic(err)
+    }
+    // secrets are scrubbed before the handler sees them,
+    // whichever output it is set to
+    logger := slog.New(api.NewRedactHandler(logHandler))
     resy_api := resy.GetDefaultAPI()
-    appCtx := app.AppCtx{API: &resy_api}
+    resy_api.Logger = logger
     cli := cli.ResolvedCLI{
-        AppCtx: appCtx,
+        AppCtx: app.AppCtx{API: &resy_api, Logger: logger},
         In: os.Stdin,
         Out: os.Stdout,
         Err: os.Stderr,
+        LogHandler: logHandler,
+        // every service's shared client records through
+        // it, resy and opentable alike, so the 'record'
+        // command can save an operation's requests for
+        // a bug report
+        Recorder: api.DefaultRecorder,
     }
     cli.Run()
 }
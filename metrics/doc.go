@@ -0,0 +1,31 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+
+**********************************************************************
+
+General Purpose: 
+
+    The metrics pkg keeps counters, histograms and gauges and writes
+    them in the Prometheus text exposition format, without pulling
+    in the Prometheus client library.
+
+**********************************************************************
+
+How To Use:
+
+    A Registry holds metric families by name. Counter and Histogram
+    return the family registered under a name, making it the first
+    time, so they are called once at package level and the result
+    shared. Label values are passed in the order the labels were
+    registered, and calls with the wrong number of values are
+    ignored. GaugeFunc registers a gauge read from a callback each
+    time the registry is written.
+
+    The api and app layers record to the Default registry. WriteText
+    writes a registry, Handler serves it over HTTP and Serve starts
+    a background server for it at /metrics.
+
+**********************************************************************
+*/
+package metrics
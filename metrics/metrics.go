@@ -0,0 +1,416 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package metrics
+
+import (
+    "io"
+    "math"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+/*
+Name: DefaultBuckets
+Type: External Var
+Purpose: Histogram bucket upper bounds in seconds, suited
+to HTTP requests to a reservation service
+*/
+var DefaultBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+/*
+Name: Default
+Type: External Var
+Purpose: The registry the api and app layers record to
+*/
+var Default = NewRegistry()
+
+/*
+Name: Registry
+Type: External Struct
+Purpose: Hold a set of metric families and write them
+in the Prometheus text exposition format
+*/
+type Registry struct {
+    mu          sync.Mutex
+    families    map[string]family
+}
+
+/*
+Name: family
+Type: Internal Interface
+Purpose: A named metric with its samples, as written
+to the text format
+*/
+type family interface {
+    write(w io.Writer) (error)
+}
+
+/*
+Name: NewRegistry
+Type: External Func
+Purpose: Make an empty registry
+*/
+func NewRegistry() (*Registry) {
+    return &Registry{families: map[string]family{}}
+}
+
+/*
+Name: Counter
+Type: External Struct
+Purpose: A value per set of label values that only
+goes up
+*/
+type Counter struct {
+    name        string
+    help        string
+    labels      []string
+    mu          sync.Mutex
+    values      map[string]float64
+}
+
+/*
+Name: Counter
+Type: External Func
+Purpose: Return the counter registered under name, making
+it if there isn't one. Counters are made once at package
+level and shared
+*/
+func (r *Registry) Counter(name string, help string, labels ...string) (*Counter) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if c, ok := r.families[name].(*Counter); ok {
+        return c
+    }
+    c := &Counter{name: name, help: help, labels: labels, values: map[string]float64{}}
+    r.families[name] = c
+    return c
+}
+
+/*
+Name: Inc
+Type: External Func
+Purpose: Add one to the counter for the label values,
+given in the order the labels were registered
+*/
+func (c *Counter) Inc(labelValues ...string) {
+    c.Add(1, labelValues...)
+}
+
+/*
+Name: Add
+Type: External Func
+Purpose: Add v to the counter for the label values,
+ignoring negative v and the wrong number of values
+*/
+func (c *Counter) Add(v float64, labelValues ...string) {
+    if v < 0 || len(labelValues) != len(c.labels) {
+        return
+    }
+    key := labelKey(labelValues)
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.values[key] += v
+}
+
+/*
+Name: Value
+Type: External Func
+Purpose: Return the counter for the label values
+*/
+func (c *Counter) Value(labelValues ...string) (float64) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.values[labelKey(labelValues)]
+}
+
+/*
+Name: write
+Type: family method
+Purpose: Satisfy the family interface
+*/
+func (c *Counter) write(w io.Writer) (error) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    str := header(c.name, c.help, "counter")
+    for _, key := range sortedKeys(c.values) {
+        str += c.name + labelString(c.labels, splitKey(key), "", "") + " " + formatValue(c.values[key]) + "\n"
+    }
+    _, err := io.WriteString(w, str)
+    return err
+}
+
+/*
+Name: Histogram
+Type: External Struct
+Purpose: Counts of observed values falling under each of
+a set of bucket bounds, per set of label values
+*/
+type Histogram struct {
+    name        string
+    help        string
+    labels      []string
+    buckets     []float64
+    mu          sync.Mutex
+    series      map[string]*histogramSeries
+}
+
+/*
+Name: histogramSeries
+Type: Internal Struct
+Purpose: The samples of a histogram for one set of
+label values
+*/
+type histogramSeries struct {
+    // Observations at or under each bucket bound,
+    // not cumulative
+    counts      []uint64
+    count       uint64
+    sum         float64
+}
+
+/*
+Name: Histogram
+Type: External Func
+Purpose: Return the histogram registered under name,
+making it with the given bucket bounds if there isn't one
+*/
+func (r *Registry) Histogram(name string, help string, buckets []float64, labels ...string) (*Histogram) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    if h, ok := r.families[name].(*Histogram); ok {
+        return h
+    }
+    sorted := append([]float64(nil), buckets...)
+    sort.Float64s(sorted)
+    h := &Histogram{name: name, help: help, labels: labels, buckets: sorted, series: map[string]*histogramSeries{}}
+    r.families[name] = h
+    return h
+}
+
+/*
+Name: Observe
+Type: External Func
+Purpose: Record a value for the label values, ignoring
+the wrong number of values
+*/
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+    if len(labelValues) != len(h.labels) {
+        return
+    }
+    key := labelKey(labelValues)
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    series, ok := h.series[key]
+    if !ok {
+        series = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+        h.series[key] = series
+    }
+    for i, bound := range h.buckets {
+        if v <= bound {
+            series.counts[i] += 1
+            break
+        }
+    }
+    series.count += 1
+    series.sum += v
+}
+
+/*
+Name: write
+Type: family method
+Purpose: Satisfy the family interface
+*/
+func (h *Histogram) write(w io.Writer) (error) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    keys := make([]string, 0, len(h.series))
+    for key := range h.series {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    str := header(h.name, h.help, "histogram")
+    for _, key := range keys {
+        series := h.series[key]
+        values := splitKey(key)
+        var cumulative uint64
+        for i, bound := range h.buckets {
+            cumulative += series.counts[i]
+            str += h.name + "_bucket" + labelString(h.labels, values, "le", formatValue(bound)) + " " + strconv.FormatUint(cumulative, 10) + "\n"
+        }
+        str += h.name + "_bucket" + labelString(h.labels, values, "le", "+Inf") + " " + strconv.FormatUint(series.count, 10) + "\n"
+        str += h.name + "_sum" + labelString(h.labels, values, "", "") + " " + formatValue(series.sum) + "\n"
+        str += h.name + "_count" + labelString(h.labels, values, "", "") + " " + strconv.FormatUint(series.count, 10) + "\n"
+    }
+    _, err := io.WriteString(w, str)
+    return err
+}
+
+/*
+Name: gaugeFunc
+Type: Internal Struct
+Purpose: A gauge whose values are read from a callback
+each time the registry is written
+*/
+type gaugeFunc struct {
+    name        string
+    help        string
+    label       string
+    fn          func() (map[string]float64)
+}
+
+/*
+Name: GaugeFunc
+Type: External Func
+Purpose: Register a gauge whose value per label value is
+read from fn each time the registry is written, replacing
+any gauge registered under the same name
+*/
+func (r *Registry) GaugeFunc(name string, help string, label string, fn func() (map[string]float64)) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.families[name] = &gaugeFunc{name: name, help: help, label: label, fn: fn}
+}
+
+/*
+Name: write
+Type: family method
+Purpose: Satisfy the family interface
+*/
+func (g *gaugeFunc) write(w io.Writer) (error) {
+    values := g.fn()
+    str := header(g.name, g.help, "gauge")
+    for _, key := range sortedKeys(values) {
+        str += g.name + labelString([]string{g.label}, []string{key}, "", "") + " " + formatValue(values[key]) + "\n"
+    }
+    _, err := io.WriteString(w, str)
+    return err
+}
+
+/*
+Name: WriteText
+Type: External Func
+Purpose: Write every metric in the registry in the
+Prometheus text exposition format, sorted by name
+*/
+func (r *Registry) WriteText(w io.Writer) (error) {
+    r.mu.Lock()
+    names := make([]string, 0, len(r.families))
+    for name := range r.families {
+        names = append(names, name)
+    }
+    families := make([]family, 0, len(names))
+    sort.Strings(names)
+    for _, name := range names {
+        families = append(families, r.families[name])
+    }
+    r.mu.Unlock()
+    for _, f := range families {
+        if err := f.write(w); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+/*
+Name: header
+Type: Internal Func
+Purpose: Return the HELP and TYPE lines of a family
+*/
+func header(name string, help string, kind string) (string) {
+    help = strings.ReplaceAll(help, `\`, `\\`)
+    help = strings.ReplaceAll(help, "\n", `\n`)
+    return "# HELP " + name + " " + help + "\n# TYPE " + name + " " + kind + "\n"
+}
+
+/*
+Name: labelString
+Type: Internal Func
+Purpose: Return the {a="b",...} part of a sample line,
+with an extra label appended if extraName is set, or
+nothing if there are no labels
+*/
+func labelString(names []string, values []string, extraName string, extraValue string) (string) {
+    pairs := []string{}
+    for i, name := range names {
+        if i < len(values) {
+            pairs = append(pairs, name + `="` + escapeLabel(values[i]) + `"`)
+        }
+    }
+    if extraName != "" {
+        pairs = append(pairs, extraName + `="` + escapeLabel(extraValue) + `"`)
+    }
+    if len(pairs) == 0 {
+        return ""
+    }
+    return "{" + strings.Join(pairs, ",") + "}"
+}
+
+/*
+Name: escapeLabel
+Type: Internal Func
+Purpose: Escape a label value for the text format
+*/
+func escapeLabel(v string) (string) {
+    v = strings.ReplaceAll(v, `\`, `\\`)
+    v = strings.ReplaceAll(v, `"`, `\"`)
+    return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+/*
+Name: formatValue
+Type: Internal Func
+Purpose: Format a sample value for the text format
+*/
+func formatValue(v float64) (string) {
+    switch {
+    case math.IsInf(v, 1):
+        return "+Inf"
+    case math.IsInf(v, -1):
+        return "-Inf"
+    }
+    return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Label values are joined on a byte that can't
+// show up in them to key a series
+const labelSep = "\xff"
+
+/*
+Name: labelKey
+Type: Internal Func
+Purpose: Join label values into a series key
+*/
+func labelKey(values []string) (string) {
+    return strings.Join(values, labelSep)
+}
+
+/*
+Name: splitKey
+Type: Internal Func
+Purpose: Split a series key back into label values
+*/
+func splitKey(key string) ([]string) {
+    if key == "" {
+        return nil
+    }
+    return strings.Split(key, labelSep)
+}
+
+/*
+Name: sortedKeys
+Type: Internal Func
+Purpose: Return the keys of a value map in order
+*/
+func sortedKeys(values map[string]float64) ([]string) {
+    keys := make([]string, 0, len(values))
+    for key := range values {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    return keys
+}
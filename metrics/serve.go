@@ -0,0 +1,47 @@
+/*
+Author: Bruce Jagid
+Created On: Oct 18, 2026
+*/
+package metrics
+
+import (
+    "net"
+    "net/http"
+    "time"
+)
+
+/*
+Name: Handler
+Type: External Func
+Purpose: Return an http.Handler serving the registry in
+the Prometheus text exposition format
+*/
+func (r *Registry) Handler() (http.Handler) {
+    return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+        w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+        r.WriteText(w)
+    })
+}
+
+/*
+Name: Serve
+Type: External Func
+Purpose: Start serving the registry at /metrics on addr in
+the background. Returns once the address is listened on,
+close the returned server to stop
+*/
+func Serve(addr string, r *Registry) (*http.Server, error) {
+    listener, err := net.Listen("tcp", addr)
+    if err != nil {
+        return nil, err
+    }
+    mux := http.NewServeMux()
+    mux.Handle("/metrics", r.Handler())
+    server := &http.Server{
+        Addr: listener.Addr().String(),
+        Handler: mux,
+        ReadHeaderTimeout: 10 * time.Second,
+    }
+    go server.Serve(listener)
+    return server, nil
+}
@@ -28,6 +28,17 @@ How To Use(In Code):
     being that this CLI pkg can be easily repurposed between external
     APIs. Although the opentable go API is not complete yet, its 
     partial development has already yielded difficulties for this pkg.
+    An optional LogHandler, the app.LogHandler behind the loggers
+    given to the AppCtx and API, lets the 'loglevel' command change
+    what is logged and where. Everything the CLI prints is scrubbed
+    of secrets with api.Redact, and main wraps the log handler with
+    api.NewRedactHandler so log files are too. When a command fails
+    because the service turned a request down, the start of the
+    service's response is printed under the error. An optional
+    Recorder, the api.Recorder under the API's client, lets the
+    'record' command save the requests an operation made to a
+    cassette file. main sets it to api.DefaultRecorder, which every
+    service's shared client records through.
 
 **********************************************************************
 
@@ -64,44 +75,121 @@ How to Use(On Computer):
             specify restaurants and a piece of data
             that must be sent in a reservation command
 
-        4. rats [-v venue-id] [-ps party-size] [-resD reservation-day] [-resT reservation-times] [-reqD request-date]
+        4. rats [-v venue-id] [-ps party-size] [-resD reservation-day] [-resT reservation-times] [-reqD request-date] [-u upgrade] [-ui upgrade-interval] [-t table] [-xt exclude-table] [-sel selector] [-mf max-fee] [-np no-prepay] [-dry dry-run] [-wl warm-lead] [-es early-start] [-nc no-clock-sync] [-ntp ntp-server] [-ba burst-attempts] [-bs burst-spacing] [-bw burst-window] [-pd parallel-details]
             
             This command sends a reservation request
             at a specified date down to the minute.
             The res is for a venue specified by the id in the 
             -v field, party size specified by the
-            -ps field, reservation day specified
-            in the -resD field(in YYYY:MM:DD format
-            relative to the restaurant locale), 
+            -ps field, priority list of reservation
+            days specified in the -resD field(each in
+            YYYY:MM:DD format relative to the 
+            restaurant locale, or a YYYY:MM:DD-YYYY:MM:DD
+            range of days, where every time on a day 
+            outranks the times on the days after it),
             priority list of reservation times
             specified in the -resT field(each in HH:MM 
             military time format relative to the 
-            restaurant locale), and the date to send
+            restaurant locale, or a HH:MM-HH:MM window
+            which takes the slot closest to its middle,
+            or to the time after an @ as in
+            18:30-20:00@19:15, or the @earliest or 
+            @latest slot in it), and the date to send
             the request to resy in the -reqD field
             (in YYYY:MM:DD:HH:MM miliatry time format
-            relative to the local locale).
+            relative to the local locale). With -u, the
+            operation keeps polling every -ui(HH:MM,
+            a minute by default) for a slot higher on
+            the table and time priority lists after it
+            books. Once a better slot is confirmed the
+            earlier booking is cancelled, never before.
+            Any sizes after the first in -ps are 
+            alternates, tried in order only once the
+            preferred size has no table at any day, time
+            or seating, and the result shows the size 
+            booked if it isn't the preferred one. 
+            -sel picks the order open slots are tried
+            in: table-first(the default) tries every
+            time on a table type before the next table
+            type, time-first tries every table type at
+            a time before the next time, and 
+            weighted:time=1,window=30,seating=10 tries 
+            the lowest weighted sum first, out of time,
+            window, seating, fee and party weights.
+            -t is the priority list of seating to book,
+            each a word or phrase that must be whole 
+            words of the venue's seating name, so bar
+            takes "Main Bar" but not "Barrel Room", or
+            exact:, contains: or regex: followed by a 
+            pattern for the whole name, any part of it,
+            or a regular expression. -xt lists seating,
+            in the same form, never to book.
+            -mf skips slots whose deposit or 
+            cancellation fee is above the amount given,
+            and -np skips slots which take a deposit or
+            prepayment. If nothing else can be booked
+            the operation fails saying which slot was
+            turned down and its fees. With -dry, the
+            operation does everything but book: it logs
+            in, finds and picks a slot and fetches its
+            details, then succeeds as "Would Have Booked"
+            with the slot it picked. It can't be combined
+            with -u. A few seconds before the request date
+            the operation logs in again and opens its
+            connections to the service, so the requests at
+            the drop go out on warm connections. -wl sets
+            how many seconds ahead this happens, 5 by
+            default, and -wl 0 turns it off. The request
+            date is taken to be on the service's clock: 30
+            seconds ahead the operation measures how far
+            the service's clock is from ours, from the
+            Date headers of its responses and from the NTP
+            server in the -ntp field if given, and fires
+            on the corrected time. 'list' shows the offset
+            and how sure it is. -nc skips this and fires
+            on our clock, and -es sends the request the
+            given milliseconds early. Drops can open a
+            second or two late, so -ba sends up to that
+            many reserve requests from the request date,
+            -bs milliseconds apart(250 by default), until
+            one books, stopping once -bw milliseconds have
+            passed since the first if given. -pd fetches
+            the booking details of that many of the best
+            slots at once instead of one after another,
+            then books the best of them that can be
+            booked. Only one slot is ever booked.
 
-        5. rais [-v venue-id] [-ps party-size] [-resD reservation-day] [-resT reservation-times] [-i interval] 
+        5. rais [-v venue-id] [-ps party-size] [-resD reservation-day] [-resT reservation-times] [-i interval] [-u upgrade] [-t table] [-xt exclude-table] [-sel selector] [-mf max-fee] [-np no-prepay] [-dry dry-run] [-pd parallel-details]
             
             This command sends a reservation request
             on a repeated interval until a time is
             acquired or all possible times are past.
             The res is for a venue specified by the id in the 
             -v field, party size specified by the
-            -ps field, reservation day specified
-            in the -resD field(in YYYY:MM:DD format
-            relative to the restaurant locale), 
+            -ps field, priority list of reservation
+            days specified in the -resD field and
             priority list of reservation times
-            specified in the -resT field(each in HH:MM 
-            military time format relative to the 
-            restaurant locale), and the interval to send
+            specified in the -resT field(each as in 
+            rats), alternate party sizes, seating,
+            slot selector and fee limits as in rats,
+            and the interval to send
             the request to resy in the -i field
-            (in HH:MM format).
+            (in HH:MM format). With -u, the operation
+            keeps hunting for a better slot on the same
+            interval after it books, like rats -u.
+            Days which have passed are dropped from
+            the list as it goes. Slots turned down for
+            their fees are retried like taken ones.
+            -dry rehearses the operation as in rats,
+            ending at the first slot it could book, and
+            -pd works as in rats.
 
         6. list
             
             This command lists a history of operations, their IDs,
-            and statuses
+            and statuses. A failed operation the service turned
+            down shows the step, HTTP status and the service's
+            error code, followed by the start of its response
 
         7. cancel [-i id]
             
@@ -115,11 +203,153 @@ How to Use(On Computer):
             from the history displayed by the list command. This
             will only work on operations that are not in progress.
             
-        9. help 
+        9. webhook [-u url] [-s secret] [-r retries]
+
+            This command subscribes the urls in the -u field
+            to the outcome of every operation scheduled after
+            it. Each outcome is posted as JSON, signed with
+            the secret in the -s field if given, and failed
+            deliveries are retried -r times(3 by default)
+
+        10. email [-a addr] [-f from] [-t to] [-u user] [-p password] [-s subject] [-b body] [-ap allow-plain]
+
+            This command subscribes the recipients in the -t
+            field to emails about the outcome of every
+            operation scheduled after it. Mail is sent through
+            the SMTP server in the -a field(host:port), upgraded
+            with STARTTLS unless -ap is given, authenticating
+            with -u and -p if given. The -s and -b fields are
+            go templates over the event fields
+
+        11. notify test
+
+            Send a test event through every webhook and email
+            notifier to check their configuration
+
+        12. hook [-c command] [-ev events] [-to timeout]
+
+            This command runs the shell command in the -c field
+            whenever an operation scheduled after it succeeds,
+            fails, is cancelled or would have booked in a dry
+            run, or a watch sees a slot change or ends
+            (or only on the events listed
+            in the -ev field). The event is passed to the command
+            as RESOLVED_* environment variables and as JSON on 
+            stdin, and the command is killed after -to seconds
+            (30 by default)
+
+        13. hook output [-i id]
+
+            This command prints the exit code and captured
+            output of each hook run for the operation with
+            the id in the -i field
+
+        14. export-ics [-f file] [-i id] [-pr provider-reservations] [-e email] [-p password]
+
+            This command writes the reservations booked by the
+            successful operations with ids in the -i field(all 
+            successful operations if omitted) to the iCalendar
+            file in the -f field. With -pr, the upcoming 
+            reservations on the account are exported too, using
+            the login defaults unless -e and -p are given
+
+        15. conflict-policy [-p policy] [-b buffer]
+
+            This command sets how operations scheduled after it
+            treat reservation times closer than the buffer in the
+            -b field(hh:mm, 02:00 by default) to a booking made by
+            another operation, a reservation on the account, or a
+            candidate time of another pending operation. With the
+            warn policy(the default) overlaps are recorded and
+            shown by list, with refuse an overlapping rats or rais
+            is rejected and times overlapping a booking are skipped,
+            and with ignore nothing is checked
+
+        16. conflicts [-i id]
+
+            This command prints the overlaps found for each of
+            the operations with ids in the -i field, both when
+            it was scheduled and when it booked
+
+        17. watch [-v venue-id] [-ps party-size] [-resD reservation-day] [-resT reservation-times] [-i interval]
+
+            This command polls the open slots at a venue every
+            -i(HH:MM) for the window between the two times in
+            the -resT field, without ever booking. Each slot
+            that appears or disappears between polls is sent
+            to the notifiers and hooks as a slot.appeared or
+            slot.disappeared event. Slots open at the first 
+            poll count as appeared, and a watch that runs to
+            the end of its window sends a watch.ended event.
+            The -i interval must be more than zero
+
+        18. watch changes [-i id]
+
+            This command prints the slots open at the latest
+            poll and every change seen so far for each of
+            the watch operations with ids in the -i field
+
+        19. seating [-v venue-id] [-ps party-size] [-resD reservation-day] [-resT reservation-time]
+
+            This command lists the seating types a venue has
+            open on the -resD day(YYYY:MM:DD), as the service
+            names them, with how many slots each has open and
+            the first and last of them. Use it to write the
+            -t and -xt patterns of rats and rais. Services 
+            which only list slots near a time look around 
+            -resT(HH:MM, 19:00 by default)
+
+        20. trace [-i id] [-o file]
+
+            This command prints the timing of every request the
+            operation with id in the -i field has made, grouped
+            by attempt, an attempt being one pass from login to
+            book. Each request shows its step, status and total
+            time, split into DNS, connect, TLS and time to first
+            byte, or notes that a kept-alive connection was
+            reused, followed by any retries of the request with
+            why it failed and how long was waited. With -o it instead writes the trace to the
+            file in the -o field as OpenTelemetry JSON
+
+        21. loglevel [-l level] [-f file] [-fmt format]
+
+            This command sets the lowest level logged in the
+            -l field(debug, info, warn or error, or off), 
+            sends logs to the file in the -f field, appending
+            to it, or back to the error output with -f -, and
+            writes them as text or json per the -fmt field.
+            Operations tag their logs with their id and venue
+            id. Logs go to the error output at warn by default,
+            and with no flags this prints the current level
+
+        22. metrics [-a address] [-s]
+
+            This command serves Prometheus metrics at /metrics
+            on the host:port in the -a field, 127.0.0.1:9090 by
+            default, in the background. The metrics count the
+            requests made to the service per step with their
+            errors and latency, and the operations by status
+            and outcome. With no flags it prints where metrics
+            are served, and -s stops serving them
+
+        23. record [-i id] [-o file]
+
+            This command starts recording every request the
+            operation with the id in the -i field makes to the
+            service and the response it got, retries included.
+            The id may be of an operation not scheduled yet, so
+            its first requests are caught too. Other operations
+            are never recorded onto it. With -o it stops and saves
+            what was recorded to the file in the -o field as a
+            cassette, with secrets redacted, to attach to a bug
+            report. A cassette can be replayed with
+            api.NewReplayClient
+
+        24. help 
 
             Display helpful info about commands    
 
-        10. exit/quit 
+        25. exit/quit 
             
             Leave the CLI environment 
  
@@ -8,11 +8,14 @@ import (
     "bufio"
     "io"
     "fmt"
+    "log/slog"
     "strconv"
     "strings"
     "github.com/21Bruce/resolved-server/app"
     "github.com/21Bruce/resolved-server/api"
     "github.com/21Bruce/resolved-server/cli"
+    "github.com/21Bruce/resolved-server/metrics"
+    "net/http"
     "os"
     "errors"
     "time"
@@ -23,6 +26,33 @@ var (
     ErrInvDate = errors.New("invalid date format")
     // Error if we can't parse table type properly
     ErrInvTableType = errors.New("invalid table type")
+    // Error if we can't parse event type properly
+    ErrInvEventType = errors.New("invalid event type")
+    // Error if we can't parse conflict policy properly
+    ErrInvPolicy = errors.New("invalid conflict policy")
+    // Error if we can't parse slot selector properly
+    ErrInvSelector = errors.New("invalid slot selector")
+    // Error if we can't parse log level properly
+    ErrInvLogLevel = errors.New("invalid log level")
+    // Error if logging can't be configured
+    ErrNoLogHandler = errors.New("no log handler configured")
+    // Error if a metrics endpoint is already being served
+    ErrMetricsRunning = errors.New("metrics are already being served")
+    // Error if there is no metrics endpoint to stop
+    ErrNoMetrics = errors.New("metrics are not being served")
+    // Error if requests can't be recorded
+    ErrNoRecorder = errors.New("no recorder configured")
+    // Error if an operation is already being recorded
+    ErrRecording = errors.New("already recording this operation")
+    // Error if there is no recording to save
+    ErrNoRecording = errors.New("not recording this operation")
+    // Error if a repeat interval isn't positive
+    ErrInvInterval = errors.New("invalid repeat interval")
+)
+
+const (
+    // Where the 'metrics' command serves if no address is given
+    DefaultMetricsAddr = "127.0.0.1:9090"
 )
 
 /*
@@ -36,6 +66,17 @@ type ResolvedCLI struct {
     In          io.Reader
     Out         io.Writer
     Err         io.Writer
+    // Handler behind the app and api loggers, which
+    // the 'loglevel' command reconfigures
+    LogHandler  *app.LogHandler
+    // Recorder under the api's client, usually
+    // api.DefaultRecorder, which the 'record'
+    // command starts and stops per operation
+    Recorder    *api.Recorder
+    // Log file opened by 'loglevel', if any
+    logFile     *os.File
+    // Metrics endpoint started by 'metrics', if any
+    metricsServer   *http.Server
     parseCtx    cli.ParseCtx
 }
 
@@ -165,75 +206,24 @@ func (c *ResolvedCLI) parseRats(in map[string][]string) (*app.ReserveAtTimeParam
     if in["p"] != nil {
         req.Login.Password = in["p"][0]
     }
-    if in["t"] != nil {
-	    req.TableTypes = make([]api.TableType, len(in["t"]), len(in["t"])) 
-	    for i := 0; i < len(in["t"]); i++ {
-            currType := strings.ToLower(in["t"][i])
-	        if strings.Contains(currType, string(api.DiningRoom)) {
-                req.TableTypes[i] = api.DiningRoom
-	        } else if strings.Contains(currType, string(api.Indoor)) {
-                req.TableTypes[i] = api.Indoor
-	        } else if strings.Contains(currType, string(api.Outdoor)) {
-                req.TableTypes[i] = api.Outdoor
-	        } else if strings.Contains(currType, string(api.Patio)) {
-                req.TableTypes[i] = api.Patio
-	        } else if strings.Contains(currType, string(api.Bar)) {
-                req.TableTypes[i] = api.Bar
-	        } else if strings.Contains(currType, string(api.Lounge)) {
-                req.TableTypes[i] = api.Lounge
-	        } else if strings.Contains(currType, string(api.Booth)) {
-                req.TableTypes[i] = api.Booth
-	        } else {
-                return nil, ErrInvTableType
-            }
-	    }	
-    }
-    id, err := strconv.ParseInt(in["v"][0], 10, 64)
-    if err != nil {
-        return nil, err
-    }
-    req.VenueID = id
-    rawResDay := in["resD"][0]
-    resDaySplt := strings.Split(rawResDay, ":")
-    if len(resDaySplt) != 3 {
-        return nil, ErrInvDate
-    }
-
-    reqYear, err := strconv.Atoi(resDaySplt[0])
+    var err error
+    req.TableTypes, req.ExcludeTableTypes, err = parseTableTypes(in["t"], in["xt"])
     if err != nil {
         return nil, err
     }
-    reqMonth, err := strconv.Atoi(resDaySplt[1])
+    id, err := strconv.ParseInt(in["v"][0], 10, 64)
     if err != nil {
         return nil, err
     }
-    reqDay, err := strconv.Atoi(resDaySplt[2])
+    req.VenueID = id
+    req.ReservationTimes, req.TimeWindows, err = parseDayWindows(in["resD"], in["resT"])
     if err != nil {
         return nil, err
     }
-
-    req.ReservationTimes = make([]time.Time, len(in["resT"]), len(in["resT"]))
-    for i, timeStr := range in["resT"] {
-        timeSplt := strings.Split(timeStr, ":")        
-        if len(timeSplt) != 2 {
-            return nil, ErrInvDate
-        }
-        reqHour, err := strconv.Atoi(timeSplt[0])
-        if err != nil {
-            return nil, err
-        }
-        reqMin, err := strconv.Atoi(timeSplt[1])
-        if err != nil {
-            return nil, err
-        }
-        req.ReservationTimes[i] = time.Date(reqYear, time.Month(reqMonth), reqDay, reqHour, reqMin, 0, 0, time.Local)
-
-    }
-    ps, err := strconv.ParseInt(in["ps"][0], 10, 64)
+    req.PartySize, req.AltPartySizes, err = parsePartySizes(in["ps"])
     if err != nil {
         return nil, err
     }
-    req.PartySize = int(ps)
     rawReqDate := in["reqD"][0]
     reqDateSplt := strings.Split(rawReqDate, ":")
 
@@ -264,9 +254,372 @@ func (c *ResolvedCLI) parseRats(in map[string][]string) (*app.ReserveAtTimeParam
     timeLoc := time.Date(int(year), time.Month(int(month)), int(day), int(hour), int(minute), 0, 0, time.Local)
     timeUTC := timeLoc.UTC()
     req.RequestTime = timeUTC
+    if in["u"] != nil {
+        req.Upgrade = true
+    }
+    if in["sel"] != nil {
+        req.Selector, err = parseSelector(in["sel"][0])
+        if err != nil {
+            return nil, err
+        }
+    }
+    if in["mf"] != nil {
+        req.FeeLimit.LimitFee = true
+        req.FeeLimit.MaxFee, err = strconv.ParseFloat(in["mf"][0], 64)
+        if err != nil {
+            return nil, err
+        }
+    }
+    if in["np"] != nil {
+        req.FeeLimit.NoPrepay = true
+    }
+    if in["dry"] != nil {
+        req.DryRun = true
+    }
+    if in["ui"] != nil {
+        rawUpInt := in["ui"][0]
+        upIntSplt := strings.Split(rawUpInt, ":")
+        if len(upIntSplt) != 2 {
+            return nil, ErrInvDate
+        }
+        upHour, err := strconv.Atoi(upIntSplt[0])
+        if err != nil {
+            return nil, err
+        }
+        upMin, err := strconv.Atoi(upIntSplt[1])
+        if err != nil {
+            return nil, err
+        }
+        req.UpgradeInterval = time.Hour * time.Duration(upHour) + time.Minute * time.Duration(upMin)
+    }
+    if in["wl"] != nil {
+        warmSecs, err := strconv.Atoi(in["wl"][0])
+        if err != nil {
+            return nil, err
+        }
+        // zero turns warming off, which the app
+        // takes as a negative lead
+        req.WarmLead = time.Duration(warmSecs) * time.Second
+        if warmSecs == 0 {
+            req.WarmLead = -1
+        }
+    }
+    if in["es"] != nil {
+        earlyMs, err := strconv.Atoi(in["es"][0])
+        if err != nil {
+            return nil, err
+        }
+        req.EarlyStart = time.Duration(earlyMs) * time.Millisecond
+    }
+    if in["nc"] != nil {
+        req.NoClockSync = true
+    }
+    if in["ntp"] != nil {
+        req.NTPServer = in["ntp"][0]
+    }
+    if in["ba"] != nil {
+        req.Burst.Attempts, err = strconv.Atoi(in["ba"][0])
+        if err != nil {
+            return nil, err
+        }
+    }
+    if in["bs"] != nil {
+        spacingMs, err := strconv.Atoi(in["bs"][0])
+        if err != nil {
+            return nil, err
+        }
+        req.Burst.Spacing = time.Duration(spacingMs) * time.Millisecond
+    }
+    if in["bw"] != nil {
+        windowMs, err := strconv.Atoi(in["bw"][0])
+        if err != nil {
+            return nil, err
+        }
+        req.Burst.Window = time.Duration(windowMs) * time.Millisecond
+    }
+    if in["pd"] != nil {
+        req.ParallelDetails, err = strconv.Atoi(in["pd"][0])
+        if err != nil {
+            return nil, err
+        }
+    }
     return &req, nil
 }
 
+/*
+Name: parseClock
+Type: Internal Func
+Purpose: Parse an hh:mm time on the given day
+*/
+func parseClock(raw string, year int, month int, day int) (time.Time, error) {
+    timeSplt := strings.Split(raw, ":")
+    if len(timeSplt) != 2 {
+        return time.Time{}, ErrInvDate
+    }
+    hour, err := strconv.Atoi(timeSplt[0])
+    if err != nil {
+        return time.Time{}, err
+    }
+    min, err := strconv.Atoi(timeSplt[1])
+    if err != nil {
+        return time.Time{}, err
+    }
+    return time.Date(year, time.Month(month), day, hour, min, 0, 0, time.Local), nil
+}
+
+/*
+Name: parseTimeWindows
+Type: Internal Func
+Purpose: Parse the -resT field of rats and rais. Each
+entry is either an exact hh:mm time or a hh:mm-hh:mm
+window, optionally followed by @hh:mm to prefer the slot
+closest to that time, or @earliest/@latest. A window with
+no preference prefers its middle. The windows are only
+returned if some entry is a range, so a list of exact 
+times still goes through the plain times list
+*/
+func parseTimeWindows(raw []string, year int, month int, day int) ([]time.Time, []api.TimeWindow, error) {
+    times := make([]time.Time, len(raw))
+    windows := make([]api.TimeWindow, len(raw))
+    isRange := false
+    for i, entry := range raw {
+        pref := ""
+        if at := strings.Index(entry, "@"); at != -1 {
+            pref = strings.ToLower(entry[at+1:])
+            entry = entry[:at]
+        }
+        bounds := strings.Split(entry, "-")
+        if len(bounds) > 2 {
+            return nil, nil, ErrInvDate
+        }
+        start, err := parseClock(bounds[0], year, month, day)
+        if err != nil {
+            return nil, nil, err
+        }
+        window := api.PointWindow(start)
+        if len(bounds) == 2 {
+            end, err := parseClock(bounds[1], year, month, day)
+            if err != nil {
+                return nil, nil, err
+            }
+            if end.Before(start) {
+                return nil, nil, ErrInvDate
+            }
+            window = api.TimeWindow{Start: start, End: end}
+            isRange = true
+        }
+        switch pref {
+        case "":
+        case "earliest":
+            window.Policy = api.EarliestWindowPolicy
+        case "latest":
+            window.Policy = api.LatestWindowPolicy
+        default:
+            target, err := parseClock(pref, year, month, day)
+            if err != nil {
+                return nil, nil, err
+            }
+            window.Target = target
+        }
+        windows[i] = window
+        times[i] = window.Preferred()
+    }
+    if !isRange {
+        return times, nil, nil
+    }
+    return times, windows, nil
+}
+
+/*
+Name: parseTableTypes
+Type: Internal Func
+Purpose: Parse the -t and -xt fields of rats and rais
+into seating patterns. Each is a plain word or phrase
+matching whole words of a venue's seating name, or is
+prefixed by exact:, contains: or regex: as described in
+'api.TableType.Matches'
+*/
+func parseTableTypes(raw []string, rawExcludes []string) ([]api.TableType, []api.TableType, error) {
+    var tableTypes []api.TableType
+    for _, entry := range raw {
+        tableType := api.TableType(entry)
+        if tableType.Validate() != nil {
+            return nil, nil, ErrInvTableType
+        }
+        tableTypes = append(tableTypes, tableType)
+    }
+    var excludes []api.TableType
+    for _, entry := range rawExcludes {
+        exclude := api.TableType(entry)
+        if exclude.Validate() != nil {
+            return nil, nil, ErrInvTableType
+        }
+        excludes = append(excludes, exclude)
+    }
+    return tableTypes, excludes, nil
+}
+
+/*
+Name: parseSelector
+Type: Internal Func
+Purpose: Parse the -sel field of rats and rais, either
+table-first, time-first, or weighted followed by weights
+as in weighted:time=1,window=30,seating=10,fee=0.5,party=100
+where any weight left out is 0
+*/
+func parseSelector(raw string) (api.SlotSelector, error) {
+    raw = strings.ToLower(raw)
+    switch raw {
+    case "table", "table-first":
+        return api.TableFirstSelector{}, nil
+    case "time", "time-first":
+        return api.TimeFirstSelector{}, nil
+    }
+    if !strings.HasPrefix(raw, "weighted:") {
+        return nil, ErrInvSelector
+    }
+    selector := api.WeightedSelector{}
+    for _, entry := range strings.Split(strings.TrimPrefix(raw, "weighted:"), ",") {
+        pair := strings.Split(entry, "=")
+        if len(pair) != 2 {
+            return nil, ErrInvSelector
+        }
+        weight, err := strconv.ParseFloat(pair[1], 64)
+        if err != nil {
+            return nil, err
+        }
+        switch pair[0] {
+        case "time":
+            selector.TimeWeight = weight
+        case "window":
+            selector.WindowWeight = weight
+        case "seating":
+            selector.SeatingWeight = weight
+        case "fee":
+            selector.FeeWeight = weight
+        case "party":
+            selector.PartyWeight = weight
+        default:
+            return nil, ErrInvSelector
+        }
+    }
+    return selector, nil
+}
+
+/*
+Name: parsePartySizes
+Type: Internal Func
+Purpose: Parse the -ps field of rats and rais, the
+preferred party size followed by any alternates in
+the order to fall back on them
+*/
+func parsePartySizes(raw []string) (int, []int, error) {
+    sizes := make([]int, len(raw))
+    for i, entry := range raw {
+        size, err := strconv.Atoi(entry)
+        if err != nil {
+            return 0, nil, err
+        }
+        sizes[i] = size
+    }
+    return sizes[0], sizes[1:], nil
+}
+
+/*
+Name: parseDay
+Type: Internal Func
+Purpose: Parse a yyyy:mm:dd day
+*/
+func parseDay(raw string) (time.Time, error) {
+    daySplt := strings.Split(raw, ":")
+    if len(daySplt) != 3 {
+        return time.Time{}, ErrInvDate
+    }
+    year, err := strconv.Atoi(daySplt[0])
+    if err != nil {
+        return time.Time{}, err
+    }
+    month, err := strconv.Atoi(daySplt[1])
+    if err != nil {
+        return time.Time{}, err
+    }
+    day, err := strconv.Atoi(daySplt[2])
+    if err != nil {
+        return time.Time{}, err
+    }
+    return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.Local), nil
+}
+
+/*
+Name: parseDays
+Type: Internal Func
+Purpose: Parse the -resD field of rats and rais. Each
+entry is either a yyyy:mm:dd day or a yyyy:mm:dd-yyyy:mm:dd
+range of days, and the days are returned in the order 
+given, a range from its first day to its last. A day 
+listed twice only keeps its first place
+*/
+func parseDays(raw []string) ([]time.Time, error) {
+    days := []time.Time{}
+    seen := map[time.Time]bool{}
+    for _, entry := range raw {
+        bounds := strings.Split(entry, "-")
+        if len(bounds) > 2 {
+            return nil, ErrInvDate
+        }
+        start, err := parseDay(bounds[0])
+        if err != nil {
+            return nil, err
+        }
+        end := start
+        if len(bounds) == 2 {
+            end, err = parseDay(bounds[1])
+            if err != nil {
+                return nil, err
+            }
+            if end.Before(start) {
+                return nil, ErrInvDate
+            }
+        }
+        for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+            if !seen[day] {
+                seen[day] = true
+                days = append(days, day)
+            }
+        }
+    }
+    return days, nil
+}
+
+/*
+Name: parseDayWindows
+Type: Internal Func
+Purpose: Parse the -resD and -resT fields of rats and
+rais together. The -resT list is repeated on each day, 
+with every time on a day ranked above those on the days
+after it. As with parseTimeWindows, windows are only 
+returned if some -resT entry is a range
+*/
+func parseDayWindows(rawDays []string, rawTimes []string) ([]time.Time, []api.TimeWindow, error) {
+    days, err := parseDays(rawDays)
+    if err != nil {
+        return nil, nil, err
+    }
+    times := []time.Time{}
+    var windows []api.TimeWindow
+    for _, day := range days {
+        dayTimes, dayWindows, err := parseTimeWindows(rawTimes, day.Year(), int(day.Month()), day.Day())
+        if err != nil {
+            return nil, nil, err
+        }
+        times = append(times, dayTimes...)
+        if dayWindows != nil {
+            windows = append(windows, dayWindows...)
+        }
+    }
+    return times, windows, nil
+}
+
 /*
 Name: handleRats 
 Type: Internal Func
@@ -307,72 +660,24 @@ func (c *ResolvedCLI) parseRais(in map[string][]string) (*app.ReserveAtIntervalP
     if in["p"] != nil {
         req.Login.Password = in["p"][0]
     } 
-    if in["t"] != nil {
-	    req.TableTypes = make([]api.TableType, len(in["t"]), len(in["t"])) 
-	    for i := 0; i < len(in["t"]); i++ {
-            currType := in["t"][i]
-	        if strings.Contains(currType, string(api.DiningRoom)) {
-                req.TableTypes[i] = api.DiningRoom
-	        } else if strings.Contains(currType, string(api.Indoor)) {
-                req.TableTypes[i] = api.Indoor
-	        } else if strings.Contains(currType, string(api.Outdoor)) {
-                req.TableTypes[i] = api.Outdoor
-	        } else if strings.Contains(currType, string(api.Patio)) {
-                req.TableTypes[i] = api.Patio
-	        } else if strings.Contains(currType, string(api.Bar)) {
-                req.TableTypes[i] = api.Bar
-	        } else if strings.Contains(currType, string(api.Lounge)) {
-                req.TableTypes[i] = api.Lounge
-	        } else if strings.Contains(currType, string(api.Booth)) {
-                req.TableTypes[i] = api.Booth
-	        } else {
-                return nil, ErrInvTableType
-            }
-	    }	
-    }    
-    id, err := strconv.ParseInt(in["v"][0], 10, 64)
-    if err != nil {
-        return nil, err
-    }
-    req.VenueID = id
-    rawResDay := in["resD"][0]
-    resDaySplt := strings.Split(rawResDay, ":")
-    if len(resDaySplt) != 3 {
-        return nil, ErrInvDate
-    }
-    reqYear, err := strconv.Atoi(resDaySplt[0])
+    var err error
+    req.TableTypes, req.ExcludeTableTypes, err = parseTableTypes(in["t"], in["xt"])
     if err != nil {
         return nil, err
     }
-    reqMonth, err := strconv.Atoi(resDaySplt[1])
+    id, err := strconv.ParseInt(in["v"][0], 10, 64)
     if err != nil {
         return nil, err
     }
-    reqDay, err := strconv.Atoi(resDaySplt[2])
+    req.VenueID = id
+    req.ReservationTimes, req.TimeWindows, err = parseDayWindows(in["resD"], in["resT"])
     if err != nil {
         return nil, err
     }
-    req.ReservationTimes = make([]time.Time, len(in["resT"]), len(in["resT"]))
-    for i, timeStr := range in["resT"] {
-        timeSplt := strings.Split(timeStr, ":")        
-        if len(timeSplt) != 2 {
-            return nil, ErrInvDate
-        }
-        reqHour, err := strconv.Atoi(timeSplt[0])
-        if err != nil {
-            return nil, err
-        }
-        reqMin, err := strconv.Atoi(timeSplt[1])
-        if err != nil {
-            return nil, err
-        }
-        req.ReservationTimes[i] = time.Date(reqYear, time.Month(reqMonth), reqDay, reqHour, reqMin, 0, 0, time.Local)
-    }
-    ps, err := strconv.ParseInt(in["ps"][0], 10, 64)
+    req.PartySize, req.AltPartySizes, err = parsePartySizes(in["ps"])
     if err != nil {
         return nil, err
     }
-    req.PartySize = int(ps)
     rawRepInt := in["i"][0]
     repIntSplt := strings.Split(rawRepInt, ":")
 
@@ -389,7 +694,35 @@ func (c *ResolvedCLI) parseRais(in map[string][]string) (*app.ReserveAtIntervalP
         return nil, err
     }
     req.RepeatInterval = time.Hour * time.Duration(repHour) + time.Minute * time.Duration(repMin)
+    if in["u"] != nil {
+        req.Upgrade = true
+    }
+    if in["sel"] != nil {
+        req.Selector, err = parseSelector(in["sel"][0])
+        if err != nil {
+            return nil, err
+        }
+    }
+    if in["mf"] != nil {
+        req.FeeLimit.LimitFee = true
+        req.FeeLimit.MaxFee, err = strconv.ParseFloat(in["mf"][0], 64)
+        if err != nil {
+            return nil, err
+        }
+    }
+    if in["np"] != nil {
+        req.FeeLimit.NoPrepay = true
+    }
+    if in["dry"] != nil {
+        req.DryRun = true
+    }
 
+    if in["pd"] != nil {
+        req.ParallelDetails, err = strconv.Atoi(in["pd"][0])
+        if err != nil {
+            return nil, err
+        }
+    }
     return &req, nil
 }
 
@@ -519,91 +852,1456 @@ func (c *ResolvedCLI) handleClean(in map[string][]string) (string, error) {
 }
 
 /*
-Name: initParseCtx 
+Name: handleWebhook
 Type: Internal Func
-Purpose: This function initializes
-the parse ctx with the above handlers
-and command info
+Purpose: This function is the handler
+for the 'webhook' command, its goal is to
+subscribe a webhook notifier to the outcomes
+of operations scheduled afterwards
 */
-func (c *ResolvedCLI) initParseCtx() {
-    // 'search' command
-    searchCommand := cli.Command{
-        Name: "search",
-        Description: "Finds restaurant info",
-        Flags: []cli.Flag{
-            cli.Flag{
-                Name: "n",
-                LongName: "name",
-                Description: "This flag is required. It takes one text input, the name of the restaurant",
-                ValidationCtx: cli.FlagValidationCtx{
-                    Required: true,
-                    MinArgs: 1,
-                    MaxArgs: 1,
-                },
-            },
-            cli.Flag{
-                Name: "l",
-                LongName: "limit",
-                Description: "This flag is optional. It takes one number input, the max amount of results to return",
-                ValidationCtx: cli.FlagValidationCtx{
-                    Required: false,
-                    MinArgs: 1,
-                    MaxArgs: 1,
-                },
-            },
-        },
-        Handler: c.handleSearch,
+func (c *ResolvedCLI) handleWebhook(in map[string][]string) (string, error) {
+    secret := ""
+    if in["s"] != nil {
+        secret = in["s"][0]
+    }
+    notifier := app.NewWebhookNotifier(in["u"], secret)
+    if in["r"] != nil {
+        retries, err := strconv.Atoi(in["r"][0])
+        if err != nil {
+            return "", err
+        }
+        notifier.MaxRetries = retries
     }
+    c.AppCtx.AddNotifier(notifier)
+    return "Successfully Added Webhook", nil
+}
 
-    // 'rats' command
-    ratsCommand := cli.Command{
-        Name: "rats",
-        Description: "Reserve At Time Scheduler",
-        Flags: []cli.Flag{
+/*
+Name: handleEmail
+Type: Internal Func
+Purpose: This function is the handler
+for the 'email' command, its goal is to
+subscribe an SMTP notifier to the outcomes
+of operations scheduled afterwards
+*/
+func (c *ResolvedCLI) handleEmail(in map[string][]string) (string, error) {
+    notifier := app.NewEmailNotifier(in["a"][0], in["f"][0], in["t"])
+    if in["u"] != nil {
+        notifier.Username = in["u"][0]
+    }
+    if in["p"] != nil {
+        notifier.Password = in["p"][0]
+        api.AddSecret(notifier.Password)
+    }
+    if in["s"] != nil {
+        notifier.Subject = in["s"][0]
+    }
+    if in["b"] != nil {
+        notifier.Body = in["b"][0]
+    }
+    if in["ap"] != nil {
+        notifier.RequireTLS = false
+    }
+    c.AppCtx.AddNotifier(notifier)
+    return "Successfully Added Email Notifier", nil
+}
+
+/*
+Name: handleNotifyTest
+Type: Internal Func
+Purpose: This function is the handler
+for the 'notify test' command, its goal is to
+send a test event through every notifier
+*/
+func (c *ResolvedCLI) handleNotifyTest(in map[string][]string) (string, error) {
+    err := c.AppCtx.TestNotifiers()
+    if err != nil {
+        return "", err
+    }
+    return "Successfully Sent Test Notification", nil
+}
+
+/*
+Name: parseEventType
+Type: Internal Func
+Purpose: Map a cli event name, either the
+short form like "failed" or the full form like
+"operation.failed", to its app event type
+*/
+func parseEventType(raw string) (app.EventType, error) {
+    eventTypes := []app.EventType{
+        app.SuccessEventType,
+        app.FailEventType,
+        app.CancelEventType,
+        app.WouldBookEventType,
+        app.SlotAppearedEventType,
+        app.SlotDisappearedEventType,
+        app.WatchEndedEventType,
+    }
+    raw = strings.ToLower(raw)
+    for _, eventType := range eventTypes {
+        if raw == string(eventType) || "operation." + raw == string(eventType) || "slot." + raw == string(eventType) || "watch." + raw == string(eventType) {
+            return eventType, nil
+        }
+    }
+    return "", ErrInvEventType
+}
+
+/*
+Name: handleHook
+Type: Internal Func
+Purpose: This function is the handler
+for the 'hook' command, its goal is to
+register a shell command to run on the 
+events of operations scheduled afterwards
+*/
+func (c *ResolvedCLI) handleHook(in map[string][]string) (string, error) {
+    hook := app.Hook{Command: in["c"][0]}
+    for _, rawEvent := range in["ev"] {
+        eventType, err := parseEventType(rawEvent)
+        if err != nil {
+            return "", err
+        }
+        hook.Events = append(hook.Events, eventType)
+    }
+    if in["to"] != nil {
+        secs, err := strconv.Atoi(in["to"][0])
+        if err != nil {
+            return "", err
+        }
+        hook.Timeout = time.Duration(secs) * time.Second
+    }
+    err := c.AppCtx.AddHook(hook)
+    if err != nil {
+        return "", err
+    }
+    return "Successfully Added Hook", nil
+}
+
+/*
+Name: handleHookOutput
+Type: Internal Func
+Purpose: This function is the handler
+for the 'hook output' command, its goal is
+to print what the hooks of an operation
+wrote when they ran
+*/
+func (c *ResolvedCLI) handleHookOutput(in map[string][]string) (string, error) {
+    id, err := strconv.ParseInt(in["i"][0], 10, 64)
+    if err != nil {
+        return "", err
+    }
+    hookRuns, err := c.AppCtx.OperationHookRuns(id)
+    if err != nil {
+        return "", err
+    }
+    if len(hookRuns) == 0 {
+        return "No Hook Runs", nil
+    }
+    retStr := "Hook Runs: \n"
+    for _, hookRun := range hookRuns {
+        retStr += "\n\tCommand: " + hookRun.Command + "\n"
+        retStr += "\tEvent: " + string(hookRun.Event) + "\n"
+        retStr += "\tExit Code: " + strconv.Itoa(hookRun.ExitCode) + "\n"
+        retStr += "\tDuration: " + hookRun.Duration.String() + "\n"
+        if hookRun.Err != nil {
+            retStr += "\tError: " + hookRun.Err.Error() + "\n"
+        }
+        retStr += "\tOutput:\n" + hookRun.Output + "\n"
+    }
+    return retStr, nil
+}
+
+/*
+Name: millis
+Type: Internal Func
+Purpose: Format a duration as whole milliseconds
+*/
+func millis(d time.Duration) (string) {
+    return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
+
+/*
+Name: handleTrace
+Type: Internal Func
+Purpose: This function is the handler
+for the 'trace' command, its goal is to
+print the timing of each request an operation
+made, by attempt, or with -o to write it as
+OpenTelemetry JSON
+*/
+func (c *ResolvedCLI) handleTrace(in map[string][]string) (string, error) {
+    id, err := strconv.ParseInt(in["i"][0], 10, 64)
+    if err != nil {
+        return "", err
+    }
+    if in["o"] != nil {
+        file, err := os.Create(in["o"][0])
+        if err != nil {
+            return "", err
+        }
+        err = c.AppCtx.ExportOperationTrace(id, file)
+        if err != nil {
+            file.Close()
+            return "", err
+        }
+        err = file.Close()
+        if err != nil {
+            return "", err
+        }
+        return "Successfully Exported Trace", nil
+    }
+    spans, err := c.AppCtx.OperationTrace(id)
+    if err != nil {
+        return "", err
+    }
+    if len(spans) == 0 {
+        return "No Requests Traced", nil
+    }
+    retStr := "Trace: \n"
+    attempt := -1
+    for _, span := range spans {
+        if span.Attempt != attempt {
+            attempt = span.Attempt
+            retStr += "\n\tAttempt " + strconv.Itoa(attempt) + ", " + span.Start.Format("15:04:05.000") + "\n"
+        }
+        retStr += "\t\t" + span.Step + " " + span.Method + " " + span.URL
+        if span.Status != 0 {
+            retStr += " " + strconv.Itoa(span.Status)
+        }
+        retStr += ", " + millis(span.Duration) + " total"
+        if span.ConnReused {
+            retStr += ", reused connection"
+        } else {
+            retStr += ", dns " + millis(span.DNS) + ", connect " + millis(span.Connect) + ", tls " + millis(span.TLS)
+        }
+        retStr += ", first byte " + millis(span.FirstByte) + "\n"
+        for _, retry := range span.Retries {
+            retStr += "\t\tRetried: " + string(retry.Class)
+            if retry.Status != 0 {
+                retStr += " " + strconv.Itoa(retry.Status)
+            }
+            retStr += ", waited " + millis(retry.Wait) + "\n"
+        }
+        if span.Err != "" {
+            retStr += "\t\tError: " + span.Err + "\n"
+        }
+    }
+    return retStr, nil
+}
+
+/*
+Name: handleExportICS
+Type: Internal Func
+Purpose: This function is the handler
+for the 'export-ics' command, its goal is to
+write the reservations made by operations,
+and optionally those on the account, to an
+iCalendar file
+*/
+func (c *ResolvedCLI) handleExportICS(in map[string][]string) (string, error) {
+    ids := make([]int64, len(in["i"]))
+    for i, idStr := range in["i"] {
+        id, err := strconv.ParseInt(idStr, 10, 64)
+        if err != nil {
+            return "", err
+        }
+        ids[i] = id
+    }
+    reservations, err := c.AppCtx.OperationReservations(ids)
+    if err != nil {
+        return "", err
+    }
+    if in["pr"] != nil {
+        login := app.LoginParam{}
+        if in["e"] != nil {
+            login.Email = in["e"][0]
+        }
+        if in["p"] != nil {
+            login.Password = in["p"][0]
+        }
+        providerReservations, err := c.AppCtx.ProviderReservations(login)
+        if err != nil {
+            return "", err
+        }
+        // a booking made by an op also shows up on the account 
+        for _, providerReservation := range providerReservations {
+            isDup := false
+            for _, reservation := range reservations {
+                if reservation.ReservationID != "" && reservation.ReservationID == providerReservation.ReservationID {
+                    isDup = true
+                    break
+                }
+            }
+            if !isDup {
+                reservations = append(reservations, providerReservation)
+            }
+        }
+    }
+    file, err := os.Create(in["f"][0])
+    if err != nil {
+        return "", err
+    }
+    err = app.WriteICS(file, reservations)
+    if err != nil {
+        file.Close()
+        return "", err
+    }
+    err = file.Close()
+    if err != nil {
+        return "", err
+    }
+    return "Successfully Exported " + strconv.Itoa(len(reservations)) + " Reservations", nil
+}
+
+/*
+Name: handleConflictPolicy
+Type: Internal Func
+Purpose: This function is the handler
+for the 'conflict-policy' command, its goal
+is to set how new operations treat times that
+overlap other bookings and operations
+*/
+func (c *ResolvedCLI) handleConflictPolicy(in map[string][]string) (string, error) {
+    var policy app.ConflictPolicy
+    switch strings.ToLower(in["p"][0]) {
+    case "warn":
+        policy = app.WarnConflictPolicy
+    case "refuse":
+        policy = app.RefuseConflictPolicy
+    case "ignore":
+        policy = app.IgnoreConflictPolicy
+    default:
+        return "", ErrInvPolicy
+    }
+    buffer := app.DefaultConflictBuffer
+    if in["b"] != nil {
+        bufferSplt := strings.Split(in["b"][0], ":")
+        if len(bufferSplt) != 2 {
+            return "", ErrInvDate
+        }
+        bufferHour, err := strconv.Atoi(bufferSplt[0])
+        if err != nil {
+            return "", err
+        }
+        bufferMin, err := strconv.Atoi(bufferSplt[1])
+        if err != nil {
+            return "", err
+        }
+        buffer = time.Hour * time.Duration(bufferHour) + time.Minute * time.Duration(bufferMin)
+    }
+    c.AppCtx.SetConflictPolicy(policy, buffer)
+    return "Successfully Set Conflict Policy", nil
+}
+
+/*
+Name: handleConflicts
+Type: Internal Func
+Purpose: This function is the handler
+for the 'conflicts' command, its goal is to
+print the overlaps found for each operation
+given in the -i field
+*/
+func (c *ResolvedCLI) handleConflicts(in map[string][]string) (string, error) {
+    retStr := "Conflicts: \n"
+    for _, idStr := range in["i"] {
+        id, err := strconv.ParseInt(idStr, 10, 64)
+        if err != nil {
+            return "", err
+        }
+        conflicts, err := c.AppCtx.OperationConflicts(id)
+        if err != nil {
+            return "", err
+        }
+        retStr += "\n\tID: " + idStr + "\n"
+        if len(conflicts) == 0 {
+            retStr += "\t\tNone\n"
+        }
+        for _, conflict := range conflicts {
+            retStr += "\t\t" + conflict.String() + "\n"
+        }
+    }
+    return retStr, nil
+}
+
+/*
+Name: parseWatch
+Type: Internal Func
+Purpose: This function helps with parsing
+for the main 'watch' handler function
+*/
+func (c *ResolvedCLI) parseWatch(in map[string][]string) (*app.WatchParam, error) {
+    req := app.WatchParam{}
+    if in["e"] != nil {
+        req.Login.Email = in["e"][0]
+    }
+    if in["p"] != nil {
+        req.Login.Password = in["p"][0]
+    }
+    id, err := strconv.ParseInt(in["v"][0], 10, 64)
+    if err != nil {
+        return nil, err
+    }
+    req.VenueID = id
+    ps, err := strconv.ParseInt(in["ps"][0], 10, 64)
+    if err != nil {
+        return nil, err
+    }
+    req.PartySize = int(ps)
+    resDaySplt := strings.Split(in["resD"][0], ":")
+    if len(resDaySplt) != 3 {
+        return nil, ErrInvDate
+    }
+    reqYear, err := strconv.Atoi(resDaySplt[0])
+    if err != nil {
+        return nil, err
+    }
+    reqMonth, err := strconv.Atoi(resDaySplt[1])
+    if err != nil {
+        return nil, err
+    }
+    reqDay, err := strconv.Atoi(resDaySplt[2])
+    if err != nil {
+        return nil, err
+    }
+    // the window is given as a start and end time
+    req.Start, err = parseClock(in["resT"][0], reqYear, reqMonth, reqDay)
+    if err != nil {
+        return nil, err
+    }
+    req.End, err = parseClock(in["resT"][1], reqYear, reqMonth, reqDay)
+    if err != nil {
+        return nil, err
+    }
+    repIntSplt := strings.Split(in["i"][0], ":")
+    if len(repIntSplt) != 2 {
+        return nil, ErrInvDate
+    }
+    repHour, err := strconv.Atoi(repIntSplt[0])
+    if err != nil {
+        return nil, err
+    }
+    repMin, err := strconv.Atoi(repIntSplt[1])
+    if err != nil {
+        return nil, err
+    }
+    req.RepeatInterval = time.Hour * time.Duration(repHour) + time.Minute * time.Duration(repMin)
+    if repHour < 0 || repMin < 0 || req.RepeatInterval <= 0 {
+        return nil, ErrInvInterval
+    }
+    return &req, nil
+}
+
+/*
+Name: handleWatch
+Type: Internal Func
+Purpose: This function is the handler
+for the 'watch' command. Its goal is to
+take the values defined in each flag field
+and schedule a watch operation in the AppCtx
+*/
+func (c *ResolvedCLI) handleWatch(in map[string][]string) (string, error) {
+    req, err := c.parseWatch(in)
+    if err != nil {
+        return "", err
+    }
+    id, err := c.AppCtx.ScheduleWatchOperation(*req)
+    if err != nil {
+        return "", err
+    }
+    idstr := strconv.FormatInt(id, 10)
+    retstr := "Successfully started watch operation with ID " + idstr
+    return retstr, nil
+}
+
+/*
+Name: handleWatchChanges
+Type: Internal Func
+Purpose: This function is the handler
+for the 'watch changes' command, its goal is
+to print the slots open and the slot changes
+seen by each watch given in the -i field
+*/
+func (c *ResolvedCLI) handleWatchChanges(in map[string][]string) (string, error) {
+    retStr := "Slot Changes: \n"
+    for _, idStr := range in["i"] {
+        id, err := strconv.ParseInt(idStr, 10, 64)
+        if err != nil {
+            return "", err
+        }
+        changes, slots, err := c.AppCtx.OperationSlotChanges(id)
+        if err != nil {
+            return "", err
+        }
+        retStr += "\n\tID: " + idStr + "\n"
+        retStr += "\t\tOpen:"
+        if len(slots) == 0 {
+            retStr += " None"
+        }
+        for _, slot := range slots {
+            retStr += " " + slot.Time.Format("15:04")
+            if slot.TableType != "" {
+                retStr += "(" + slot.TableType + ")"
+            }
+        }
+        retStr += "\n"
+        for _, change := range changes {
+            retStr += "\t\t" + change.String() + "\n"
+        }
+    }
+    return retStr, nil
+}
+
+/*
+Name: handleSeating
+Type: Internal Func
+Purpose: This function is the handler
+for the 'seating' command, its goal is to
+list the seating types a venue has open
+on a day, as the service names them
+*/
+func (c *ResolvedCLI) handleSeating(in map[string][]string) (string, error) {
+    req := app.SeatingParam{}
+    if in["e"] != nil {
+        req.Login.Email = in["e"][0]
+    }
+    if in["p"] != nil {
+        req.Login.Password = in["p"][0]
+    }
+    id, err := strconv.ParseInt(in["v"][0], 10, 64)
+    if err != nil {
+        return "", err
+    }
+    req.VenueID = id
+    ps, err := strconv.ParseInt(in["ps"][0], 10, 64)
+    if err != nil {
+        return "", err
+    }
+    req.PartySize = int(ps)
+    req.Day, err = parseDay(in["resD"][0])
+    if err != nil {
+        return "", err
+    }
+    // services that list slots near a time look
+    // around the evening by default
+    req.Day = req.Day.Add(19 * time.Hour)
+    if in["resT"] != nil {
+        req.Day, err = parseClock(in["resT"][0], req.Day.Year(), int(req.Day.Month()), req.Day.Day())
+        if err != nil {
+            return "", err
+        }
+    }
+    resp, err := c.AppCtx.SeatingTypes(req)
+    if err != nil {
+        return "", err
+    }
+    retStr := "Seating Types"
+    if resp.VenueName != "" {
+        retStr += " at " + resp.VenueName
+    }
+    retStr += ": \n"
+    if len(resp.SeatingTypes) == 0 {
+        retStr += "\tNone open\n"
+    }
+    for _, seating := range resp.SeatingTypes {
+        retStr += "\t" + seating.String() + "\n"
+    }
+    return retStr, nil
+}
+
+/*
+Name: parseLogLevel
+Type: Internal Func
+Purpose: Map a cli log level name to its slog level
+*/
+func parseLogLevel(raw string) (slog.Level, error) {
+    switch strings.ToLower(raw) {
+    case "debug":
+        return slog.LevelDebug, nil
+    case "info":
+        return slog.LevelInfo, nil
+    case "warn":
+        return slog.LevelWarn, nil
+    case "error":
+        return slog.LevelError, nil
+    case "off":
+        return app.OffLogLevel, nil
+    }
+    return 0, ErrInvLogLevel
+}
+
+/*
+Name: logLevelName
+Type: Internal Func
+Purpose: Map a slog level back to its cli name
+*/
+func logLevelName(level slog.Level) (string) {
+    if level >= app.OffLogLevel {
+        return "off"
+    }
+    return strings.ToLower(level.String())
+}
+
+/*
+Name: handleLogLevel
+Type: Internal Func
+Purpose: This function is the handler
+for the 'loglevel' command, its goal is to
+set the lowest level logged and where and
+how logs are written, or with no flags to
+print the current level
+*/
+func (c *ResolvedCLI) handleLogLevel(in map[string][]string) (string, error) {
+    if c.LogHandler == nil {
+        return "", ErrNoLogHandler
+    }
+    if in["l"] == nil && in["f"] == nil && in["fmt"] == nil {
+        return "Log level is " + logLevelName(c.LogHandler.Level()), nil
+    }
+    var level slog.Level
+    var err error
+    if in["l"] != nil {
+        level, err = parseLogLevel(in["l"][0])
+        if err != nil {
+            return "", err
+        }
+    }
+    if in["f"] != nil || in["fmt"] != nil {
+        // keep whichever of the two isn't being changed
+        out, format := c.LogHandler.Output()
+        if in["fmt"] != nil {
+            format = app.LogFormat(strings.ToLower(in["fmt"][0]))
+        }
+        file := c.logFile
+        if in["f"] != nil {
+            file = nil
+            out = c.Err
+            if in["f"][0] != "-" {
+                file, err = os.OpenFile(in["f"][0], os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+                if err != nil {
+                    return "", err
+                }
+                out = file
+            }
+        }
+        err = c.LogHandler.SetOutput(out, format)
+        if err != nil {
+            if file != nil && file != c.logFile {
+                file.Close()
+            }
+            return "", err
+        }
+        // the handler has moved on, so the old
+        // file can be let go of
+        if c.logFile != nil && c.logFile != file {
+            c.logFile.Close()
+        }
+        c.logFile = file
+    }
+    if in["l"] != nil {
+        c.LogHandler.SetLevel(level)
+    }
+    return "Successfully Set Logging, level is " + logLevelName(c.LogHandler.Level()), nil
+}
+
+/*
+Name: handleMetrics
+Type: Internal Func
+Purpose: This function is the handler
+for the 'metrics' command, its goal is to
+start or stop serving Prometheus metrics, or
+with no flags to say where they are served
+*/
+func (c *ResolvedCLI) handleMetrics(in map[string][]string) (string, error) {
+    if in["s"] != nil {
+        if c.metricsServer == nil {
+            return "", ErrNoMetrics
+        }
+        err := c.metricsServer.Close()
+        c.metricsServer = nil
+        if err != nil {
+            return "", err
+        }
+        return "Successfully Stopped Serving Metrics", nil
+    }
+    if c.metricsServer != nil {
+        if in["a"] != nil {
+            return "", ErrMetricsRunning
+        }
+        return "Serving metrics at http://" + c.metricsServer.Addr + "/metrics", nil
+    }
+    addr := DefaultMetricsAddr
+    if in["a"] != nil {
+        addr = in["a"][0]
+    }
+    c.AppCtx.RegisterMetrics(metrics.Default)
+    server, err := metrics.Serve(addr, metrics.Default)
+    if err != nil {
+        return "", err
+    }
+    c.metricsServer = server
+    return "Serving metrics at http://" + server.Addr + "/metrics", nil
+}
+
+/*
+Name: handleRecord
+Type: Internal Func
+Purpose: This function is the handler
+for the 'record' command, its goal is to
+start recording the requests made by the
+operation given in the -i field, or with -o
+to stop and write them to a cassette file
+*/
+func (c *ResolvedCLI) handleRecord(in map[string][]string) (string, error) {
+    if c.Recorder == nil {
+        return "", ErrNoRecorder
+    }
+    id, err := strconv.ParseInt(in["i"][0], 10, 64)
+    if err != nil {
+        return "", err
+    }
+    if in["o"] != nil {
+        cassette := c.Recorder.Stop(id)
+        if cassette == nil {
+            return "", ErrNoRecording
+        }
+        err := api.SaveCassette(in["o"][0], cassette)
+        if err != nil {
+            return "", err
+        }
+        return "Successfully Saved " + strconv.Itoa(len(cassette.Interactions)) + " Requests", nil
+    }
+    if c.Recorder.Recording(id) {
+        return "", ErrRecording
+    }
+    c.Recorder.Start(id)
+    return "Recording Requests of Operation " + strconv.FormatInt(id, 10), nil
+}
+
+/*
+Name: initParseCtx 
+Type: Internal Func
+Purpose: This function initializes
+the parse ctx with the above handlers
+and command info
+*/
+func (c *ResolvedCLI) initParseCtx() {
+    // 'search' command
+    searchCommand := cli.Command{
+        Name: "search",
+        Description: "Finds restaurant info",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "n",
+                LongName: "name",
+                Description: "This flag is required. It takes one text input, the name of the restaurant",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "l",
+                LongName: "limit",
+                Description: "This flag is optional. It takes one number input, the max amount of results to return",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+        },
+        Handler: c.handleSearch,
+    }
+
+    // 'rats' command
+    ratsCommand := cli.Command{
+        Name: "rats",
+        Description: "Reserve At Time Scheduler",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "e",
+                LongName: "email",
+                Description: "This flag is optional if already logged in using Login command. Specifies login email",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "p",
+                LongName: "password",
+                Description: "This flag is optional if already logged in using Login command. Specifies login password",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "v",
+                LongName: "venue-id",
+                Description: "This flag is required. Specifies the venueu id(use search to find by name)",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+	        cli.Flag{
+		        Name: "t",
+		        LongName: "table",
+		        Description: "This flag is optional. Used to set the type of table in order of preference. Each is a word or phrase matching whole words of the venue's seating name, such as dining, patio or bar, or is prefixed by exact:, contains: or regex: to match the whole name, any part of it, or a regular expression. The 'seating' command lists the names a venue uses",
+		        ValidationCtx: cli.FlagValidationCtx{
+		            Required: false,
+		            MinArgs: 1,
+		            MaxArgs: cli.InfiniteArgs, 
+		        },
+	        },
+            cli.Flag{
+                Name: "xt",
+                LongName: "exclude-table",
+                Description: "This flag is optional. Seating patterns, written as in -t, that are never booked",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "resD",
+                LongName: "reservation-day",
+                Description: "This flag is required. Specifies the priority day list for the reservation, each a yyyy:mm:dd day or a yyyy:mm:dd-yyyy:mm:dd range of days",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "resT",
+                LongName: "reservation-times",
+                Description: "This flag is required. Specifies the priority time list for the reservation, each an hh:mm time or an hh:mm-hh:mm window, optionally followed by @hh:mm to prefer the closest slot to a time or by @earliest or @latest",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "reqD",
+                LongName: "request-date",
+                Description: "This flag is required. Specifies the date to send request in yyyy:mm:dd:hh:mm format",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "ps",
+                LongName: "party-size",
+                Description: "This flag is required. Specifies the size of party, optionally followed by alternate sizes to fall back on in priority order",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "u",
+                LongName: "upgrade",
+                Description: "This flag is optional. It takes no input and keeps the operation hunting for a better slot after it books, cancelling the earlier booking once a better one is confirmed",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "ui",
+                LongName: "upgrade-interval",
+                Description: "This flag is optional. Specifies how often to poll for a better slot in upgrade mode in hh:mm format, defaults to 00:01",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "mf",
+                LongName: "max-fee",
+                Description: "This flag is optional. Specifies the largest deposit or cancellation fee accepted, slots with larger fees are skipped",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "np",
+                LongName: "no-prepay",
+                Description: "This flag is optional. It takes no input and skips slots which take a deposit or prepayment when booking",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "dry",
+                LongName: "dry-run",
+                Description: "This flag is optional. It takes no input and runs the operation without booking, going through login, finding and picking a slot and fetching its details, then reporting the slot it would have booked",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "wl",
+                LongName: "warm-lead",
+                Description: "This flag is optional. Specifies how many seconds before the request time to log in again and open connections to the service, defaults to 5, 0 turns it off",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "es",
+                LongName: "early-start",
+                Description: "This flag is optional. Specifies how many milliseconds before the request date to send the request, defaults to 0",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "nc",
+                LongName: "no-clock-sync",
+                Description: "This flag is optional. It takes no input and times the request on this computer's clock instead of correcting it to the service's",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "ntp",
+                LongName: "ntp-server",
+                Description: "This flag is optional. Specifies an NTP server, such as pool.ntp.org, to also ask for the clock offset",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "ba",
+                LongName: "burst-attempts",
+                Description: "This flag is optional. Specifies how many reserve requests to send at the request date if nothing is bookable yet, defaults to 1",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "bs",
+                LongName: "burst-spacing",
+                Description: "This flag is optional. Specifies the milliseconds between the reserve requests of -ba, defaults to 250",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "bw",
+                LongName: "burst-window",
+                Description: "This flag is optional. Specifies the milliseconds after the first reserve request of -ba to stop sending more, defaults to no limit",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "pd",
+                LongName: "parallel-details",
+                Description: "This flag is optional. Specifies how many of the best open slots to fetch booking details for at once, the best of them that can be booked is booked and never more than one, defaults to 1",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "sel",
+                LongName: "selector",
+                Description: "This flag is optional. Specifies the order to try open slots in: table-first (the default) tries every time on a table type before the next one, time-first tries every table type at a time before the next time, and weighted:time=w,window=w,seating=w,fee=w,party=w tries the slots with the lowest weighted sum of minutes from the preferred time, window rank, table type rank, deposit and party size rank first",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+ 
+        },
+        Handler: c.handleRats,
+    }
+
+    // 'rais' command
+    raisCommand := cli.Command{
+        Name: "rais",
+        Description: "Reserve At Interval Scheduler",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "e",
+                LongName: "email",
+                Description: "This flag is optional if already logged in using Login command. Specifies login email",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "p",
+                LongName: "password",
+                Description: "This flag is optional if already logged in using Login command. Specifies login password",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "v",
+                LongName: "venue-id",
+                Description: "This flag is required. Specifies the venueu id(use search to find by name)",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+	        cli.Flag{
+		        Name: "t",
+		        LongName: "table",
+		        Description: "This flag is optional. Used to set the type of table in order of preference. Each is a word or phrase matching whole words of the venue's seating name, such as dining, patio or bar, or is prefixed by exact:, contains: or regex: to match the whole name, any part of it, or a regular expression. The 'seating' command lists the names a venue uses",
+		        ValidationCtx: cli.FlagValidationCtx{
+		            Required: false,
+		            MinArgs: 1,
+		            MaxArgs: cli.InfiniteArgs, 
+		        },
+	        },
+            cli.Flag{
+                Name: "xt",
+                LongName: "exclude-table",
+                Description: "This flag is optional. Seating patterns, written as in -t, that are never booked",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "resD",
+                LongName: "reservation-day",
+                Description: "This flag is required. Specifies the priority day list for the reservation, each a yyyy:mm:dd day or a yyyy:mm:dd-yyyy:mm:dd range of days",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "resT",
+                LongName: "reservation-times",
+                Description: "This flag is required. Specifies the priority time list for the reservation, each an hh:mm time or an hh:mm-hh:mm window, optionally followed by @hh:mm to prefer the closest slot to a time or by @earliest or @latest",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "i",
+                LongName: "interval",
+                Description: "This flag is required. Specifies the interval to send request on in hh:mm format",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "ps",
+                LongName: "party-size",
+                Description: "This flag is required. Specifies the size of party, optionally followed by alternate sizes to fall back on in priority order",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "u",
+                LongName: "upgrade",
+                Description: "This flag is optional. It takes no input and keeps the operation hunting for a better slot on the same interval after it books, cancelling the earlier booking once a better one is confirmed",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "mf",
+                LongName: "max-fee",
+                Description: "This flag is optional. Specifies the largest deposit or cancellation fee accepted, slots with larger fees are skipped",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "np",
+                LongName: "no-prepay",
+                Description: "This flag is optional. It takes no input and skips slots which take a deposit or prepayment when booking",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "dry",
+                LongName: "dry-run",
+                Description: "This flag is optional. It takes no input and runs the operation without booking, going through login, finding and picking a slot and fetching its details, then reporting the slot it would have booked",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "pd",
+                LongName: "parallel-details",
+                Description: "This flag is optional. Specifies how many of the best open slots to fetch booking details for at once, the best of them that can be booked is booked and never more than one, defaults to 1",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "sel",
+                LongName: "selector",
+                Description: "This flag is optional. Specifies the order to try open slots in: table-first (the default) tries every time on a table type before the next one, time-first tries every table type at a time before the next time, and weighted:time=w,window=w,seating=w,fee=w,party=w tries the slots with the lowest weighted sum of minutes from the preferred time, window rank, table type rank, deposit and party size rank first",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+ 
+        },
+        Handler: c.handleRais,
+    }
+
+
+    // 'list' command
+    listCommand := cli.Command{
+        Name: "list",
+        Description: "List all operations",
+        Flags: []cli.Flag{},
+        Handler: c.handleList,
+    }
+
+    // 'login' command
+    loginCommand := cli.Command{
+        Name: "login",
+        Description: "Set login defaults",
+        Flags: []cli.Flag{
             cli.Flag{
                 Name: "e",
                 LongName: "email",
-                Description: "This flag is optional if already logged in using Login command. Specifies login email",
+                Description: "This flag is required. Provides login email",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MaxArgs: 1,
+                    MinArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "p",
+                LongName: "password",
+                Description: "This flag is required. Provides login password",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MaxArgs: 1,
+                    MinArgs: 1,
+                },
+            },
+        },
+        Handler: c.handleLogin,
+    }
+
+    // 'logout' command
+    logoutCommand := cli.Command{
+        Name: "logout",
+        Description: "Clear default login credentials",
+        Flags: []cli.Flag{},
+        Handler: c.handleLogout,
+    }
+
+    // 'cancel' command
+    cancelCommand := cli.Command{
+        Name: "cancel",
+        Description: "Cancel operations given ids",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "i",
+                LongName: "id",
+                Description: "This flag is required. It takes one to unmeasured number inputs, the ids of operations",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+        },
+        Handler: c.handleCancel,
+    }
+
+    // 'clean' command
+    cleanCommand := cli.Command{
+        Name: "clean",
+        Description: "Clean operations given ids",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "i",
+                LongName: "id",
+                Description: "This flag is required. It takes one to unmeasured number inputs, the ids of operations",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+        },
+        Handler: c.handleClean,
+    }
+
+    // 'webhook' command
+    webhookCommand := cli.Command{
+        Name: "webhook",
+        Description: "Post operation outcomes to webhook urls",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "u",
+                LongName: "url",
+                Description: "This flag is required. It takes one to unmeasured number inputs, the urls to post to",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "s",
+                LongName: "secret",
+                Description: "This flag is optional. Specifies the secret used to HMAC-SHA256 sign each payload",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "r",
+                LongName: "retries",
+                Description: "This flag is optional. Specifies how many times a failed delivery is retried, defaults to 3",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+        },
+        Handler: c.handleWebhook,
+    }
+
+    // 'email' command
+    emailCommand := cli.Command{
+        Name: "email",
+        Description: "Email operation outcomes through an SMTP server",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "a",
+                LongName: "addr",
+                Description: "This flag is required. Specifies the SMTP server in host:port format",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "f",
+                LongName: "from",
+                Description: "This flag is required. Specifies the sender address",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "t",
+                LongName: "to",
+                Description: "This flag is required. It takes one to unmeasured number inputs, the recipient addresses",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+            cli.Flag{
+                Name: "u",
+                LongName: "user",
+                Description: "This flag is optional. Specifies the SMTP auth username",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "p",
+                LongName: "password",
+                Description: "This flag is optional. Specifies the SMTP auth password",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "s",
+                LongName: "subject",
+                Description: "This flag is optional. Specifies the subject as a go template over the event",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "b",
+                LongName: "body",
+                Description: "This flag is optional. Specifies the body as a go template over the event",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "ap",
+                LongName: "allow-plain",
+                Description: "This flag is optional. It takes no input and allows sending without STARTTLS",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+        },
+        Handler: c.handleEmail,
+    }
+
+    // 'notify test' command
+    notifyTestCommand := cli.Command{
+        Name: "notify test",
+        Description: "Send a test event through every notifier",
+        Flags: []cli.Flag{},
+        Handler: c.handleNotifyTest,
+    }
+
+    // 'hook' command
+    hookCommand := cli.Command{
+        Name: "hook",
+        Description: "Run a shell command when operations change state",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "c",
+                LongName: "command",
+                Description: "This flag is required. It takes one text input, the shell command to run. Event details are passed as RESOLVED_* env vars and JSON on stdin",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "ev",
+                LongName: "events",
+                Description: "This flag is optional. Limits the hook to the listed events. The available events are succeeded, failed, cancelled, would_have_booked, appeared, disappeared, and ended",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: false,
                     MinArgs: 1,
-                    MaxArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
                 },
             },
             cli.Flag{
-                Name: "p",
-                LongName: "password",
-                Description: "This flag is optional if already logged in using Login command. Specifies login password",
+                Name: "to",
+                LongName: "timeout",
+                Description: "This flag is optional. Specifies the seconds the command may run before it is killed, defaults to 30",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: false,
                     MinArgs: 1,
                     MaxArgs: 1,
                 },
             },
+        },
+        Handler: c.handleHook,
+    }
+
+    // 'hook output' command
+    hookOutputCommand := cli.Command{
+        Name: "hook output",
+        Description: "Show the output of the hooks run for an operation",
+        Flags: []cli.Flag{
             cli.Flag{
-                Name: "v",
-                LongName: "venue-id",
-                Description: "This flag is required. Specifies the venueu id(use search to find by name)",
+                Name: "i",
+                LongName: "id",
+                Description: "This flag is required. It takes one number input, the id of the operation",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
                     MaxArgs: 1,
                 },
             },
-	        cli.Flag{
-		        Name: "t",
-		        LongName: "table",
-		        Description: "This flag is optional. Used to set the type of table in order of preference. The available types are dining, patio, bar, lounge, indoor, and outdoor",
-		        ValidationCtx: cli.FlagValidationCtx{
-		            Required: false,
-		            MinArgs: 1,
-		            MaxArgs: cli.InfiniteArgs, 
-		        },
-	        },
+        },
+        Handler: c.handleHookOutput,
+    }
+
+    // 'trace' command
+    traceCommand := cli.Command{
+        Name: "trace",
+        Description: "Show how long each request an operation made took",
+        Flags: []cli.Flag{
             cli.Flag{
-                Name: "resD",
-                LongName: "reservation-day",
-                Description: "This flag is required. Specifies the day for the reservation in yyyy:mm:dd format",
+                Name: "i",
+                LongName: "id",
+                Description: "This flag is required. It takes one number input, the id of the operation",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
@@ -611,44 +2309,130 @@ func (c *ResolvedCLI) initParseCtx() {
                 },
             },
             cli.Flag{
-                Name: "resT",
-                LongName: "reservation-times",
-                Description: "This flag is required. Specifies the priority time list for the reservation in hh:mm format",
+                Name: "o",
+                LongName: "otel",
+                Description: "This flag is optional. It takes one text input, a file to write the trace to as OpenTelemetry JSON instead of printing it",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+        },
+        Handler: c.handleTrace,
+    }
+
+    // 'export-ics' command
+    exportICSCommand := cli.Command{
+        Name: "export-ics",
+        Description: "Export booked reservations as an iCalendar file",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "f",
+                LongName: "file",
+                Description: "This flag is required. It takes one text input, the path of the .ics file to write",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "i",
+                LongName: "id",
+                Description: "This flag is optional. It takes one to unmeasured number inputs, the ids of successful operations to export. Defaults to all successful operations",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
                     MaxArgs: cli.InfiniteArgs,
                 },
             },
             cli.Flag{
-                Name: "reqD",
-                LongName: "request-date",
-                Description: "This flag is required. Specifies the date to send request in yyyy:mm:dd:hh:mm format",
+                Name: "pr",
+                LongName: "provider-reservations",
+                Description: "This flag is optional. It takes no input and also exports the upcoming reservations on the account",
                 ValidationCtx: cli.FlagValidationCtx{
-                    Required: true,
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
+                },
+            },
+            cli.Flag{
+                Name: "e",
+                LongName: "email",
+                Description: "This flag is optional if already logged in using Login command. Specifies login email for -pr",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
                     MinArgs: 1,
                     MaxArgs: 1,
                 },
             },
             cli.Flag{
-                Name: "ps",
-                LongName: "party-size",
-                Description: "This flag is required. Specifies the size of party",
+                Name: "p",
+                LongName: "password",
+                Description: "This flag is optional if already logged in using Login command. Specifies login password for -pr",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+        },
+        Handler: c.handleExportICS,
+    }
+
+    // 'conflict-policy' command
+    conflictPolicyCommand := cli.Command{
+        Name: "conflict-policy",
+        Description: "Set how new operations treat overlapping reservations",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "p",
+                LongName: "policy",
+                Description: "This flag is required. It takes one text input, the policy. The available policies are warn, refuse, and ignore",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
                     MaxArgs: 1,
                 },
             },
- 
+            cli.Flag{
+                Name: "b",
+                LongName: "buffer",
+                Description: "This flag is optional. Specifies in hh:mm format how close two reservations may be before they conflict, defaults to 02:00",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
         },
-        Handler: c.handleRats,
+        Handler: c.handleConflictPolicy,
     }
 
-    // 'rais' command
-    raisCommand := cli.Command{
-        Name: "rais",
-        Description: "Reserve At Interval Scheduler",
+    // 'conflicts' command
+    conflictsCommand := cli.Command{
+        Name: "conflicts",
+        Description: "Show overlaps found for operations given ids",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "i",
+                LongName: "id",
+                Description: "This flag is required. It takes one to unmeasured number inputs, the ids of operations",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+        },
+        Handler: c.handleConflicts,
+    }
+
+    // 'watch' command
+    watchCommand := cli.Command{
+        Name: "watch",
+        Description: "Watch for open slots without booking",
         Flags: []cli.Flag{
             cli.Flag{
                 Name: "e",
@@ -673,27 +2457,17 @@ func (c *ResolvedCLI) initParseCtx() {
             cli.Flag{
                 Name: "v",
                 LongName: "venue-id",
-                Description: "This flag is required. Specifies the venueu id(use search to find by name)",
+                Description: "This flag is required. Specifies the venue id(use search to find by name)",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
                     MaxArgs: 1,
                 },
             },
-	        cli.Flag{
-		        Name: "t",
-		        LongName: "table",
-		        Description: "This flag is optional. Used to set the type of table in order of preference. The available types are dining, patio, bar, lounge, indoor, and outdoor",
-		        ValidationCtx: cli.FlagValidationCtx{
-		            Required: false,
-		            MinArgs: 1,
-		            MaxArgs: cli.InfiniteArgs, 
-		        },
-	        },
             cli.Flag{
                 Name: "resD",
                 LongName: "reservation-day",
-                Description: "This flag is required. Specifies the day for the reservation in yyyy:mm:dd format",
+                Description: "This flag is required. Specifies the day to watch in yyyy:mm:dd format",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
@@ -703,17 +2477,17 @@ func (c *ResolvedCLI) initParseCtx() {
             cli.Flag{
                 Name: "resT",
                 LongName: "reservation-times",
-                Description: "This flag is required. Specifies the priority time list for the reservation in hh:mm format",
+                Description: "This flag is required. Specifies the start and end of the time window to watch in hh:mm format",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
-                    MinArgs: 1,
-                    MaxArgs: cli.InfiniteArgs,
+                    MinArgs: 2,
+                    MaxArgs: 2,
                 },
             },
             cli.Flag{
                 Name: "i",
                 LongName: "interval",
-                Description: "This flag is required. Specifies the interval to send request on in hh:mm format",
+                Description: "This flag is required. Specifies the interval to poll on in hh:mm format",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
@@ -730,93 +2504,193 @@ func (c *ResolvedCLI) initParseCtx() {
                     MaxArgs: 1,
                 },
             },
- 
         },
-        Handler: c.handleRais,
+        Handler: c.handleWatch,
     }
 
-
-    // 'list' command
-    listCommand := cli.Command{
-        Name: "list",
-        Description: "List all operations",
-        Flags: []cli.Flag{},
-        Handler: c.handleList,
+    // 'watch changes' command
+    watchChangesCommand := cli.Command{
+        Name: "watch changes",
+        Description: "Show slot changes seen by watch operations given ids",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "i",
+                LongName: "id",
+                Description: "This flag is required. It takes one to unmeasured number inputs, the ids of watch operations",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: cli.InfiniteArgs,
+                },
+            },
+        },
+        Handler: c.handleWatchChanges,
     }
 
-    // 'login' command
-    loginCommand := cli.Command{
-        Name: "login",
-        Description: "Set login defaults",
+    // 'seating' command
+    seatingCommand := cli.Command{
+        Name: "seating",
+        Description: "List the seating types a venue has open on a day",
         Flags: []cli.Flag{
             cli.Flag{
                 Name: "e",
                 LongName: "email",
-                Description: "This flag is required. Provides login email",
+                Description: "This flag is optional if already logged in using Login command. Specifies login email",
                 ValidationCtx: cli.FlagValidationCtx{
-                    Required: true,
-                    MaxArgs: 1,
+                    Required: false,
                     MinArgs: 1,
+                    MaxArgs: 1,
                 },
             },
             cli.Flag{
                 Name: "p",
                 LongName: "password",
-                Description: "This flag is required. Provides login password",
+                Description: "This flag is optional if already logged in using Login command. Specifies login password",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "v",
+                LongName: "venue-id",
+                Description: "This flag is required. Specifies the venue id(use search to find by name)",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "resD",
+                LongName: "reservation-day",
+                Description: "This flag is required. Specifies the day to list in yyyy:mm:dd format",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "resT",
+                LongName: "reservation-time",
+                Description: "This flag is optional. Specifies in hh:mm format the time to look around for services which only list slots near a time, defaults to 19:00",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
                     MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "ps",
+                LongName: "party-size",
+                Description: "This flag is required. Specifies the size of party",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: true,
                     MinArgs: 1,
+                    MaxArgs: 1,
                 },
             },
         },
-        Handler: c.handleLogin,
+        Handler: c.handleSeating,
     }
 
-    // 'logout' command
-    logoutCommand := cli.Command{
-        Name: "logout",
-        Description: "Clear default login credentials",
-        Flags: []cli.Flag{},
-        Handler: c.handleLogout,
+    // 'loglevel' command
+    logLevelCommand := cli.Command{
+        Name: "loglevel",
+        Description: "Set or show how much the bot logs and where",
+        Flags: []cli.Flag{
+            cli.Flag{
+                Name: "l",
+                LongName: "level",
+                Description: "This flag is optional. It takes one text input, the lowest level logged. The available levels are debug, info, warn, error, and off",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "f",
+                LongName: "file",
+                Description: "This flag is optional. Specifies a file to append logs to, or - to log to the error output",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "fmt",
+                LongName: "format",
+                Description: "This flag is optional. It takes one text input, the log format. The available formats are text(the default) and json",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
+                },
+            },
+        },
+        Handler: c.handleLogLevel,
     }
 
-    // 'cancel' command
-    cancelCommand := cli.Command{
-        Name: "cancel",
-        Description: "Cancel operations given ids",
+    // 'metrics' command
+    metricsCommand := cli.Command{
+        Name: "metrics",
+        Description: "Serve Prometheus metrics over HTTP, or show where they are served",
         Flags: []cli.Flag{
             cli.Flag{
-                Name: "i",
-                LongName: "id",
-                Description: "This flag is required. It takes one to unmeasured number inputs, the ids of operations",
+                Name: "a",
+                LongName: "address",
+                Description: "This flag is optional. It takes one text input, the host:port to serve metrics on. Defaults to " + DefaultMetricsAddr,
                 ValidationCtx: cli.FlagValidationCtx{
-                    Required: true,
+                    Required: false,
                     MinArgs: 1,
-                    MaxArgs: cli.InfiniteArgs,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "s",
+                LongName: "stop",
+                Description: "This flag is optional. It takes no input and stops serving metrics",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 0,
+                    MaxArgs: 0,
                 },
             },
         },
-        Handler: c.handleCancel,
+        Handler: c.handleMetrics,
     }
 
-    // 'clean' command
-    cleanCommand := cli.Command{
-        Name: "clean",
-        Description: "Clean operations given ids",
+    // 'record' command
+    recordCommand := cli.Command{
+        Name: "record",
+        Description: "Record the requests an operation makes, or save them to a cassette file",
         Flags: []cli.Flag{
             cli.Flag{
                 Name: "i",
                 LongName: "id",
-                Description: "This flag is required. It takes one to unmeasured number inputs, the ids of operations",
+                Description: "This flag is required. It takes one number input, the id of the operation to record, which may not be scheduled yet",
                 ValidationCtx: cli.FlagValidationCtx{
                     Required: true,
                     MinArgs: 1,
-                    MaxArgs: cli.InfiniteArgs,
+                    MaxArgs: 1,
+                },
+            },
+            cli.Flag{
+                Name: "o",
+                LongName: "output",
+                Description: "This flag is optional. It takes one text input, the file to save the requests of the operation recorded so far to, which stops recording it. Without it recording starts",
+                ValidationCtx: cli.FlagValidationCtx{
+                    Required: false,
+                    MinArgs: 1,
+                    MaxArgs: 1,
                 },
             },
         },
-        Handler: c.handleClean,
+        Handler: c.handleRecord,
     }
 
     // 'quit' command
@@ -856,6 +2730,21 @@ func (c *ResolvedCLI) initParseCtx() {
             logoutCommand,
             ratsCommand,
             raisCommand,
+            webhookCommand,
+            emailCommand,
+            notifyTestCommand,
+            hookCommand,
+            hookOutputCommand,
+            exportICSCommand,
+            conflictPolicyCommand,
+            conflictsCommand,
+            watchCommand,
+            watchChangesCommand,
+            seatingCommand,
+            traceCommand,
+            logLevelCommand,
+            metricsCommand,
+            recordCommand,
             quitCommand,
             exitCommand,
             helpCommand,
@@ -883,16 +2772,21 @@ func (c *ResolvedCLI) Run() (error) {
         if err := scanner.Err(); err != nil {
             fmt.Fprintln(c.Err, err);
         }
-        // parse input 
+        // parse input, scrubbing secrets from whatever
+        // is printed back
         result, err := c.parseCtx.Parse(scanner.Text()) 
         if err != nil {
             fmt.Fprint(c.Err, "ERROR: ")
-            fmt.Fprintln(c.Err, err)
+            fmt.Fprintln(c.Err, api.Redact(err.Error()))
+            // show what the service said, when it said why
+            var providerErr *api.ProviderError
+            if errors.As(err, &providerErr) && providerErr.Body != "" {
+                fmt.Fprintln(c.Err, "\tResponse: " + providerErr.Body)
+            }
         } else  {
-            fmt.Fprintln(c.Out, result) 
+            fmt.Fprintln(c.Out, api.Redact(result)) 
         }
     }
-    return nil
 }
 
 